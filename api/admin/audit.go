@@ -0,0 +1,162 @@
+package admin
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+var auditCSVHeader = []string{
+	"id", "created_at", "level", "event", "status", "reason", "actor", "actor_id",
+	"target_user_id", "team_id", "ip", "request_id", "message", "fields", "prev_hash", "hash",
+}
+
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+// auditQuery applies the filters shared by listing and export.
+func auditQuery(ctx *gin.Context) (*gorm.DB, bool) {
+	query := models.DB.WithContext(ctx).Model(&models.AuditEvent{})
+	for param, column := range map[string]string{"event": "event", "status": "status"} {
+		if v := ctx.Query(param); v != "" {
+			query = query.Where(column+" = ?", v)
+		}
+	}
+	if v := ctx.Query("user_id"); v != "" {
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid user_id"})
+			return nil, false
+		}
+		query = query.Where("actor_id = ? OR target_user_id = ?", userID, userID)
+	}
+	if v := ctx.Query("team_id"); v != "" {
+		teamID, err := strconv.Atoi(v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid team_id"})
+			return nil, false
+		}
+		query = query.Where("team_id = ?", teamID)
+	}
+	for param, op := range map[string]string{"from": ">=", "to": "<"} {
+		if v := ctx.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid " + param + ", expected RFC 3339"})
+				return nil, false
+			}
+			query = query.Where("created_at "+op+" ?", t)
+		}
+	}
+	return query, true
+}
+
+func listAuditEvents(ctx *gin.Context) {
+	query, ok := auditQuery(ctx)
+	if !ok {
+		return
+	}
+	switch ctx.Query("format") {
+	case "csv":
+		exportAuditCSV(ctx, query)
+		return
+	case "jsonl":
+		exportAuditJSONL(ctx, query)
+		return
+	}
+	limit := defaultAuditLimit
+	if v := ctx.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = min(n, maxAuditLimit)
+		}
+	}
+	if v := ctx.Query("before_id"); v != "" {
+		beforeID, err := strconv.Atoi(v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid before_id"})
+			return
+		}
+		query = query.Where("id < ?", beforeID)
+	}
+	var events []models.AuditEvent
+	if err := query.Order("id DESC").Limit(limit).Find(&events).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch audit events"})
+		return
+	}
+	resp := auditListResponse{Events: events}
+	if len(events) == limit {
+		resp.NextBeforeID = &events[len(events)-1].ID
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// streamAudit hands matching events to write in ID order, a batch at a time.
+func streamAudit(query *gorm.DB, write func(models.AuditEvent) error) error {
+	var batch []models.AuditEvent
+	return query.Order("id").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, e := range batch {
+			if err := write(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+func exportAuditCSV(ctx *gin.Context, query *gorm.DB) {
+	ctx.Header("Content-Type", "text/csv")
+	ctx.Header("Content-Disposition", `attachment; filename="audit.csv"`)
+	w := csv.NewWriter(ctx.Writer)
+	w.Write(auditCSVHeader)
+	err := streamAudit(query, func(e models.AuditEvent) error {
+		return w.Write([]string{
+			strconv.FormatUint(uint64(e.ID), 10),
+			e.CreatedAt.UTC().Format(time.RFC3339Nano),
+			e.Level, e.Event, e.Status, e.Reason, e.Actor,
+			optionalID(e.ActorID), optionalID(e.TargetUserID), optionalID(e.TeamID),
+			e.IP, e.RequestID, e.Message, e.Fields, e.PrevHash, e.Hash,
+		})
+	})
+	w.Flush()
+	if err != nil {
+		ctx.Error(err)
+	}
+}
+
+func exportAuditJSONL(ctx *gin.Context, query *gorm.DB) {
+	ctx.Header("Content-Type", "application/x-ndjson")
+	ctx.Header("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	enc := json.NewEncoder(ctx.Writer)
+	if err := streamAudit(query, func(e models.AuditEvent) error { return enc.Encode(e) }); err != nil {
+		ctx.Error(err)
+	}
+}
+
+func verifyAuditChain(ctx *gin.Context) {
+	checked, brokenAt, err := models.VerifyAuditChain()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to verify the audit chain"})
+		return
+	}
+	resp := auditVerifyResponse{Valid: brokenAt == 0, Checked: checked}
+	if brokenAt != 0 {
+		resp.BrokenAt = &brokenAt
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
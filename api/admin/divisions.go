@@ -0,0 +1,229 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func loadDivision(ctx *gin.Context) (models.Division, bool) {
+	var division models.Division
+	divisionID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid division ID"})
+		return division, false
+	}
+	if err := models.DB.WithContext(ctx).First(&division, divisionID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Division not found"})
+		return division, false
+	}
+	return division, true
+}
+
+func listDivisions(ctx *gin.Context) {
+	var divisions []models.Division
+	if err := models.DB.WithContext(ctx).Order("id").Find(&divisions).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch divisions"})
+		return
+	}
+	ctx.JSON(http.StatusOK, divisions)
+}
+
+func createDivision(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	var req createDivisionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to parse the body"})
+		return
+	}
+	division := models.Division{
+		Name:           req.Name,
+		Description:    req.Description,
+		TeamSize:       req.TeamSize,
+		EmailRegex:     req.EmailRegex,
+		OAuthProviders: req.OAuthProviders,
+	}
+	if err := models.ValidateDivision(&division); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := models.DB.WithContext(ctx).Create(&division).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "A division with this name already exists"})
+			return
+		}
+		auditLog.WithFields(logrus.Fields{
+			"event":  "admin_create_division",
+			"status": "failure",
+			"reason": "db_error",
+			"ip":     ctx.ClientIP(),
+			"error":  err.Error(),
+		}).Error("Failed to create division")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create division"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":       "admin_create_division",
+		"status":      "success",
+		"division_id": division.ID,
+		"name":        division.Name,
+		"ip":          ctx.ClientIP(),
+	}).Info("Division created")
+	ctx.JSON(http.StatusCreated, division)
+}
+
+// updateDivision changes the rules of a division. Members who no longer meet
+// them are left in place; the rules apply to whoever joins next.
+func updateDivision(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	division, ok := loadDivision(ctx)
+	if !ok {
+		return
+	}
+	var req updateDivisionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to parse the body"})
+		return
+	}
+	if req.Name != nil {
+		division.Name = *req.Name
+	}
+	if req.Description != nil {
+		division.Description = *req.Description
+	}
+	if req.TeamSize != nil {
+		division.TeamSize = *req.TeamSize
+	}
+	if req.EmailRegex != nil {
+		division.EmailRegex = *req.EmailRegex
+	}
+	if req.OAuthProviders != nil {
+		division.OAuthProviders = req.OAuthProviders
+	}
+	if err := models.ValidateDivision(&division); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := models.DB.WithContext(ctx).Save(&division).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "A division with this name already exists"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update division"})
+		return
+	}
+	// cached teams carry their division, name and all
+	var teamIDs []uint
+	if err := models.DB.WithContext(ctx).Model(&models.Team{}).Where("division_id = ?", division.ID).Pluck("id", &teamIDs).Error; err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":       "admin_update_division",
+			"status":      "partial",
+			"reason":      "db_error",
+			"division_id": division.ID,
+			"ip":          ctx.ClientIP(),
+			"error":       err.Error(),
+		}).Warn("Failed to list the division's teams; cached teams show the old division until they expire")
+	}
+	for _, teamID := range teamIDs {
+		shared.ForgetTeam(ctx, teamID)
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":       "admin_update_division",
+		"status":      "success",
+		"division_id": division.ID,
+		"name":        division.Name,
+		"ip":          ctx.ClientIP(),
+	}).Info("Division updated")
+	ctx.JSON(http.StatusOK, division)
+}
+
+func deleteDivision(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	division, ok := loadDivision(ctx)
+	if !ok {
+		return
+	}
+	var teams int64
+	if err := models.DB.WithContext(ctx).Model(&models.Team{}).Where("division_id = ?", division.ID).Count(&teams).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if teams > 0 {
+		ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "Move the division's teams elsewhere first"})
+		return
+	}
+	if err := models.DB.WithContext(ctx).Delete(&division).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to delete division"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":       "admin_delete_division",
+		"status":      "success",
+		"division_id": division.ID,
+		"name":        division.Name,
+		"ip":          ctx.ClientIP(),
+	}).Info("Division deleted")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Division deleted"})
+}
+
+// moveTeamDivision puts a team in another division, or in none. The move is
+// an admin decision, so neither the size nor the eligibility rules of the
+// new division are checked against the current members.
+func moveTeamDivision(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	teamID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+	var req moveTeamDivisionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to parse the body"})
+		return
+	}
+	var team models.Team
+	if err := models.DB.WithContext(ctx).First(&team, teamID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Team not found"})
+		return
+	}
+	var division models.Division
+	if req.DivisionID != nil {
+		if err := models.DB.WithContext(ctx).First(&division, *req.DivisionID).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Division not found"})
+			return
+		}
+	}
+	previous := team.DivisionID
+	if err := models.DB.WithContext(ctx).Model(&team).Update("division_id", req.DivisionID).Error; err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "admin_move_team_division",
+			"status":  "failure",
+			"reason":  "db_error",
+			"team_id": team.ID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Error("Failed to move team to another division")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to move team"})
+		return
+	}
+	shared.TeamCache.Delete(ctx, team.ID)
+	auditLog.WithFields(logrus.Fields{
+		"event":   "admin_move_team_division",
+		"status":  "success",
+		"team_id": team.ID,
+		"from":    previous,
+		"to":      req.DivisionID,
+		"ip":      ctx.ClientIP(),
+	}).Info("Team moved to another division")
+	events.Publish(events.TeamDivisionChanged, ctx.GetUint("user_id"), team.ID, map[string]any{"division_id": req.DivisionID, "division": division.Name})
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Team moved"})
+}
@@ -6,21 +6,96 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/intraware/rodan-authify/api/shared"
 	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
 )
 
+// setToggle flips a flag for one of the legacy open/close endpoints.
+func setToggle(ctx *gin.Context, name string, enabled bool, msg string) {
+	log := utils.AuditLog(ctx).WithFields(logrus.Fields{
+		"event":   "admin_update_flag",
+		"flag":    name,
+		"enabled": enabled,
+		"ip":      ctx.ClientIP(),
+	})
+	if err := shared.SetFlag(name, enabled); err != nil {
+		log.WithFields(logrus.Fields{
+			"status": "failure",
+			"reason": "db_error",
+			"error":  err.Error(),
+		}).Error("Failed to update feature flag")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update the flag"})
+		return
+	}
+	log.WithField("status", "success").Info("Feature flag updated")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: msg})
+}
+
 func closeLogin(ctx *gin.Context) {
-	shared.SetLogin(false)
-	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Login closed for everyone"})
+	setToggle(ctx, shared.FlagLogin, false, "Login closed for everyone")
 }
 func openLogin(ctx *gin.Context) {
-	shared.SetLogin(true)
-	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Login opened for everyone"})
+	setToggle(ctx, shared.FlagLogin, true, "Login opened for everyone")
 }
 func closeSignup(ctx *gin.Context) {
-	shared.SetSignup(false)
-	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Signup closed for everyone"})
+	setToggle(ctx, shared.FlagSignup, false, "Signup closed for everyone")
 }
 func openSignup(ctx *gin.Context) {
-	shared.SetSignup(true)
-	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Signup opened for everyone"})
+	setToggle(ctx, shared.FlagSignup, true, "Signup opened for everyone")
+}
+
+func listFlags(ctx *gin.Context) {
+	current := shared.Flags()
+	resp := make([]flagInfo, 0, len(current))
+	for _, name := range shared.FlagNames() {
+		resp = append(resp, flagInfo{Name: name, Enabled: current[name]})
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func updateFlag(ctx *gin.Context) {
+	name := ctx.Param("name")
+	if !shared.FlagExists(name) {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Unknown flag"})
+		return
+	}
+	var req updateFlagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to parse the body"})
+		return
+	}
+	setToggle(ctx, name, *req.Enabled, "Flag updated")
+}
+
+func getSchedule(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, shared.Schedule())
+}
+
+func updateSchedule(ctx *gin.Context) {
+	var schedule shared.EventSchedule
+	if err := ctx.ShouldBindJSON(&schedule); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to parse the body"})
+		return
+	}
+	if err := schedule.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := shared.SetSchedule(schedule); err != nil {
+		utils.AuditLog(ctx).WithFields(logrus.Fields{
+			"event":  "admin_update_schedule",
+			"status": "failure",
+			"reason": "db_error",
+			"ip":     ctx.ClientIP(),
+			"error":  err.Error(),
+		}).Error("Failed to persist the event schedule")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to save the schedule"})
+		return
+	}
+	utils.AuditLog(ctx).WithFields(logrus.Fields{
+		"event":  "admin_update_schedule",
+		"status": "success",
+		"ip":     ctx.ClientIP(),
+	}).Info("Event schedule updated")
+	ctx.JSON(http.StatusOK, schedule)
 }
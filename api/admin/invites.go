@@ -0,0 +1,147 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func buildInviteInfo(invite models.Invite) inviteInfo {
+	return inviteInfo{
+		ID:          invite.ID,
+		Code:        invite.Code,
+		MaxUses:     invite.MaxUses,
+		Uses:        invite.Uses,
+		ExpiresAt:   invite.ExpiresAt,
+		Email:       invite.Email,
+		TeamID:      invite.TeamID,
+		CreatedByID: invite.CreatedByID,
+		Revoked:     invite.Revoked,
+		CreatedAt:   invite.CreatedAt,
+	}
+}
+
+func listInvites(ctx *gin.Context) {
+	query := models.DB.WithContext(ctx).Order("created_at DESC")
+	if ctx.Query("active") == "true" {
+		query = query.Where("revoked = ? AND uses < max_uses AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)", false)
+	}
+	if teamID := ctx.Query("team_id"); teamID != "" {
+		query = query.Where("team_id = ?", teamID)
+	}
+	var invites []models.Invite
+	if err := query.Find(&invites).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch invites"})
+		return
+	}
+	resp := make([]inviteInfo, len(invites))
+	for i, invite := range invites {
+		resp[i] = buildInviteInfo(invite)
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func createInvite(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	var req createInviteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to parse the body"})
+		return
+	}
+	invite := models.Invite{
+		Code:      strings.TrimSpace(req.Code),
+		MaxUses:   req.MaxUses,
+		ExpiresAt: req.ExpiresAt,
+		Email:     req.Email,
+		TeamID:    req.TeamID,
+	}
+	if invite.MaxUses == 0 {
+		invite.MaxUses = 1
+	}
+	if userID := ctx.GetUint("user_id"); userID != 0 {
+		invite.CreatedByID = &userID
+	}
+	if invite.TeamID != nil {
+		var team models.Team
+		if err := models.DB.WithContext(ctx).First(&team, *invite.TeamID).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Team not found"})
+			return
+		}
+	}
+	if err := models.DB.WithContext(ctx).Create(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "Invite code already exists"})
+			return
+		}
+		auditLog.WithFields(logrus.Fields{
+			"event":  "admin_create_invite",
+			"status": "failure",
+			"reason": "db_error",
+			"ip":     ctx.ClientIP(),
+			"error":  err.Error(),
+		}).Error("Failed to create invite")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create invite"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":     "admin_create_invite",
+		"status":    "success",
+		"invite_id": invite.ID,
+		"max_uses":  invite.MaxUses,
+		"team_id":   invite.TeamID,
+		"ip":        ctx.ClientIP(),
+	}).Info("Invite created")
+	ctx.JSON(http.StatusCreated, buildInviteInfo(invite))
+}
+
+func revokeInvite(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	inviteID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid invite ID"})
+		return
+	}
+	result := models.DB.WithContext(ctx).Model(&models.Invite{}).Where("id = ?", inviteID).Update("revoked", true)
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to revoke invite"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Invite not found"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":     "admin_revoke_invite",
+		"status":    "success",
+		"invite_id": inviteID,
+		"ip":        ctx.ClientIP(),
+	}).Info("Invite revoked")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Invite revoked"})
+}
+
+func listInviteRedemptions(ctx *gin.Context) {
+	inviteID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid invite ID"})
+		return
+	}
+	var redemptions []inviteRedemptionInfo
+	if err := models.DB.WithContext(ctx).Table("invite_redemptions").
+		Select("invite_redemptions.user_id, users.username, users.email, invite_redemptions.ip, invite_redemptions.created_at AS redeemed_at").
+		Joins("LEFT JOIN users ON users.id = invite_redemptions.user_id").
+		Where("invite_redemptions.invite_id = ? AND invite_redemptions.deleted_at IS NULL", inviteID).
+		Order("invite_redemptions.created_at").
+		Scan(&redemptions).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch redemptions"})
+		return
+	}
+	ctx.JSON(http.StatusOK, redemptions)
+}
@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func buildAPIKeyInfo(key models.AdminAPIKey) apiKeyInfo {
+	return apiKeyInfo{
+		ID:          key.ID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Scopes:      key.Scopes,
+		CreatedByID: key.CreatedByID,
+		ExpiresAt:   key.ExpiresAt,
+		LastUsedAt:  key.LastUsedAt,
+		Revoked:     key.Revoked,
+		CreatedAt:   key.CreatedAt,
+	}
+}
+
+func listAPIKeys(ctx *gin.Context) {
+	var keys []models.AdminAPIKey
+	if err := models.DB.WithContext(ctx).Order("created_at DESC").Find(&keys).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch API keys"})
+		return
+	}
+	resp := make([]apiKeyInfo, len(keys))
+	for i, key := range keys {
+		resp[i] = buildAPIKeyInfo(key)
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func createAPIKey(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	var req createAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to parse the body"})
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !models.ValidPermission(scope) {
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Unknown scope: " + scope})
+			return
+		}
+	}
+	key := models.AdminAPIKey{
+		Name:      strings.TrimSpace(req.Name),
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if userID := ctx.GetUint("user_id"); userID != 0 {
+		key.CreatedByID = &userID
+	}
+	raw, err := models.NewAdminAPIKey(&key)
+	if err == nil {
+		err = models.DB.WithContext(ctx).Create(&key).Error
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "An API key with this name already exists"})
+			return
+		}
+		auditLog.WithFields(logrus.Fields{
+			"event":  "admin_create_api_key",
+			"status": "failure",
+			"reason": "db_error",
+			"ip":     ctx.ClientIP(),
+			"error":  err.Error(),
+		}).Error("Failed to create API key")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create API key"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":  "admin_create_api_key",
+		"status": "success",
+		"key_id": key.ID,
+		"name":   key.Name,
+		"scopes": key.Scopes,
+		"ip":     ctx.ClientIP(),
+	}).Info("API key created")
+	ctx.JSON(http.StatusCreated, createAPIKeyResponse{Key: raw, Info: buildAPIKeyInfo(key)})
+}
+
+func revokeAPIKey(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	keyID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid key ID"})
+		return
+	}
+	var key models.AdminAPIKey
+	if err := models.DB.WithContext(ctx).First(&key, keyID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "API key not found"})
+		return
+	}
+	if err := models.DB.WithContext(ctx).Model(&key).Update("revoked", true).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to revoke API key"})
+		return
+	}
+	shared.ForgetAdminKey(ctx, key.Prefix)
+	auditLog.WithFields(logrus.Fields{
+		"event":  "admin_revoke_api_key",
+		"status": "success",
+		"key_id": key.ID,
+		"name":   key.Name,
+		"ip":     ctx.ClientIP(),
+	}).Info("API key revoked")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "API key revoked"})
+}
@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// rosterExceptionExpiry is used when an admin does not pick a duration.
+const rosterExceptionExpiry = time.Hour
+
+func listRosterExceptions(ctx *gin.Context) {
+	query := models.DB.WithContext(ctx).Order("id DESC").Limit(100)
+	if userID := ctx.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if ctx.Query("active") == "true" {
+		query = query.Where("revoked = ? AND expires_at > ?", false, time.Now())
+	}
+	var exceptions []models.RosterException
+	if err := query.Find(&exceptions).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch roster exceptions"})
+		return
+	}
+	ctx.JSON(http.StatusOK, exceptions)
+}
+
+func createRosterException(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	var req createRosterExceptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to parse the body"})
+		return
+	}
+	duration := rosterExceptionExpiry
+	if req.Duration != "" {
+		var err error
+		if duration, err = time.ParseDuration(req.Duration); err != nil || duration <= 0 {
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid duration"})
+			return
+		}
+	}
+	var user models.User
+	if err := models.DB.WithContext(ctx).First(&user, req.UserID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		return
+	}
+	if req.TeamID != nil {
+		var team models.Team
+		if err := models.DB.WithContext(ctx).First(&team, *req.TeamID).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Team not found"})
+			return
+		}
+	}
+	exception := models.RosterException{
+		UserID:    user.ID,
+		TeamID:    req.TeamID,
+		Reason:    req.Reason,
+		GrantedBy: ctx.GetString("admin_actor"),
+		ExpiresAt: time.Now().Add(duration),
+	}
+	if err := models.DB.WithContext(ctx).Create(&exception).Error; err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "admin_create_roster_exception",
+			"status":  "failure",
+			"reason":  "db_error",
+			"user_id": user.ID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Error("Failed to create roster exception")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create roster exception"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":        "admin_create_roster_exception",
+		"status":       "success",
+		"exception_id": exception.ID,
+		"user_id":      user.ID,
+		"username":     user.Username,
+		"team_id":      exception.TeamID,
+		"expires_at":   exception.ExpiresAt,
+		"note":         exception.Reason,
+		"ip":           ctx.ClientIP(),
+	}).Info("Roster exception granted")
+	ctx.JSON(http.StatusCreated, exception)
+}
+
+func revokeRosterException(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	exceptionID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid exception ID"})
+		return
+	}
+	result := models.DB.WithContext(ctx).Model(&models.RosterException{}).
+		Where("id = ? AND revoked = ?", exceptionID, false).Update("revoked", true)
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to revoke roster exception"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Roster exception not found"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":        "admin_revoke_roster_exception",
+		"status":       "success",
+		"exception_id": exceptionID,
+		"ip":           ctx.ClientIP(),
+	}).Info("Roster exception revoked")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Roster exception revoked"})
+}
@@ -0,0 +1,42 @@
+package admin_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/api/team"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRosterFreeze(t *testing.T) {
+	r, token := setupAdmin(t, models.RoleOrganiser, team.LoadTeam)
+	t.Cleanup(func() { shared.SetRosterFrozen(false) })
+
+	leader := testutil.CreateUser(t, "leader")
+	pwners := testutil.CreateTeam(t, models.DB, leader)
+	join := func(user models.User) int {
+		return testutil.Do(r, http.MethodPost, fmt.Sprintf("/api/team/join/%d", pwners.ID),
+			testutil.Token(t, user), `{"code":"`+pwners.Code+`"}`).Code
+	}
+	alice, bob := testutil.CreateUser(t, "alice"), testutil.CreateUser(t, "bob")
+
+	require.Equal(t, http.StatusOK, testutil.Do(r, http.MethodPatch, "/api/admin/flags/roster_frozen", token, `{"enabled":true}`).Code)
+	require.Equal(t, http.StatusForbidden, join(alice))
+
+	w := testutil.Do(r, http.MethodPost, "/api/admin/roster/exceptions", token,
+		fmt.Sprintf(`{"user_id":%d,"team_id":%d,"reason":"replacing a member who fell ill","duration":"10m"}`, alice.ID, pwners.ID))
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, http.StatusOK, join(alice))
+	require.NoError(t, models.DB.First(&alice, alice.ID).Error)
+	require.NotNil(t, alice.TeamID)
+
+	// the exception is alice's alone, and the freeze is checked before the
+	// team size
+	require.Equal(t, http.StatusForbidden, join(bob))
+	require.Equal(t, http.StatusOK, testutil.Do(r, http.MethodPatch, "/api/admin/flags/roster_frozen", token, `{"enabled":false}`).Code)
+	require.Equal(t, http.StatusConflict, join(bob))
+}
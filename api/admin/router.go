@@ -1,31 +1,66 @@
 package admin
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
 	"github.com/intraware/rodan-authify/internal/config"
-	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/utils/middleware"
 )
 
 func LoadAdminRouter(r *gin.RouterGroup, adminCfg config.AdminConfig) {
+	// EventSource cannot set headers, so the stream is opened with a
+	// single-use ticket issued below to a caller allowed to read it
+	r.GET(adminCfg.Endpoint+"/stream", middleware.StreamTicketAuth(shared.StreamScopeAdmin), streamAllEvents)
+
 	adminRouter := r.Group(adminCfg.Endpoint)
-	adminRouter.Use(func(ctx *gin.Context) {
-		apiKeyHeader := ctx.GetHeader("x-api-key")
-		if apiKeyHeader == "" {
-			ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "APIKey header is required"})
-			ctx.Abort()
-			return
-		}
-		if apiKeyHeader != adminCfg.HashedAPIKey {
-			ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Invalid Token"})
-			ctx.Abort()
-			return
-		}
-		ctx.Next()
-	})
-	adminRouter.POST("/auth/login/close", closeLogin)
-	adminRouter.POST("/auth/login/open", openLogin)
-	adminRouter.POST("/auth/signup/close", closeSignup)
-	adminRouter.POST("/auth/signup/open", oepnSignup)
+	adminRouter.Use(middleware.AdminAuth)
+
+	eventRouter := adminRouter.Group("/", middleware.RequirePermission(models.PermEventManage))
+	eventRouter.POST("/auth/login/close", closeLogin)
+	eventRouter.POST("/auth/login/open", openLogin)
+	eventRouter.POST("/auth/signup/close", closeSignup)
+	eventRouter.POST("/auth/signup/open", openSignup)
+	eventRouter.GET("/flags", listFlags)
+	eventRouter.PATCH("/flags/:name", updateFlag)
+	eventRouter.GET("/event/schedule", getSchedule)
+	eventRouter.PUT("/event/schedule", updateSchedule)
+	eventRouter.GET("/divisions", listDivisions)
+	eventRouter.POST("/divisions", createDivision)
+	eventRouter.PATCH("/divisions/:id", updateDivision)
+	eventRouter.DELETE("/divisions/:id", deleteDivision)
+	eventRouter.PUT("/teams/:id/division", moveTeamDivision)
+	eventRouter.GET("/roster/exceptions", listRosterExceptions)
+	eventRouter.POST("/roster/exceptions", createRosterException)
+	eventRouter.DELETE("/roster/exceptions/:id", revokeRosterException)
+
+	inviteRouter := adminRouter.Group("/invites", middleware.RequirePermission(models.PermInvitesManage))
+	inviteRouter.GET("", listInvites)
+	inviteRouter.POST("", createInvite)
+	inviteRouter.DELETE("/:id", revokeInvite)
+	inviteRouter.GET("/:id/redemptions", listInviteRedemptions)
+
+	adminRouter.GET("/users/:id", middleware.RequirePermission(models.PermUsersRead), getUser)
+	adminRouter.PUT("/users/:id/role", middleware.RequirePermission(models.PermRolesManage), updateUserRole)
+	adminRouter.POST("/users/:id/ban", middleware.RequirePermission(models.PermUsersBan), banUser)
+	adminRouter.DELETE("/users/:id/ban", middleware.RequirePermission(models.PermUsersBan), unbanUser)
+
+	webhookRouter := adminRouter.Group("/webhooks", middleware.RequirePermission(models.PermWebhooksManage))
+	webhookRouter.GET("", listWebhooks)
+	webhookRouter.POST("", createWebhook)
+	webhookRouter.PATCH("/:id", updateWebhook)
+	webhookRouter.DELETE("/:id", deleteWebhook)
+	webhookRouter.GET("/:id/deliveries", listWebhookDeliveries)
+	webhookRouter.POST("/:id/deliveries/:delivery_id/retry", retryWebhookDelivery)
+
+	adminRouter.POST("/stream/ticket", middleware.RequirePermission(models.PermAuditRead), issueStreamTicket)
+
+	auditRouter := adminRouter.Group("/audit", middleware.RequirePermission(models.PermAuditRead))
+	auditRouter.GET("", listAuditEvents)
+	auditRouter.GET("/verify", verifyAuditChain)
+
+	keyRouter := adminRouter.Group("/keys", middleware.RequirePermission(models.PermKeysManage))
+	keyRouter.GET("", listAPIKeys)
+	keyRouter.POST("", createAPIKey)
+	keyRouter.DELETE("/:id", revokeAPIKey)
 }
@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/types"
+)
+
+// streamAllEvents streams every lifecycle event on every instance.
+func streamAllEvents(ctx *gin.Context) {
+	ch, stop := events.Listen(func(events.Event) bool { return true })
+	defer stop()
+	shared.StreamEvents(ctx, ch)
+}
+
+// issueStreamTicket hands out a single-use ticket that opens the admin event
+// stream, so that the credentials never appear in the stream URL.
+func issueStreamTicket(ctx *gin.Context) {
+	ticket, err := shared.IssueStreamTicket(ctx, shared.StreamTicket{
+		Scope:  shared.StreamScopeAdmin,
+		UserID: ctx.GetUint("user_id"),
+		Actor:  ctx.GetString("admin_actor"),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to issue stream ticket"})
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.StreamTicketResponse{Ticket: ticket, ExpiresIn: int(shared.StreamTicketTTL.Seconds())})
+}
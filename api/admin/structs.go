@@ -0,0 +1,143 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/intraware/rodan-authify/internal/models"
+)
+
+type createInviteRequest struct {
+	Code      string     `json:"code" example:"spring-round"`
+	MaxUses   int        `json:"max_uses" binding:"omitempty,min=1" example:"50"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2026-11-01T00:00:00Z"`
+	Email     *string    `json:"email" binding:"omitempty,email" example:"player@example.org"`
+	TeamID    *uint      `json:"team_id" example:"3"`
+}
+
+type inviteInfo struct {
+	ID          uint       `json:"id" example:"7"`
+	Code        string     `json:"code" example:"9f86d081884c7d65"`
+	MaxUses     int        `json:"max_uses" example:"50"`
+	Uses        int        `json:"uses" example:"12"`
+	ExpiresAt   *time.Time `json:"expires_at" example:"2026-11-01T00:00:00Z"`
+	Email       *string    `json:"email,omitempty" example:"player@example.org"`
+	TeamID      *uint      `json:"team_id,omitempty" example:"3"`
+	CreatedByID *uint      `json:"created_by_id,omitempty" example:"42"`
+	Revoked     bool       `json:"revoked" example:"false"`
+	CreatedAt   time.Time  `json:"created_at" example:"2026-10-01T00:00:00Z"`
+}
+
+type inviteRedemptionInfo struct {
+	UserID     uint      `json:"user_id" example:"42"`
+	Username   string    `json:"username" example:"intraware"`
+	Email      string    `json:"email" example:"example@intraware.org"`
+	IP         string    `json:"ip" example:"10.0.0.1"`
+	RedeemedAt time.Time `json:"redeemed_at" example:"2026-10-02T00:00:00Z"`
+}
+
+type flagInfo struct {
+	Name    string `json:"name" example:"team_join"`
+	Enabled bool   `json:"enabled" example:"true"`
+}
+
+type updateFlagRequest struct {
+	Enabled *bool `json:"enabled" binding:"required" example:"false"`
+}
+
+type adminUserInfo struct {
+	ID        uint      `json:"id" example:"42"`
+	Username  string    `json:"username" example:"intraware"`
+	Email     string    `json:"email" example:"example@intraware.org"`
+	Role      string    `json:"role" example:"player"`
+	Active    bool      `json:"active" example:"true"`
+	Ban       bool      `json:"ban" example:"false"`
+	TeamID    *uint     `json:"team_id" example:"3"`
+	CreatedAt time.Time `json:"created_at" example:"2026-10-01T00:00:00Z"`
+}
+
+type updateRoleRequest struct {
+	Role string `json:"role" binding:"required" example:"organiser"`
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required" example:"scoreboard-sync"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1" example:"invites:manage"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2027-01-01T00:00:00Z"`
+}
+
+type apiKeyInfo struct {
+	ID          uint       `json:"id" example:"4"`
+	Name        string     `json:"name" example:"scoreboard-sync"`
+	Prefix      string     `json:"prefix" example:"9f86d081"`
+	Scopes      []string   `json:"scopes" example:"invites:manage"`
+	CreatedByID *uint      `json:"created_by_id,omitempty" example:"42"`
+	ExpiresAt   *time.Time `json:"expires_at" example:"2027-01-01T00:00:00Z"`
+	LastUsedAt  *time.Time `json:"last_used_at" example:"2026-10-02T00:00:00Z"`
+	Revoked     bool       `json:"revoked" example:"false"`
+	CreatedAt   time.Time  `json:"created_at" example:"2026-10-01T00:00:00Z"`
+}
+
+type createAPIKeyResponse struct {
+	Key  string     `json:"key" example:"rak_9f86d081_3c2b..."`
+	Info apiKeyInfo `json:"info"`
+}
+
+type auditListResponse struct {
+	Events       []models.AuditEvent `json:"events"`
+	NextBeforeID *uint               `json:"next_before_id,omitempty" example:"1200"`
+}
+
+type auditVerifyResponse struct {
+	Valid    bool  `json:"valid" example:"true"`
+	Checked  int   `json:"checked" example:"5120"`
+	BrokenAt *uint `json:"broken_at,omitempty" example:"311"`
+}
+
+type banUserRequest struct {
+	Reason   string `json:"reason" binding:"required" example:"flag sharing"`
+	Duration string `json:"duration" example:"24h"` // empty escalates per [app.ban]
+}
+
+type createWebhookRequest struct {
+	Name   string   `json:"name" binding:"required" example:"discord-bot"`
+	URL    string   `json:"url" binding:"required,url" example:"https://bot.example.org/hooks/authify"`
+	Events []string `json:"events" binding:"required,min=1" example:"team.created"`
+}
+
+type updateWebhookRequest struct {
+	URL    *string  `json:"url" binding:"omitempty,url" example:"https://bot.example.org/hooks/authify"`
+	Events []string `json:"events" example:"team.member_joined"`
+	Active *bool    `json:"active" example:"false"`
+}
+
+type createWebhookResponse struct {
+	Secret  string         `json:"secret" example:"5e884898da28047151d0e56f8dc62927..."`
+	Webhook models.Webhook `json:"webhook"`
+}
+
+type createDivisionRequest struct {
+	Name           string   `json:"name" binding:"required" example:"student"`
+	Description    string   `json:"description" example:"Teams of enrolled students"`
+	TeamSize       int      `json:"team_size" binding:"min=0" example:"4"` // 0 uses app.team-size
+	EmailRegex     string   `json:"email_regex" example:"@(.+\\.)?edu$"`
+	OAuthProviders []string `json:"oauth_providers" example:"github"`
+}
+
+type updateDivisionRequest struct {
+	Name           *string  `json:"name" example:"student"`
+	Description    *string  `json:"description" example:"Teams of enrolled students"`
+	TeamSize       *int     `json:"team_size" binding:"omitempty,min=0" example:"4"`
+	EmailRegex     *string  `json:"email_regex" example:"@(.+\\.)?edu$"`
+	OAuthProviders []string `json:"oauth_providers" example:"github"`
+}
+
+type moveTeamDivisionRequest struct {
+	DivisionID *uint `json:"division_id" example:"2"` // null takes the team out of any division
+}
+
+type createRosterExceptionRequest struct {
+	UserID   uint   `json:"user_id" binding:"required" example:"42"`
+	TeamID   *uint  `json:"team_id" example:"3"` // only changes involving this team
+	Reason   string `json:"reason" binding:"required" example:"replacing a member who fell ill"`
+	Duration string `json:"duration" example:"30m"` // defaults to an hour
+}
@@ -0,0 +1,168 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func getUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+	var user models.User
+	if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, adminUserInfo{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		Active:    user.Active,
+		Ban:       user.Ban,
+		TeamID:    user.TeamID,
+		CreatedAt: user.CreatedAt,
+	})
+}
+
+func updateUserRole(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+	var req updateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || !models.ValidRole(req.Role) {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Role must be one of admin, organiser, support or player"})
+		return
+	}
+	if uint(userID) == ctx.GetUint("user_id") {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "You cannot change your own role"})
+		return
+	}
+	var user models.User
+	if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		return
+	}
+	previous := user.Role
+	columns := map[string]any{"role": req.Role}
+	demoted := models.RoleDemotes(previous, req.Role)
+	if demoted {
+		// sessions started under the old role end with it
+		columns["token_version"] = gorm.Expr("token_version + 1")
+	}
+	if err := models.DB.WithContext(ctx).Model(&user).Updates(columns).Error; err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "admin_update_role",
+			"status":  "failure",
+			"reason":  "db_error",
+			"user_id": user.ID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Error("Failed to update role")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update role"})
+		return
+	}
+	shared.ForgetUser(ctx, user.ID)
+	shared.ForgetLogin(ctx, user.Username)
+	auditLog.WithFields(logrus.Fields{
+		"event":    "admin_update_role",
+		"status":   "success",
+		"user_id":  user.ID,
+		"username": user.Username,
+		"from":     previous,
+		"to":       req.Role,
+		"demoted":  demoted,
+		"ip":       ctx.ClientIP(),
+	}).Info("User role updated")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Role updated"})
+}
+
+func banUser(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+	var req banUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to parse the body"})
+		return
+	}
+	var duration time.Duration
+	if req.Duration != "" {
+		if duration, err = time.ParseDuration(req.Duration); err != nil || duration <= 0 {
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid duration"})
+			return
+		}
+	}
+	ban, err := shared.BanUser(ctx, uint(userID), req.Reason, duration)
+	if err != nil {
+		status, msg, reason := http.StatusInternalServerError, "Failed to ban user", "db_error"
+		switch {
+		case errors.Is(err, shared.ErrUserBanDisabled):
+			status, msg, reason = http.StatusConflict, "User bans are disabled", "disabled"
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status, msg, reason = http.StatusNotFound, "User not found", "user_not_found"
+		}
+		auditLog.WithFields(logrus.Fields{
+			"event":   "admin_ban_user",
+			"status":  "failure",
+			"reason":  reason,
+			"user_id": userID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Warn("Failed to ban user")
+		ctx.JSON(status, types.ErrorResponse{Error: msg})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":      "admin_ban_user",
+		"status":     "success",
+		"user_id":    userID,
+		"ban_reason": req.Reason,
+		"expires_at": ban.ExpiresAt,
+		"ip":         ctx.ClientIP(),
+	}).Info("User banned")
+	ctx.JSON(http.StatusOK, ban)
+}
+
+func unbanUser(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+	if err := shared.UnbanUser(ctx, uint(userID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to unban user"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":   "admin_unban_user",
+		"status":  "success",
+		"user_id": userID,
+		"ip":      ctx.ClientIP(),
+	}).Info("User unbanned")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "User unbanned"})
+}
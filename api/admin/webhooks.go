@@ -0,0 +1,183 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/webhook"
+	"github.com/sirupsen/logrus"
+)
+
+func validEvents(list []string) bool {
+	for _, e := range list {
+		if e != "*" && !events.ValidType(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func loadWebhook(ctx *gin.Context) (models.Webhook, bool) {
+	var hook models.Webhook
+	hookID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid webhook ID"})
+		return hook, false
+	}
+	if err := models.DB.WithContext(ctx).First(&hook, hookID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Webhook not found"})
+		return hook, false
+	}
+	return hook, true
+}
+
+func listWebhooks(ctx *gin.Context) {
+	var hooks []models.Webhook
+	if err := models.DB.WithContext(ctx).Order("id").Find(&hooks).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch webhooks"})
+		return
+	}
+	ctx.JSON(http.StatusOK, hooks)
+}
+
+func createWebhook(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	var req createWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to parse the body"})
+		return
+	}
+	if !validEvents(req.Events) {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Unknown event type"})
+		return
+	}
+	secret, err := webhook.GenerateSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to generate secret"})
+		return
+	}
+	hook := models.Webhook{Name: req.Name, URL: req.URL, Secret: secret, Events: req.Events, Active: true}
+	if err := models.DB.WithContext(ctx).Create(&hook).Error; err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":  "admin_create_webhook",
+			"status": "failure",
+			"reason": "db_error",
+			"ip":     ctx.ClientIP(),
+			"error":  err.Error(),
+		}).Error("Failed to create webhook")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create webhook"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":      "admin_create_webhook",
+		"status":     "success",
+		"webhook_id": hook.ID,
+		"url":        hook.URL,
+		"events":     hook.Events,
+		"ip":         ctx.ClientIP(),
+	}).Info("Webhook created")
+	ctx.JSON(http.StatusCreated, createWebhookResponse{Secret: secret, Webhook: hook})
+}
+
+func updateWebhook(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	hook, ok := loadWebhook(ctx)
+	if !ok {
+		return
+	}
+	var req updateWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to parse the body"})
+		return
+	}
+	if req.URL != nil {
+		hook.URL = *req.URL
+	}
+	if req.Events != nil {
+		if len(req.Events) == 0 || !validEvents(req.Events) {
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Unknown event type"})
+			return
+		}
+		hook.Events = req.Events
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	if err := models.DB.WithContext(ctx).Save(&hook).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update webhook"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":      "admin_update_webhook",
+		"status":     "success",
+		"webhook_id": hook.ID,
+		"active":     hook.Active,
+		"ip":         ctx.ClientIP(),
+	}).Info("Webhook updated")
+	ctx.JSON(http.StatusOK, hook)
+}
+
+func deleteWebhook(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	hook, ok := loadWebhook(ctx)
+	if !ok {
+		return
+	}
+	if err := models.DB.WithContext(ctx).Delete(&hook).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to delete webhook"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":      "admin_delete_webhook",
+		"status":     "success",
+		"webhook_id": hook.ID,
+		"ip":         ctx.ClientIP(),
+	}).Info("Webhook deleted")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Webhook deleted"})
+}
+
+func listWebhookDeliveries(ctx *gin.Context) {
+	hook, ok := loadWebhook(ctx)
+	if !ok {
+		return
+	}
+	query := models.DB.WithContext(ctx).Where("webhook_id = ?", hook.ID).Order("id DESC").Limit(100)
+	if status := ctx.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []models.WebhookDelivery
+	if err := query.Find(&deliveries).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch deliveries"})
+		return
+	}
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+func retryWebhookDelivery(ctx *gin.Context) {
+	hook, ok := loadWebhook(ctx)
+	if !ok {
+		return
+	}
+	result := models.DB.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND webhook_id = ?", ctx.Param("delivery_id"), hook.ID).
+		Updates(map[string]any{
+			"status":          models.DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to requeue delivery"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Delivery not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Delivery requeued"})
+}
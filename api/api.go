@@ -4,9 +4,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/intraware/rodan-authify/api/admin"
 	"github.com/intraware/rodan-authify/api/auth"
+	"github.com/intraware/rodan-authify/api/event"
 	"github.com/intraware/rodan-authify/api/shared"
 	"github.com/intraware/rodan-authify/api/team"
 	"github.com/intraware/rodan-authify/api/user"
+	"github.com/intraware/rodan-authify/internal/utils/avatar"
 	"github.com/intraware/rodan-authify/internal/utils/values"
 )
 
@@ -16,9 +18,15 @@ func LoadRoutes(r *gin.Engine) {
 	auth.LoadAuth(apiRouter)
 	team.LoadTeam(apiRouter)
 	user.LoadUser(apiRouter)
+	event.LoadEvent(apiRouter)
 	admin.LoadAdminRouter(apiRouter, values.GetConfig().App.Admin)
 	shared.Init(&values.GetConfig().App)
 	apiRouter.GET("/ping", func(ctx *gin.Context) {
 		ctx.JSON(200, gin.H{"msg": "pong"})
 	})
+	if cfg := values.GetConfig().App.Avatar; cfg.Storage == "local" && cfg.PublicURL == "" {
+		r.Static(avatar.LocalPrefix, cfg.Path)
+	}
+	r.GET("/healthz", healthz)
+	r.GET("/readyz", readyz)
 }
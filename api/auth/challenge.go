@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/pow"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/sirupsen/logrus"
+)
+
+// issueChallenge godoc
+// @Summary      Proof-of-work challenge
+// @Description  Issues a single-use puzzle required by signup, login and forgot password.
+// @Description  Find a nonce such that sha256(challenge + nonce) starts with `difficulty` zero bits,
+// @Description  then send both in the X-PoW-Challenge and X-PoW-Nonce headers.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  challengeResponse
+// @Failure      500  {object}  types.ErrorResponse
+// @Router       /auth/challenge [get]
+func issueChallenge(ctx *gin.Context) {
+	cfg := values.GetConfig()
+	powCfg := cfg.App.PoW
+	difficulty := shared.NextPoWDifficulty(ctx, ctx.ClientIP(), &powCfg)
+	challenge, err := pow.Issue(pow.Key(cfg.Server.Security.JWTSecret), difficulty, powCfg.ChallengeTTL)
+	if err != nil {
+		utils.AuditLog(ctx).WithFields(logrus.Fields{
+			"event":  "issue_challenge",
+			"status": "failure",
+			"reason": "challenge_generation_failed",
+			"ip":     ctx.ClientIP(),
+			"error":  err.Error(),
+		}).Error("Failed to generate challenge")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to generate challenge"})
+		return
+	}
+	shared.PoWChallengeCache.Set(ctx, challenge.ID, struct{}{})
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, challengeResponse{
+		Challenge:  challenge.Token(),
+		Difficulty: challenge.Difficulty,
+		ExpiresAt:  challenge.ExpiresAt,
+		Algorithm:  "sha256",
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/events"
 	"github.com/intraware/rodan-authify/internal/models"
 	"github.com/intraware/rodan-authify/internal/types"
 	"github.com/intraware/rodan-authify/internal/utils"
@@ -17,19 +18,22 @@ import (
 
 // signUp godoc
 // @Summary      Sign up new user
-// @Description  Registers a new user account in the system
+// @Description  Registers a new user account in the system, optionally redeeming an invite code
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        user  body      signUpRequest   true  "User registration data"
+// @Param        X-PoW-Challenge  header  string  false  "Solved challenge from /auth/challenge, when proof of work is enabled"
+// @Param        X-PoW-Nonce      header  string  false  "Nonce solving the challenge"
 // @Success      201   {object}  authResponse
 // @Failure      400   {object}  types.ErrorResponse
+// @Failure      403   {object}  types.ErrorResponse
 // @Failure      409   {object}  types.ErrorResponse
 // @Failure      500   {object}  types.ErrorResponse
 // @Router       /auth/signup [post]
 func signUp(ctx *gin.Context) {
 	appCfg := values.GetConfig().App
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	if !shared.AllowSignup() {
 		auditLog.WithFields(logrus.Fields{
 			"event":  "sign_up",
@@ -51,24 +55,34 @@ func signUp(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to parse the body"})
 		return
 	}
+	if models.ReservedUsername(req.Username) {
+		auditLog.WithFields(logrus.Fields{
+			"event":    "sign_up",
+			"status":   "failure",
+			"reason":   "reserved_username",
+			"username": req.Username,
+			"ip":       ctx.ClientIP(),
+		}).Warn("Signup with a reserved username")
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: models.ErrUsernameReserved.Error()})
+		return
+	}
 	if !appCfg.CompiledEmail.MatchString(req.Email) {
 		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Bad email ID provided"})
 		return
 	}
 	var existingUser models.User
-	if err := models.DB.Where("email = ?", req.Email).First(&existingUser).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) && !appCfg.AllowOutsideEmail {
-			auditLog.WithFields(logrus.Fields{
-				"event":    "sign_up",
-				"status":   "failure",
-				"reason":   "outside_email",
-				"username": req.Username,
-				"email":    req.Email,
-				"ip":       ctx.ClientIP(),
-			}).Warn("User tried to sign up with outside email")
-			ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Outside email not allowed"})
-			return
-		}
+	if err := models.DB.WithContext(ctx).Where("email = ?", req.Email).First(&existingUser).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		auditLog.WithFields(logrus.Fields{
+			"event":    "sign_up",
+			"status":   "failure",
+			"reason":   "db_error",
+			"username": req.Username,
+			"email":    req.Email,
+			"ip":       ctx.ClientIP(),
+			"error":    err.Error(),
+		}).Error("Failed to look up user during signup")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create user"})
+		return
 	}
 	if existingUser.ID > 0 && existingUser.Active {
 		auditLog.WithFields(logrus.Fields{
@@ -82,62 +96,127 @@ func signUp(ctx *gin.Context) {
 		ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "User with same email exists"})
 		return
 	}
-	var user models.User
-	if existingUser.ID > 0 {
-		existingUser.Username = req.Username
-		existingUser.SetPassword(req.Password)
-		existingUser.AvatarURL = req.AvatarURL
-		existingUser.Active = true
-		if err := models.DB.Save(&existingUser).Error; err != nil {
+	var invite *models.Invite
+	if req.InviteCode != "" {
+		found, err := models.FindUsableInvite(models.DB.WithContext(ctx), req.InviteCode, req.Email)
+		if err == nil && found.TeamID != nil && shared.RosterFrozen() {
+			err = shared.ErrRosterFrozen
+		}
+		if err != nil {
+			reason, status := inviteFailure(err)
 			auditLog.WithFields(logrus.Fields{
 				"event":    "sign_up",
 				"status":   "failure",
-				"reason":   "db_error",
+				"reason":   reason,
 				"username": req.Username,
 				"email":    req.Email,
 				"ip":       ctx.ClientIP(),
-				"error":    err.Error(),
-			}).Error("Failed to update user in DB during signup")
-			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create user"})
+			}).Warn("Invalid invite code during signup")
+			ctx.JSON(status, types.ErrorResponse{Error: err.Error()})
 			return
 		}
-		user = existingUser
-	} else if appCfg.AllowOutsideEmail {
-		newUser := models.User{
-			Username:  req.Username,
-			Email:     req.Email,
-			Password:  req.Password,
-			AvatarURL: req.AvatarURL,
-			Active:    true,
+		invite = &found
+	}
+	if existingUser.ID == 0 && invite == nil {
+		if appCfg.InviteOnly {
+			auditLog.WithFields(logrus.Fields{
+				"event":    "sign_up",
+				"status":   "failure",
+				"reason":   "invite_required",
+				"username": req.Username,
+				"email":    req.Email,
+				"ip":       ctx.ClientIP(),
+			}).Warn("User tried to sign up without an invite code")
+			ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "An invite code is required to sign up"})
+			return
 		}
-		if err := models.DB.Create(&newUser).Error; err != nil {
-			if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "UNIQUE") {
-				auditLog.WithFields(logrus.Fields{
-					"event":    "sign_up",
-					"status":   "failure",
-					"reason":   "duplicate_user",
-					"username": req.Username,
-					"email":    req.Email,
-					"ip":       ctx.ClientIP(),
-				}).Warn("User already exists during signup")
-				ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "User with same email or username or email exists"})
-				return
+		if !appCfg.AllowOutsideEmail {
+			auditLog.WithFields(logrus.Fields{
+				"event":    "sign_up",
+				"status":   "failure",
+				"reason":   "outside_email",
+				"username": req.Username,
+				"email":    req.Email,
+				"ip":       ctx.ClientIP(),
+			}).Warn("User tried to sign up with outside email")
+			ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Outside email not allowed"})
+			return
+		}
+	}
+	var user models.User
+	err := models.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if existingUser.ID > 0 {
+			existingUser.Username = req.Username
+			existingUser.SetPassword(req.Password)
+			existingUser.Active = true
+			if err := tx.Save(&existingUser).Error; err != nil {
+				return err
+			}
+			user = existingUser
+		} else {
+			user = models.User{
+				Username: req.Username,
+				Email:    req.Email,
+				Password: req.Password,
+				Active:   true,
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		}
+		if invite != nil {
+			if _, err := models.RedeemInvite(tx, invite.Code, &user, ctx.ClientIP()); err != nil {
+				return err
 			}
+		}
+		return nil
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "UNIQUE") || errors.Is(err, gorm.ErrDuplicatedKey) {
 			auditLog.WithFields(logrus.Fields{
 				"event":    "sign_up",
 				"status":   "failure",
-				"reason":   "db_error",
+				"reason":   "duplicate_user",
 				"username": req.Username,
 				"email":    req.Email,
 				"ip":       ctx.ClientIP(),
-				"error":    err.Error(),
-			}).Error("Failed to create user in DB during signup")
-			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create user"})
+			}).Warn("User already exists during signup")
+			ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "User with same email or username or email exists"})
 			return
 		}
-		user = newUser
+		if reason, status := inviteFailure(err); status != http.StatusInternalServerError {
+			auditLog.WithFields(logrus.Fields{
+				"event":    "sign_up",
+				"status":   "failure",
+				"reason":   reason,
+				"username": req.Username,
+				"email":    req.Email,
+				"ip":       ctx.ClientIP(),
+			}).Warn("Invite code could not be redeemed during signup")
+			ctx.JSON(status, types.ErrorResponse{Error: err.Error()})
+			return
+		}
+		auditLog.WithFields(logrus.Fields{
+			"event":    "sign_up",
+			"status":   "failure",
+			"reason":   "db_error",
+			"username": req.Username,
+			"email":    req.Email,
+			"ip":       ctx.ClientIP(),
+			"error":    err.Error(),
+		}).Error("Failed to create user in DB during signup")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create user"})
+		return
+	}
+	if user.TeamID != nil {
+		// the invite put the new user on a team
+		shared.TeamCache.Delete(ctx, *user.TeamID)
 	}
-	token, err := utils.GenerateJWT(0, user.ID, user.Username, values.GetConfig().Server.Security.JWTSecret)
+	var teamID uint
+	if user.TeamID != nil {
+		teamID = *user.TeamID
+	}
+	token, err := utils.GenerateJWT(teamID, user.ID, user.Username, user.TokenVersion, false, values.GetConfig().Server.Security.JWTSecret)
 	if err != nil {
 		auditLog.WithFields(logrus.Fields{
 			"event":    "sign_up",
@@ -155,17 +234,26 @@ func signUp(ctx *gin.Context) {
 		ID:        user.ID,
 		Username:  user.Username,
 		Email:     user.Email,
-		AvatarURL: user.AvatarURL,
+		AvatarURL: shared.UserAvatarURL(&user),
 		TeamID:    user.TeamID,
 	}
-	auditLog.WithFields(logrus.Fields{
+	fields := logrus.Fields{
 		"event":    "sign_up",
 		"status":   "success",
 		"user_id":  user.ID,
 		"username": user.Username,
 		"email":    user.Email,
 		"ip":       ctx.ClientIP(),
-	}).Info("User signed up successfully")
+	}
+	if invite != nil {
+		fields["invite_id"] = invite.ID
+		fields["team_id"] = user.TeamID
+	}
+	auditLog.WithFields(fields).Info("User signed up successfully")
+	events.Publish(events.UserSignedUp, user.ID, teamID, map[string]any{"username": user.Username})
+	if teamID != 0 {
+		events.Publish(events.TeamMemberJoined, user.ID, teamID, map[string]any{"username": user.Username, "via": "invite"})
+	}
 	ctx.JSON(http.StatusCreated, authResponse{
 		Token: token,
 		User:  userInfo,
@@ -174,11 +262,13 @@ func signUp(ctx *gin.Context) {
 
 // login godoc
 // @Summary      User login
-// @Description  Authenticates a user and returns an access token
+// @Description  Authenticates a user and returns an access token. Passing a valid TOTP code marks the session as MFA-verified, which staff need for the admin API
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        credentials  body      loginRequest  true  "Login credentials"
+// @Param        X-PoW-Challenge  header  string  false  "Solved challenge from /auth/challenge, when proof of work is enabled"
+// @Param        X-PoW-Nonce      header  string  false  "Nonce solving the challenge"
 // @Success      200          {object}  authResponse
 // @Failure      400          {object}  types.ErrorResponse
 // @Failure      401          {object}  types.ErrorResponse
@@ -186,7 +276,7 @@ func signUp(ctx *gin.Context) {
 // @Failure      500          {object}  types.ErrorResponse
 // @Router       /auth/login [post]
 func login(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	if !shared.AllowLogin() {
 		auditLog.WithFields(logrus.Fields{
 			"event":  "login",
@@ -210,8 +300,8 @@ func login(ctx *gin.Context) {
 	}
 	var user models.User
 	cacheHit := false
-	if user, cacheHit := shared.LoginCache.Get(req.Username); cacheHit {
-		if err := models.DB.Where("username = ?", req.Username).Preload("Team").First(&user).Error; err != nil {
+	if user, cacheHit = shared.LoginCache.Get(ctx, req.Username); !cacheHit {
+		if err := models.DB.WithContext(ctx).Where("username = ?", req.Username).Preload("Team").First(&user).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				auditLog.WithFields(logrus.Fields{
 					"event":    "login",
@@ -234,7 +324,7 @@ func login(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
 			return
 		} else {
-			shared.LoginCache.Set(req.Username, user)
+			shared.LoginCache.Set(ctx, req.Username, user)
 		}
 	}
 	if user.Ban {
@@ -249,7 +339,7 @@ func login(ctx *gin.Context) {
 		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Account is banned"})
 		return
 	}
-	if user.Team.Ban {
+	if user.Team != nil && user.Team.Ban {
 		auditLog.WithFields(logrus.Fields{
 			"event":     "login",
 			"status":    "failure",
@@ -265,14 +355,13 @@ func login(ctx *gin.Context) {
 	}
 	if !user.Active {
 		auditLog.WithFields(logrus.Fields{
-			"event":     "login",
-			"status":    "failure",
-			"reason":    "inactive",
-			"user_id":   user.ID,
-			"username":  user.Username,
-			"team_id":   user.Team.ID,
-			"team_name": user.Team.Name,
-			"ip":        ctx.ClientIP(),
+			"event":    "login",
+			"status":   "failure",
+			"reason":   "inactive",
+			"user_id":  user.ID,
+			"username": user.Username,
+			"team_id":  user.TeamID,
+			"ip":       ctx.ClientIP(),
 		}).Warn("Inactive user attempted login")
 		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "User is not active"})
 		return
@@ -297,7 +386,27 @@ func login(ctx *gin.Context) {
 		ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Invalid username or password"})
 		return
 	}
-	token, err := utils.GenerateJWT(*user.TeamID, user.ID, user.Username, values.GetConfig().Server.Security.JWTSecret)
+	mfa := false
+	if req.OTP != nil {
+		if !values.GetConfig().App.TOTP.Enabled || !verifyLoginOTP(ctx, user, *req.OTP) {
+			auditLog.WithFields(logrus.Fields{
+				"event":    "login",
+				"status":   "failure",
+				"reason":   "invalid_otp",
+				"user_id":  user.ID,
+				"username": user.Username,
+				"ip":       ctx.ClientIP(),
+			}).Warn("Invalid OTP during login")
+			ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Invalid username or password"})
+			return
+		}
+		mfa = true
+	}
+	var teamID uint
+	if user.TeamID != nil {
+		teamID = *user.TeamID
+	}
+	token, err := utils.GenerateJWT(teamID, user.ID, user.Username, user.TokenVersion, mfa, values.GetConfig().Server.Security.JWTSecret)
 	if err != nil {
 		auditLog.WithFields(logrus.Fields{
 			"event":    "login",
@@ -315,7 +424,7 @@ func login(ctx *gin.Context) {
 		ID:        user.ID,
 		Username:  user.Username,
 		Email:     user.Email,
-		AvatarURL: user.AvatarURL,
+		AvatarURL: shared.UserAvatarURL(&user),
 		TeamID:    user.TeamID,
 	}
 	auditLog.WithFields(logrus.Fields{
@@ -324,9 +433,11 @@ func login(ctx *gin.Context) {
 		"user_id":  user.ID,
 		"username": user.Username,
 		"email":    user.Email,
+		"mfa":      mfa,
 		"ip":       ctx.ClientIP(),
 		"cache":    cacheHit,
 	}).Info("User logged in successfully")
+	events.Publish(events.UserLogin, user.ID, teamID, map[string]any{"username": user.Username, "method": "password"})
 	ctx.JSON(http.StatusOK, authResponse{
 		Token: token,
 		User:  userInfo,
@@ -0,0 +1,62 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/intraware/rodan-authify/api/auth"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignUpWithInviteCode(t *testing.T) {
+	cfg := testutil.SetupAPI(t)
+	cfg.App.InviteOnly = true
+	cfg.App.CompiledEmail = regexp.MustCompile(`@example\.com$`)
+	r := testutil.Router(auth.LoadAuth)
+	t.Cleanup(func() { shared.SetRosterFrozen(false) })
+
+	leader := testutil.CreateUser(t, "leader")
+	pwners := testutil.CreateTeam(t, models.DB, leader)
+	invite := models.Invite{MaxUses: 1, TeamID: &pwners.ID}
+	require.NoError(t, models.DB.Create(&invite).Error)
+	signUp := func(username, code string) (int, *uint) {
+		w := testutil.Do(r, http.MethodPost, "/api/auth/signup", "",
+			`{"username":"`+username+`","email":"`+username+`@example.com","password":"correct-horse","invite_code":"`+code+`"}`)
+		var resp struct {
+			User struct {
+				TeamID *uint `json:"team_id"`
+			} `json:"user"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.User.TeamID
+	}
+
+	status, _ := signUp("alice", "")
+	require.Equal(t, http.StatusForbidden, status, "invite-only")
+	status, _ = signUp("alice", "not-a-code")
+	require.Equal(t, http.StatusForbidden, status)
+
+	// codes that put the user on a team respect the roster freeze
+	require.NoError(t, shared.SetRosterFrozen(true))
+	status, _ = signUp("alice", invite.Code)
+	require.Equal(t, http.StatusForbidden, status)
+	require.NoError(t, shared.SetRosterFrozen(false))
+
+	status, teamID := signUp("alice", invite.Code)
+	require.Equal(t, http.StatusCreated, status)
+	require.NotNil(t, teamID)
+	require.Equal(t, pwners.ID, *teamID)
+	require.NoError(t, models.DB.First(&invite, invite.ID).Error)
+	require.Equal(t, 1, invite.Uses)
+
+	status, _ = signUp("bob", invite.Code)
+	require.Equal(t, http.StatusForbidden, status, "used up")
+	var users int64
+	require.NoError(t, models.DB.Model(&models.User{}).Count(&users).Error)
+	require.EqualValues(t, 2, users)
+}
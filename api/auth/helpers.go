@@ -1,52 +1,61 @@
 package auth
 
 import (
-	"bytes"
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"html/template"
 	"io"
 	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
 	"github.com/intraware/rodan-authify/internal/config"
 	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/tracing"
 	"github.com/intraware/rodan-authify/internal/utils/email"
 	"github.com/intraware/rodan-authify/internal/utils/values"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/oauth2"
 )
 
-func sendResetToken(userEmail, token string) error {
-	emailCfg := values.GetConfig().App.Email
-	emailObj, err := email.NewEmail()
+func sendResetToken(ctx context.Context, userEmail, token string) error {
+	appCfg := values.GetConfig().App
+	emailCfg := appCfg.Email
+	link, err := buildResetLink(emailCfg.ResetURL, token)
 	if err != nil {
-		return fmt.Errorf("failed to init email service: %w", err)
-	}
-	if emailObj == nil {
-		return fmt.Errorf("email service is disabled")
-	}
-	if !emailCfg.AllowedEmailCompilexRegex.MatchString(userEmail) {
-		return fmt.Errorf("email does not match allowed regex")
-	}
-	tmpl, err := template.New("resetEmail").Parse(emailCfg.EmailTemplate)
-	if err != nil {
-		return fmt.Errorf("failed to parse email template: %w", err)
+		return fmt.Errorf("failed to build reset link: %w", err)
 	}
 	data := struct {
-		Token string
+		Token  string
+		Link   string
+		Expiry time.Duration
 	}{
-		Token: token,
+		Token:  token,
+		Link:   link,
+		Expiry: shared.ResetTokenExpiry(&appCfg),
 	}
-	var body bytes.Buffer
-	if err := tmpl.Execute(&body, data); err != nil {
-		return fmt.Errorf("failed to execute email template: %w", err)
+	return email.Send(ctx, userEmail, emailCfg.EmailSubject, emailCfg.EmailTemplate, data)
+}
+
+func buildResetLink(resetURL, token string) (string, error) {
+	u, err := url.Parse(resetURL)
+	if err != nil {
+		return "", err
 	}
-	return emailObj.DeliveryAgent.SendEmail(userEmail, emailCfg.EmailSubject, body.String())
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
 }
 
 func generateResetToken() (token string, err error) {
-	random := make([]byte, 20)
+	random := make([]byte, 32)
 	_, err = rand.Read(random)
 	if err != nil {
 		return
@@ -55,6 +64,70 @@ func generateResetToken() (token string, err error) {
 	return
 }
 
+// hashResetToken is the key a reset token is stored under, so that a leaked
+// cache never yields usable tokens.
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueResetToken generates a reset token for the user and stores its hash.
+func issueResetToken(ctx context.Context, user models.User) (string, error) {
+	token, err := generateResetToken()
+	if err != nil {
+		return "", err
+	}
+	shared.ResetPasswordCache.Set(ctx, hashResetToken(token), models.PasswordReset{
+		UserID:      user.ID,
+		Fingerprint: user.PasswordFingerprint(),
+	})
+	return token, nil
+}
+
+// inviteFailure maps an invite redemption error to its audit reason and the
+// status code returned to the client.
+func inviteFailure(err error) (string, int) {
+	switch {
+	case errors.Is(err, models.ErrInviteNotFound):
+		return "invite_not_found", http.StatusForbidden
+	case errors.Is(err, models.ErrInviteRevoked):
+		return "invite_revoked", http.StatusForbidden
+	case errors.Is(err, models.ErrInviteExpired):
+		return "invite_expired", http.StatusForbidden
+	case errors.Is(err, models.ErrInviteExhausted):
+		return "invite_exhausted", http.StatusForbidden
+	case errors.Is(err, models.ErrInviteEmailMismatch):
+		return "invite_email_mismatch", http.StatusForbidden
+	case errors.Is(err, models.ErrInviteTeamClosed):
+		return "invite_team_closed", http.StatusConflict
+	case errors.Is(err, shared.ErrRosterFrozen):
+		return "roster_frozen", http.StatusForbidden
+	case errors.Is(err, models.ErrInviteIneligible):
+		return "invite_division_ineligible", http.StatusForbidden
+	default:
+		return "db_error", http.StatusInternalServerError
+	}
+}
+
+// verifyLoginOTP checks a TOTP code against the user's enrolled secret.
+func verifyLoginOTP(ctx context.Context, user models.User, code string) bool {
+	userTOTP, ok := shared.TOTPCache.Get(ctx, user.Username)
+	if !ok {
+		if err := models.DB.WithContext(ctx).Where("user_id = ?", user.ID).First(&userTOTP).Error; err != nil {
+			return false
+		}
+		shared.TOTPCache.Set(ctx, user.Username, userTOTP)
+	}
+	return userTOTP.VerifyTOTP(code)
+}
+
+// waitUntil pads a response so that it is not sent before the deadline.
+func waitUntil(deadline time.Time) {
+	if d := time.Until(deadline); d > 0 {
+		time.Sleep(d)
+	}
+}
+
 func buildOAuthConfig(providerName string, cfg *config.OAuthConfig) *oauth2.Config {
 	providerConfig := cfg.Providers[providerName]
 	return &oauth2.Config{
@@ -83,9 +156,29 @@ func buildOauthLinkConfig(providerName string, cfg *config.OAuthConfig) *oauth2.
 	}
 }
 
-func buildUserModel(client *http.Client, providerConfig config.OAuthProviderConfig) (models.User, string, error) {
-	var user models.User
-	resp, err := client.Get(providerConfig.UserInfoURL)
+// oauthContext returns the request context with a traced HTTP client for
+// oauth2 to use for the token exchange and the clients it hands out.
+func oauthContext(ctx *gin.Context) context.Context {
+	return context.WithValue(ctx.Request.Context(), oauth2.HTTPClient, tracing.HTTPClient())
+}
+
+func exchangeOAuthCode(ctx context.Context, conf *oauth2.Config, providerName, code string) (*oauth2.Token, error) {
+	ctx, span := tracing.Start(ctx, "oauth.exchange")
+	span.SetAttributes(attribute.String("oauth.provider", providerName))
+	token, err := conf.Exchange(ctx, code)
+	tracing.End(span, err)
+	return token, err
+}
+
+func buildUserModel(ctx context.Context, client *http.Client, providerName string, providerConfig config.OAuthProviderConfig) (user models.User, providerID string, err error) {
+	ctx, span := tracing.Start(ctx, "oauth.userinfo")
+	span.SetAttributes(attribute.String("oauth.provider", providerName))
+	defer func() { tracing.End(span, err) }()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, providerConfig.UserInfoURL, nil)
+	if err != nil {
+		return user, "", fmt.Errorf("failed to build userinfo request: %w", err)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return user, "", fmt.Errorf("failed to fetch userinfo: %w", err)
 	}
@@ -111,6 +204,6 @@ func buildUserModel(client *http.Client, providerConfig config.OAuthProviderConf
 		Email:     getField("email"),
 		AvatarURL: getField("avatar_url"),
 	}
-	providerID := getField("provider_id")
+	providerID = getField("provider_id")
 	return user, providerID, nil
 }
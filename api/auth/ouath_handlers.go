@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/events"
 	"github.com/intraware/rodan-authify/internal/models"
 	"github.com/intraware/rodan-authify/internal/types"
 	"github.com/intraware/rodan-authify/internal/utils"
@@ -22,7 +23,7 @@ import (
 
 func oauthLogin(ctx *gin.Context) {
 	oauthCfg := values.GetConfig().App.OAuth
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	providerName := ctx.Param("provider")
 	if !oauthCfg.Enabled {
 		auditLog.WithFields(logrus.Fields{
@@ -62,7 +63,7 @@ func oauthLogin(ctx *gin.Context) {
 	}
 	state := fmt.Sprintf("login:%s", hex.EncodeToString(random))
 	authURL := conf.AuthCodeURL(state, oauth2.AccessTypeOffline)
-	shared.OauthStateCache.Set(state, struct{}{})
+	shared.OauthStateCache.Set(ctx, state, ctx.Query("invite"))
 	auditLog.WithFields(logrus.Fields{
 		"event":    "oauth_login",
 		"status":   "success",
@@ -72,16 +73,49 @@ func oauthLogin(ctx *gin.Context) {
 	ctx.Redirect(http.StatusFound, authURL)
 }
 
+var (
+	errOutsideEmail     = errors.New("outside emails are not allowed")
+	errOAuthNotLinked   = errors.New("OAuth account not linked. Please link it first before logging in")
+	errInviteRequired   = errors.New("an invite code is required to sign up")
+	errRegistrationShut = errors.New("registration not allowed")
+	errLoginNotAllowed  = errors.New("login is not allowed right now")
+	errSignupNotAllowed = errors.New("signup is not allowed right now")
+)
+
+// oauthFailure maps the errors of the callback's transaction to the audit
+// reason; anything else is a database error.
+func oauthFailure(err error) string {
+	switch {
+	case errors.Is(err, errOutsideEmail):
+		return "outside_email"
+	case errors.Is(err, errOAuthNotLinked):
+		return "oauth_not_linked"
+	case errors.Is(err, errInviteRequired):
+		return "invite_required"
+	case errors.Is(err, errRegistrationShut):
+		return "registration_not_allowed"
+	case errors.Is(err, errLoginNotAllowed), errors.Is(err, errSignupNotAllowed):
+		return "not_enabled"
+	case errors.Is(err, models.ErrUsernameReserved):
+		return "reserved_username"
+	}
+	if reason, code := inviteFailure(err); code != http.StatusInternalServerError {
+		return reason
+	}
+	return "db_error"
+}
+
 func oauthCallback(ctx *gin.Context) {
 	cfg := values.GetConfig()
 	appCfg := cfg.App
 	oauthCfg := appCfg.OAuth
 	secretCfg := cfg.Server.Security
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	providerName := ctx.Param("provider")
 	conf := buildOAuthConfig(providerName, &oauthCfg)
 	state := ctx.Query("state")
-	if _, ok := shared.OauthStateCache.Get(state); !ok {
+	inviteCode, ok := shared.OauthStateCache.Get(ctx, state)
+	if !ok {
 		auditLog.WithFields(logrus.Fields{
 			"event":    "oauth_callback",
 			"status":   "failure",
@@ -92,7 +126,7 @@ func oauthCallback(ctx *gin.Context) {
 		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Not authorized"})
 		return
 	}
-	shared.OauthStateCache.Delete(state)
+	shared.OauthStateCache.Delete(ctx, state)
 	code := ctx.Query("code")
 	if code == "" {
 		auditLog.WithFields(logrus.Fields{
@@ -105,7 +139,8 @@ func oauthCallback(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Missing code in callback"})
 		return
 	}
-	token, err := conf.Exchange(ctx, code)
+	reqCtx := oauthContext(ctx)
+	token, err := exchangeOAuthCode(reqCtx, conf, providerName, code)
 	if err != nil {
 		auditLog.WithFields(logrus.Fields{
 			"event":    "oauth_callback",
@@ -118,8 +153,8 @@ func oauthCallback(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to exchange code for token"})
 		return
 	}
-	client := conf.Client(ctx, token)
-	userModel, providerID, err := buildUserModel(client, oauthCfg.Providers[providerName])
+	client := conf.Client(reqCtx, token)
+	userModel, providerID, err := buildUserModel(reqCtx, client, providerName, oauthCfg.Providers[providerName])
 	if err != nil {
 		auditLog.WithFields(logrus.Fields{
 			"event":      "oauth_callback",
@@ -134,11 +169,12 @@ func oauthCallback(ctx *gin.Context) {
 		return
 	}
 	var user models.User
-	err = models.DB.Transaction(func(tx *gorm.DB) error {
+	signedUp := false
+	err = models.DB.WithContext(reqCtx).Transaction(func(tx *gorm.DB) error {
 		var existingUser models.User
 		if err := tx.Where("email = ?", userModel.Email).First(&existingUser).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) && !appCfg.AllowOutsideEmail {
-				return fmt.Errorf("outside emails are not allowed")
+			if errors.Is(err, gorm.ErrRecordNotFound) && !appCfg.AllowOutsideEmail && inviteCode == "" {
+				return errOutsideEmail
 			}
 		}
 		oauthMeta := models.UserOauthMeta{
@@ -149,16 +185,24 @@ func oauthCallback(ctx *gin.Context) {
 			Expiry:       token.Expiry,
 		}
 		if existingUser.ID > 0 && existingUser.Active {
+			if !shared.AllowLogin() {
+				return errLoginNotAllowed
+			}
 			var linkedOAuth models.UserOauthMeta
 			err := tx.Where("provider = ? AND provider_id = ?", providerName, providerID).First(&linkedOAuth).Error
 			if err != nil {
 				if errors.Is(err, gorm.ErrRecordNotFound) {
-					return fmt.Errorf("OAuth account not linked. Please link it first before logging in")
+					return errOAuthNotLinked
 				}
 				return fmt.Errorf("failed to fetch OAuth details")
 			}
 			user = existingUser
+		} else if !shared.AllowSignup() {
+			return errSignupNotAllowed
+		} else if models.ReservedUsername(userModel.Username) {
+			return models.ErrUsernameReserved
 		} else if existingUser.ID > 0 {
+			signedUp = true
 			existingUser.Username = userModel.Username
 			existingUser.AvatarURL = userModel.AvatarURL
 			existingUser.Active = true
@@ -170,7 +214,7 @@ func oauthCallback(ctx *gin.Context) {
 				return fmt.Errorf("failed to link OAuth account")
 			}
 			user = existingUser
-		} else if appCfg.AllowOutsideEmail {
+		} else if inviteCode != "" || (appCfg.AllowOutsideEmail && !appCfg.InviteOnly) {
 			newUser := models.User{
 				Username:  userModel.Username,
 				Email:     userModel.Email,
@@ -185,19 +229,25 @@ func oauthCallback(ctx *gin.Context) {
 				return fmt.Errorf("failed to link OAuth account")
 			}
 			user = newUser
+			signedUp = true
+		} else if appCfg.InviteOnly {
+			return errInviteRequired
 		} else {
-			return fmt.Errorf("registration not allowed")
+			return errRegistrationShut
+		}
+		if inviteCode != "" && !(existingUser.ID > 0 && existingUser.Active) {
+			if err := shared.CheckInviteRoster(tx, inviteCode); err != nil {
+				return err
+			}
+			if _, err := models.RedeemInvite(tx, inviteCode, &user, ctx.ClientIP()); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
 	if err != nil {
 		status := "failure"
-		reason := "unknown"
-		if err.Error() == "outside emails are not allowed" || err.Error() == "registration not allowed" || err.Error() == "OAuth account not linked. Please link it first before logging in" {
-			reason = err.Error()
-		} else {
-			reason = "db_error"
-		}
+		reason := oauthFailure(err)
 		auditLog.WithFields(logrus.Fields{
 			"event":      "oauth_callback",
 			"status":     status,
@@ -216,11 +266,15 @@ func oauthCallback(ctx *gin.Context) {
 		}
 		return
 	}
+	if signedUp && user.TeamID != nil {
+		// the invite put the new user on a team
+		shared.TeamCache.Delete(ctx, *user.TeamID)
+	}
 	var teamID uint
 	if user.TeamID != nil {
 		teamID = *user.TeamID
 	}
-	jwtToken, err := utils.GenerateJWT(teamID, user.ID, user.Username, secretCfg.JWTSecret)
+	jwtToken, err := utils.GenerateJWT(teamID, user.ID, user.Username, user.TokenVersion, false, secretCfg.JWTSecret)
 	if err != nil {
 		auditLog.WithFields(logrus.Fields{
 			"event":    "oauth_callback",
@@ -237,7 +291,7 @@ func oauthCallback(ctx *gin.Context) {
 	userInfo := userInfo{
 		ID:        user.ID,
 		Username:  user.Username,
-		AvatarURL: user.AvatarURL,
+		AvatarURL: shared.UserAvatarURL(&user),
 		TeamID:    user.TeamID,
 	}
 	auditLog.WithFields(logrus.Fields{
@@ -250,6 +304,14 @@ func oauthCallback(ctx *gin.Context) {
 		"providerID": providerID,
 		"ip":         ctx.ClientIP(),
 	}).Info("OAuth login successful")
+	if signedUp {
+		events.Publish(events.UserSignedUp, user.ID, teamID, map[string]any{"username": user.Username, "provider": providerName})
+		if teamID != 0 {
+			events.Publish(events.TeamMemberJoined, user.ID, teamID, map[string]any{"username": user.Username, "via": "invite"})
+		}
+	} else {
+		events.Publish(events.UserLogin, user.ID, teamID, map[string]any{"username": user.Username, "method": "oauth", "provider": providerName})
+	}
 	ctx.JSON(http.StatusOK, authResponse{
 		Token: jwtToken,
 		User:  userInfo,
@@ -258,7 +320,7 @@ func oauthCallback(ctx *gin.Context) {
 
 func oauthLink(ctx *gin.Context) {
 	oauthCfg := values.GetConfig().App.OAuth
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	providerName := ctx.Param("provider")
 
 	if !oauthCfg.Enabled {
@@ -300,7 +362,7 @@ func oauthLink(ctx *gin.Context) {
 	userID := ctx.GetUint("user_id")
 	state := fmt.Sprintf("link:%s:%d:%s", providerName, userID, hex.EncodeToString(random))
 	authURL := conf.AuthCodeURL(state, oauth2.AccessTypeOffline)
-	shared.OauthStateCache.Set(state, struct{}{})
+	shared.OauthStateCache.Set(ctx, state, "")
 	auditLog.WithFields(logrus.Fields{
 		"event":    "oauth_link",
 		"status":   "success",
@@ -313,11 +375,11 @@ func oauthLink(ctx *gin.Context) {
 
 func oauthLinkCallBack(ctx *gin.Context) {
 	oauthCfg := values.GetConfig().App.OAuth
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	providerName := ctx.Param("provider")
 	conf := buildOauthLinkConfig(providerName, &oauthCfg)
 	state := ctx.Query("state")
-	if _, ok := shared.OauthStateCache.Get(state); !ok {
+	if _, ok := shared.OauthStateCache.Get(ctx, state); !ok {
 		auditLog.WithFields(logrus.Fields{
 			"event":    "oauth_link_callback",
 			"status":   "failure",
@@ -328,7 +390,7 @@ func oauthLinkCallBack(ctx *gin.Context) {
 		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Invalid or expired state"})
 		return
 	}
-	shared.OauthStateCache.Delete(state)
+	shared.OauthStateCache.Delete(ctx, state)
 	parts := strings.Split(state, ":")
 	if len(parts) < 4 {
 		auditLog.WithFields(logrus.Fields{
@@ -367,7 +429,8 @@ func oauthLinkCallBack(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Missing code in callback"})
 		return
 	}
-	token, err := conf.Exchange(ctx, code)
+	reqCtx := oauthContext(ctx)
+	token, err := exchangeOAuthCode(reqCtx, conf, providerName, code)
 	if err != nil {
 		auditLog.WithFields(logrus.Fields{
 			"event":    "oauth_link_callback",
@@ -381,8 +444,8 @@ func oauthLinkCallBack(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to exchange code for token"})
 		return
 	}
-	client := conf.Client(ctx, token)
-	_, providerID, err := buildUserModel(client, oauthCfg.Providers[providerName])
+	client := conf.Client(reqCtx, token)
+	_, providerID, err := buildUserModel(reqCtx, client, providerName, oauthCfg.Providers[providerName])
 	if err != nil {
 		auditLog.WithFields(logrus.Fields{
 			"event":      "oauth_link_callback",
@@ -398,7 +461,7 @@ func oauthLinkCallBack(ctx *gin.Context) {
 		return
 	}
 	var existingMeta models.UserOauthMeta
-	if err := models.DB.Where("provider = ? AND provider_id = ?", providerName, providerID).First(&existingMeta).Error; err == nil {
+	if err := models.DB.WithContext(reqCtx).Where("provider = ? AND provider_id = ?", providerName, providerID).First(&existingMeta).Error; err == nil {
 		auditLog.WithFields(logrus.Fields{
 			"event":      "oauth_link_callback",
 			"status":     "failure",
@@ -431,7 +494,7 @@ func oauthLinkCallBack(ctx *gin.Context) {
 		RefreshToken: token.RefreshToken,
 		Expiry:       token.Expiry,
 	}
-	if err := models.DB.Create(&oauthMeta).Error; err != nil {
+	if err := models.DB.WithContext(reqCtx).Create(&oauthMeta).Error; err != nil {
 		auditLog.WithFields(logrus.Fields{
 			"event":      "oauth_link_callback",
 			"status":     "failure",
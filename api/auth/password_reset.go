@@ -1,37 +1,55 @@
 package auth
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/lifecycle"
 	"github.com/intraware/rodan-authify/internal/models"
 	"github.com/intraware/rodan-authify/internal/types"
 	"github.com/intraware/rodan-authify/internal/utils"
 	"github.com/intraware/rodan-authify/internal/utils/values"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
+// resetResponseFloor is the minimum time forgotPassword takes to answer, on
+// every path, so that known and unknown accounts are indistinguishable by
+// timing.
+const resetResponseFloor = 500 * time.Millisecond
+
 // forgotPassword godoc
 // @Summary      Forgot password
 // @Description  Initiates password reset process using email, OTP, or backup code.
+// @Description  The email flow answers identically whether or not the account exists.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        type     query     string                   false  "Reset method (email or totp)" Enums(email, totp) default(email)
 // @Param        request  body      forgotPasswordRequest    true   "Forgot password request"
+// @Param        X-PoW-Challenge  header  string  false  "Solved challenge from /auth/challenge, when proof of work is enabled"
+// @Param        X-PoW-Nonce      header  string  false  "Nonce solving the challenge"
 // @Success      200      {object}  resetTokenResponse
 // @Failure      400      {object}  types.ErrorResponse
 // @Failure      401      {object}  types.ErrorResponse
-// @Failure      404      {object}  types.ErrorResponse
 // @Failure      500      {object}  types.ErrorResponse
 // @Router       /auth/forgot-password [post]
 func forgotPassword(ctx *gin.Context) {
+	start := time.Now()
+	respond := func(status int, body any) {
+		waitUntil(start.Add(resetResponseFloor))
+		ctx.JSON(status, body)
+	}
 	var input forgotPasswordRequest
 	appCfg := values.GetConfig().App
 	var user models.User
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	resetType := ctx.DefaultQuery("type", "email")
 	if resetType == "email" && !appCfg.Email.Enabled {
 		auditLog.WithFields(logrus.Fields{
@@ -41,7 +59,7 @@ func forgotPassword(ctx *gin.Context) {
 			"resetType": resetType,
 			"ip":        ctx.ClientIP(),
 		}).Warn("Email service not enabled")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Email service is not enabled"})
+		respond(http.StatusBadRequest, gin.H{"error": "Email service is not enabled"})
 		return
 	} else if resetType == "totp" && !appCfg.TOTP.Enabled {
 		auditLog.WithFields(logrus.Fields{
@@ -51,7 +69,7 @@ func forgotPassword(ctx *gin.Context) {
 			"resetType": resetType,
 			"ip":        ctx.ClientIP(),
 		}).Warn("TOTP service not enabled")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "TOTP service is not enabled"})
+		respond(http.StatusBadRequest, gin.H{"error": "TOTP service is not enabled"})
 		return
 	}
 	if err := ctx.ShouldBindJSON(&input); err != nil {
@@ -61,7 +79,7 @@ func forgotPassword(ctx *gin.Context) {
 			"reason": "invalid_json",
 			"ip":     ctx.ClientIP(),
 		}).Warn("Invalid forgot password input")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		respond(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 	var otpSet, backupSet bool
@@ -76,137 +94,134 @@ func forgotPassword(ctx *gin.Context) {
 				"username": input.Username,
 				"ip":       ctx.ClientIP(),
 			}).Warn("Invalid OTP/Backup Code usage")
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Provide either OTP or Backup Code, not both"})
+			respond(http.StatusBadRequest, gin.H{"error": "Provide either OTP or Backup Code, not both"})
 			return
 		}
 	}
-	var ok bool
-	if user, ok = shared.LoginCache.Get(input.Username); ok {
-		ctx.Set("message", fmt.Sprintf("User %d loaded from login cache", user.ID))
+	var found bool
+	if err := models.DB.WithContext(ctx).Where("username = ?", input.Username).First(&user).Error; err == nil {
+		found = true
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.Set("message", err.Error())
 		auditLog.WithFields(logrus.Fields{
 			"event":    "forgot_password",
-			"status":   "info",
-			"reason":   "cache_hit",
-			"user_id":  user.ID,
-			"username": user.Username,
+			"status":   "failure",
+			"reason":   "user_not_found",
+			"username": input.Username,
 			"ip":       ctx.ClientIP(),
-		}).Info("User loaded from cache for password reset")
+		}).Warn("User not found for forgot password")
 	} else {
-		if err := models.DB.Where("username = ?", input.Username).First(&user).Error; err != nil {
-			ctx.Set("message", err.Error())
-			auditLog.WithFields(logrus.Fields{
-				"event":    "forgot_password",
-				"status":   "failure",
-				"reason":   "user_not_found",
-				"username": input.Username,
-				"ip":       ctx.ClientIP(),
-			}).Warn("User not found for forgot password")
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
-			return
-		}
-	}
-	if resetType == "email" {
-		token, err := generateResetToken()
-		if err != nil {
-			auditLog.WithFields(logrus.Fields{
-				"event":    "forgot_password",
-				"status":   "failure",
-				"reason":   "token_generation_failed",
-				"user_id":  user.ID,
-				"username": user.Username,
-				"ip":       ctx.ClientIP(),
-				"error":    err.Error(),
-			}).Error("Failed to generate password reset token")
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
-			return
-		}
-		shared.ResetPasswordCache.Set(token, user)
-		if err := sendResetToken(user.Email, token); err != nil {
-			auditLog.WithFields(logrus.Fields{
-				"event":    "forgot_password",
-				"status":   "failure",
-				"method":   "email",
-				"username": user.Username,
-				"email":    user.Email,
-				"reason":   "send_email_failed",
-				"ip":       ctx.ClientIP(),
-			}).Errorf("Failed to send password reset email: %v", err)
-			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{
-				Error: "Failed to send the email",
-			})
-			return
-		}
 		auditLog.WithFields(logrus.Fields{
 			"event":    "forgot_password",
-			"status":   "success",
-			"method":   "email",
-			"username": user.Username,
-			"email":    user.Email,
+			"status":   "failure",
+			"reason":   "db_error",
+			"username": input.Username,
 			"ip":       ctx.ClientIP(),
-		}).Info("Password reset email sent successfully")
-		ctx.JSON(http.StatusOK, types.SuccessResponse{
-			Message: "Reset token sent successfully to the mail",
-		})
+			"error":    err.Error(),
+		}).Error("Failed to fetch user for forgot password")
+		respond(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to process the request"})
 		return
 	}
-	var userTOTP models.UserTOTPMeta
-	if userTOTP, ok = shared.TOTPCache.Get(user.Username); !ok {
-		if err := models.DB.Where("user_id = ?", user.ID).First(&userTOTP).Error; err != nil {
-			auditLog.WithFields(logrus.Fields{
-				"event":   "profile_totp",
-				"status":  "failure",
-				"reason":  "db_error",
-				"user_id": user.ID,
-				"ip":      ctx.ClientIP(),
-				"error":   err.Error(),
-			}).Error("Failed to fetch user data in TOTP Metadata")
-			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch user"})
-			return
+	if resetType == "email" {
+		if found {
+			token, err := issueResetToken(ctx, user)
+			if err != nil {
+				auditLog.WithFields(logrus.Fields{
+					"event":    "forgot_password",
+					"status":   "failure",
+					"reason":   "token_generation_failed",
+					"user_id":  user.ID,
+					"username": user.Username,
+					"ip":       ctx.ClientIP(),
+					"error":    err.Error(),
+				}).Error("Failed to generate password reset token")
+			} else {
+				// Delivery happens off the request path so that the response
+				// time does not reveal whether the account exists.
+				ip := ctx.ClientIP()
+				sendCtx := context.WithoutCancel(ctx.Request.Context())
+				lifecycle.Go(func() {
+					if err := sendResetToken(sendCtx, user.Email, token); err != nil {
+						auditLog.WithFields(logrus.Fields{
+							"event":    "forgot_password",
+							"status":   "failure",
+							"method":   "email",
+							"username": user.Username,
+							"email":    user.Email,
+							"reason":   "send_email_failed",
+							"ip":       ip,
+						}).Errorf("Failed to send password reset email: %v", err)
+						return
+					}
+					auditLog.WithFields(logrus.Fields{
+						"event":    "forgot_password",
+						"status":   "success",
+						"method":   "email",
+						"username": user.Username,
+						"email":    user.Email,
+						"ip":       ip,
+					}).Info("Password reset email sent successfully")
+				})
+			}
 		}
-		shared.TOTPCache.Set(user.Username, userTOTP)
+		respond(http.StatusOK, types.SuccessResponse{
+			Message: "If the account exists, a reset link has been sent to its email",
+		})
+		return
 	}
-	if otpSet && userTOTP.VerifyTOTP(*input.OTP) {
-		ctx.Set("message", fmt.Sprintf("User %d resetting password using TOTP", user.ID))
-		auditLog.WithFields(logrus.Fields{
-			"event":    "forgot_password_auth",
-			"status":   "success",
-			"method":   "totp",
-			"user_id":  user.ID,
-			"username": user.Username,
-			"ip":       ctx.ClientIP(),
-		}).Info("Password reset authorized via TOTP")
-	} else if backupSet && userTOTP.BackupCode == *input.BackupCode {
-		ctx.Set("message", fmt.Sprintf("User %d resetting password using Backup code", user.ID))
-		auditLog.WithFields(logrus.Fields{
-			"event":    "forgot_password_auth",
-			"status":   "success",
-			"method":   "backup_code",
-			"user_id":  user.ID,
-			"username": user.Username,
-			"ip":       ctx.ClientIP(),
-		}).Info("Password reset authorized via Backup Code")
-	} else {
-		errMsg := "Invalid credentials"
-		method := "unknown"
-		if otpSet {
-			errMsg = "Invalid OTP"
-			method = "totp"
-		} else if backupSet {
-			errMsg = "Invalid Backup Code"
-			method = "backup_code"
+	authorized := false
+	method := "totp"
+	if backupSet {
+		method = "backup_code"
+	}
+	if found {
+		var userTOTP models.UserTOTPMeta
+		var ok bool
+		if userTOTP, ok = shared.TOTPCache.Get(ctx, user.Username); !ok {
+			if err := models.DB.WithContext(ctx).Where("user_id = ?", user.ID).First(&userTOTP).Error; err != nil {
+				auditLog.WithFields(logrus.Fields{
+					"event":   "forgot_password_auth",
+					"status":  "failure",
+					"reason":  "totp_not_configured",
+					"user_id": user.ID,
+					"ip":      ctx.ClientIP(),
+					"error":   err.Error(),
+				}).Warn("Failed to fetch user data in TOTP Metadata")
+			} else {
+				shared.TOTPCache.Set(ctx, user.Username, userTOTP)
+				ok = true
+			}
+		}
+		if ok {
+			if otpSet {
+				authorized = userTOTP.VerifyTOTP(*input.OTP)
+			} else {
+				authorized = subtle.ConstantTimeCompare([]byte(userTOTP.BackupCode), []byte(*input.BackupCode)) == 1
+			}
 		}
+	}
+	if !authorized {
 		auditLog.WithFields(logrus.Fields{
 			"event":    "forgot_password_auth",
 			"status":   "failure",
 			"reason":   "invalid_" + method,
 			"user_id":  user.ID,
-			"username": user.Username,
+			"username": input.Username,
 			"ip":       ctx.ClientIP(),
 		}).Warn("Failed password reset authentication")
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": errMsg})
+		respond(http.StatusUnauthorized, types.ErrorResponse{Error: "Invalid credentials"})
 		return
 	}
-	token, err := generateResetToken()
+	ctx.Set("message", fmt.Sprintf("User %d resetting password using %s", user.ID, method))
+	auditLog.WithFields(logrus.Fields{
+		"event":    "forgot_password_auth",
+		"status":   "success",
+		"method":   method,
+		"user_id":  user.ID,
+		"username": user.Username,
+		"ip":       ctx.ClientIP(),
+	}).Info("Password reset authorized")
+	token, err := issueResetToken(ctx, user)
 	if err != nil {
 		ctx.Set("message", err.Error())
 		auditLog.WithFields(logrus.Fields{
@@ -218,10 +233,9 @@ func forgotPassword(ctx *gin.Context) {
 			"ip":       ctx.ClientIP(),
 			"error":    err.Error(),
 		}).Error("Failed to generate password reset token")
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		respond(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
-	shared.ResetPasswordCache.Set(token, user)
 	auditLog.WithFields(logrus.Fields{
 		"event":    "forgot_password_token_issued",
 		"status":   "success",
@@ -229,14 +243,14 @@ func forgotPassword(ctx *gin.Context) {
 		"username": user.Username,
 		"ip":       ctx.ClientIP(),
 	}).Info("Password reset token successfully issued")
-	ctx.JSON(http.StatusOK, resetTokenResponse{
+	respond(http.StatusOK, resetTokenResponse{
 		ResetToken: token,
 	})
 }
 
 // resetPassword godoc
 // @Summary      Reset password
-// @Description  Resets user password using a valid reset token
+// @Description  Resets user password using a valid single-use reset token and revokes all sessions
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -249,7 +263,7 @@ func forgotPassword(ctx *gin.Context) {
 // @Router       /auth/reset-password/{token} [post]
 func resetPassword(ctx *gin.Context) {
 	token := ctx.Param("token")
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	if token == "" {
 		auditLog.WithFields(logrus.Fields{
 			"event":  "reset_password",
@@ -260,32 +274,71 @@ func resetPassword(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing reset token"})
 		return
 	}
-	user, ok := shared.ResetPasswordCache.Get(token)
-	if !ok {
+	var input resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":  "reset_password",
+			"status": "failure",
+			"reason": "invalid_json",
+			"ip":     ctx.ClientIP(),
+		}).Warn("Invalid input during password reset")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	tokenHash := hashResetToken(token)
+	// taking the token claims it; it goes back if the reset fails on our side
+	reset, ok := shared.ResetPasswordCache.Take(ctx, tokenHash)
+	if !ok || reset.UserID == 0 {
 		auditLog.WithFields(logrus.Fields{
 			"event":  "reset_password",
 			"status": "failure",
 			"reason": "invalid_or_expired_token",
-			"token":  token,
 			"ip":     ctx.ClientIP(),
 		}).Warn("Reset password token invalid or expired")
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 		return
 	}
-	var input resetPasswordRequest
-	if err := ctx.ShouldBindJSON(&input); err != nil {
+	var user models.User
+	if err := models.DB.WithContext(ctx).First(&user, reset.UserID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			shared.ResetPasswordCache.Set(ctx, tokenHash, reset)
+			auditLog.WithFields(logrus.Fields{
+				"event":   "reset_password",
+				"status":  "failure",
+				"reason":  "db_error",
+				"user_id": reset.UserID,
+				"ip":      ctx.ClientIP(),
+				"error":   err.Error(),
+			}).Error("Failed to fetch user behind reset token")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+			return
+		}
+		auditLog.WithFields(logrus.Fields{
+			"event":   "reset_password",
+			"status":  "failure",
+			"reason":  "user_not_found",
+			"user_id": reset.UserID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Warn("User behind reset token no longer exists")
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		return
+	}
+	if user.PasswordFingerprint() != reset.Fingerprint {
 		auditLog.WithFields(logrus.Fields{
 			"event":    "reset_password",
 			"status":   "failure",
-			"reason":   "invalid_json",
+			"reason":   "password_changed",
 			"user_id":  user.ID,
 			"username": user.Username,
 			"ip":       ctx.ClientIP(),
-		}).Warn("Invalid input during password reset")
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		}).Warn("Reset token issued before the last password change")
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 		return
 	}
+	oldHash := user.Password
 	if err := user.SetPassword(input.Password); err != nil {
+		shared.ResetPasswordCache.Set(ctx, tokenHash, reset)
 		auditLog.WithFields(logrus.Fields{
 			"event":    "reset_password",
 			"status":   "failure",
@@ -298,7 +351,13 @@ func resetPassword(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set password"})
 		return
 	}
-	if err := models.DB.Model(&user).Update("password", user.Password).Error; err != nil {
+	// the password the token was issued for must still be in place
+	result := models.DB.WithContext(ctx).Model(&models.User{}).Where("id = ? AND password = ?", user.ID, oldHash).Updates(map[string]any{
+		"password":      user.Password,
+		"token_version": gorm.Expr("token_version + 1"),
+	})
+	if result.Error != nil {
+		shared.ResetPasswordCache.Set(ctx, tokenHash, reset)
 		auditLog.WithFields(logrus.Fields{
 			"event":    "reset_password",
 			"status":   "failure",
@@ -306,19 +365,31 @@ func resetPassword(ctx *gin.Context) {
 			"user_id":  user.ID,
 			"username": user.Username,
 			"ip":       ctx.ClientIP(),
-			"error":    err.Error(),
+			"error":    result.Error.Error(),
 		}).Error("Failed to update password in DB")
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
 		return
 	}
-	shared.ResetPasswordCache.Delete(token)
-	shared.LoginCache.Delete(user.Email)
+	if result.RowsAffected == 0 {
+		auditLog.WithFields(logrus.Fields{
+			"event":    "reset_password",
+			"status":   "failure",
+			"reason":   "password_changed",
+			"user_id":  user.ID,
+			"username": user.Username,
+			"ip":       ctx.ClientIP(),
+		}).Warn("Password changed while the reset token was redeemed")
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		return
+	}
+	shared.UserCache.Delete(ctx, user.ID)
+	shared.LoginCache.Delete(ctx, user.Username)
 	auditLog.WithFields(logrus.Fields{
 		"event":    "reset_password",
 		"status":   "success",
 		"user_id":  user.ID,
 		"username": user.Username,
 		"ip":       ctx.ClientIP(),
-	}).Info("Password reset successfully")
+	}).Info("Password reset successfully, all sessions revoked")
 	ctx.JSON(http.StatusOK, gin.H{"message": "Password has been reset successfully"})
 }
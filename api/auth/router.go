@@ -8,11 +8,16 @@ import (
 
 func LoadAuth(r *gin.RouterGroup) {
 	authRouter := r.Group("/auth")
-	authRouter.POST("/signup", signUp)
-	authRouter.POST("/login", login)
 	cfg := values.GetConfig().App
-	if cfg.Email.Enabled && cfg.TOTP.Enabled {
-		authRouter.POST("/forgot-password", forgotPassword)
+	guarded := []gin.HandlerFunc{}
+	if cfg.PoW.Enabled {
+		authRouter.GET("/challenge", issueChallenge)
+		guarded = append(guarded, middleware.ProofOfWork)
+	}
+	authRouter.POST("/signup", append(guarded, signUp)...)
+	authRouter.POST("/login", append(guarded, login)...)
+	if cfg.Email.Enabled || cfg.TOTP.Enabled {
+		authRouter.POST("/forgot-password", append(guarded, forgotPassword)...)
 		authRouter.POST("/reset-password/:token", resetPassword)
 	}
 	if cfg.OAuth.Enabled {
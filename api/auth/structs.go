@@ -1,15 +1,16 @@
 package auth
 
 type signUpRequest struct {
-	Username  string `json:"username" binding:"required" example:"intraware"`
-	Email     string `json:"email" binding:"required,email" example:"example@intraware.org"`
-	Password  string `json:"password" binding:"required,min=8" example:"mystrongpassword"`
-	AvatarURL string `json:"avatar_url" binding:"required" example:"https://..."`
+	Username   string `json:"username" binding:"required" example:"intraware"`
+	Email      string `json:"email" binding:"required,email" example:"example@intraware.org"`
+	Password   string `json:"password" binding:"required,min=8" example:"mystrongpassword"`
+	InviteCode string `json:"invite_code" example:"9f86d081884c7d65"`
 }
 
 type loginRequest struct {
-	Username string `json:"username" binding:"required" example:"intraware"`
-	Password string `json:"password" binding:"required" example:"mystrongpassword"`
+	Username string  `json:"username" binding:"required" example:"intraware"`
+	Password string  `json:"password" binding:"required" example:"mystrongpassword"`
+	OTP      *string `json:"otp" example:"11223344"`
 }
 
 type authResponse struct {
@@ -38,3 +39,10 @@ type forgotPasswordRequest struct {
 type resetTokenResponse struct {
 	ResetToken string `json:"reset_token" example:"abc123def456..."`
 }
+
+type challengeResponse struct {
+	Challenge  string `json:"challenge" example:"3f2a...e1.18.1760000000.9c4b..."`
+	Difficulty int    `json:"difficulty" example:"18"`
+	ExpiresAt  int64  `json:"expires_at" example:"1760000000"`
+	Algorithm  string `json:"algorithm" example:"sha256"`
+}
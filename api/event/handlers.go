@@ -0,0 +1,29 @@
+package event
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+)
+
+// getStatus godoc
+// @Summary      Event status
+// @Description  Returns the current event phase, the state of the signup/login/roster toggles and the schedule
+// @Tags         event
+// @Produce      json
+// @Success      200  {object}  statusResponse
+// @Router       /event/status [get]
+func getStatus(ctx *gin.Context) {
+	now := time.Now().UTC()
+	schedule := shared.Schedule()
+	ctx.JSON(http.StatusOK, statusResponse{
+		Phase:        schedule.Phase(now),
+		SignupOpen:   shared.AllowSignup(),
+		LoginOpen:    shared.AllowLogin(),
+		RosterFrozen: shared.RosterFrozen(),
+		ServerTime:   now,
+		Schedule:     schedule,
+	})
+}
@@ -0,0 +1,8 @@
+package event
+
+import "github.com/gin-gonic/gin"
+
+func LoadEvent(r *gin.RouterGroup) {
+	eventRouter := r.Group("/event")
+	eventRouter.GET("/status", getStatus)
+}
@@ -0,0 +1,16 @@
+package event
+
+import (
+	"time"
+
+	"github.com/intraware/rodan-authify/api/shared"
+)
+
+type statusResponse struct {
+	Phase        string               `json:"phase" example:"running" enums:"upcoming,registration,running,frozen,ended"`
+	SignupOpen   bool                 `json:"signup_open" example:"false"`
+	LoginOpen    bool                 `json:"login_open" example:"true"`
+	RosterFrozen bool                 `json:"roster_frozen" example:"true"`
+	ServerTime   time.Time            `json:"server_time" example:"2026-11-07T12:00:00Z"`
+	Schedule     shared.EventSchedule `json:"schedule"`
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/cache"
+	"github.com/intraware/rodan-authify/internal/lifecycle"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/utils/email"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// healthz reports that the process is up. It checks nothing else, so a slow
+// dependency never gets the pod restarted.
+func healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyz reports whether this instance should receive traffic: the database
+// and, when configured, Redis must answer and the email provider must be
+// usable. It fails as soon as shutdown begins so the load balancer stops
+// routing here while requests drain.
+func readyz(ctx *gin.Context) {
+	if lifecycle.Draining() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting down"})
+		return
+	}
+	reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), readinessTimeout)
+	defer cancel()
+	checks := gin.H{}
+	ready := true
+	check := func(name string, err error) {
+		if err != nil {
+			checks[name] = err.Error()
+			ready = false
+			return
+		}
+		checks[name] = "ok"
+	}
+	sqlDB, err := models.DB.DB()
+	if err == nil {
+		err = sqlDB.PingContext(reqCtx)
+	}
+	check("database", err)
+	if !values.GetConfig().App.AppCache.InApp {
+		check("redis", cache.Ping(reqCtx))
+	}
+	if values.GetConfig().App.Email.Enabled {
+		check("email", email.CheckConfig())
+	}
+	if !ready {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok", "checks": checks})
+}
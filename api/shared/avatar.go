@@ -0,0 +1,101 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils/avatar"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+)
+
+// AvatarStore holds uploaded avatars; Init opens it.
+var AvatarStore avatar.Store
+
+// UserAvatarURL is the avatar shown for user: the upload if there is one,
+// else a URL set by an admin or OAuth, else the user's identicon.
+func UserAvatarURL(user *models.User) string {
+	switch {
+	case user.AvatarKey != "":
+		return AvatarStore.URL(avatar.FileKey(user.AvatarKey, avatar.DefaultSize))
+	case user.AvatarURL != "":
+		return user.AvatarURL
+	}
+	return fmt.Sprintf("/api/user/%d/avatar", user.ID)
+}
+
+// TeamAvatarURL is the avatar shown for team: the upload if there is one,
+// else the team's identicon.
+func TeamAvatarURL(team *models.Team) string {
+	if team.AvatarKey != "" {
+		return AvatarStore.URL(avatar.FileKey(team.AvatarKey, avatar.DefaultSize))
+	}
+	return fmt.Sprintf("/api/team/%d/avatar", team.ID)
+}
+
+// ReceiveAvatar reads and processes the "avatar" file of a multipart upload.
+// It writes the error response itself when the upload is unusable.
+func ReceiveAvatar(ctx *gin.Context) (map[int][]byte, bool) {
+	maxSize := values.GetConfig().App.Avatar.MaxSize
+	// room for the multipart framing around the file
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize+64<<10)
+	header, err := ctx.FormFile("avatar")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, types.ErrorResponse{Error: "Avatar file is too large"})
+		return nil, false
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Missing avatar file"})
+		return nil, false
+	}
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Failed to read avatar file"})
+		return nil, false
+	}
+	defer file.Close()
+	files, err := avatar.Process(file, maxSize)
+	switch {
+	case errors.Is(err, avatar.ErrTooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, types.ErrorResponse{Error: "Avatar file is too large"})
+	case errors.Is(err, avatar.ErrUnsupported):
+		ctx.JSON(http.StatusUnsupportedMediaType, types.ErrorResponse{Error: "Avatar must be a PNG, JPEG or GIF image"})
+	case errors.Is(err, avatar.ErrInvalid):
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Avatar is not a readable image"})
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to process avatar"})
+	default:
+		return files, true
+	}
+	return nil, false
+}
+
+// ServeAvatar answers an avatar lookup for ?size= pixels: a redirect to the
+// stored upload under key or to an external URL, or else the identicon drawn
+// from seed.
+func ServeAvatar(ctx *gin.Context, key, external, seed string) {
+	size := avatar.DefaultSize
+	if n, err := strconv.Atoi(ctx.Query("size")); err == nil && n > 0 {
+		size = avatar.NearestSize(n)
+	}
+	switch {
+	case key != "":
+		ctx.Redirect(http.StatusFound, AvatarStore.URL(avatar.FileKey(key, size)))
+		return
+	case external != "":
+		ctx.Redirect(http.StatusFound, external)
+		return
+	}
+	img, err := avatar.Identicon(seed, size)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to draw avatar"})
+		return
+	}
+	ctx.Header("Cache-Control", "public, max-age=86400")
+	ctx.Data(http.StatusOK, "image/png", img)
+}
@@ -0,0 +1,96 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"gorm.io/gorm"
+)
+
+var ErrUserBanDisabled = errors.New("user bans are disabled")
+
+// BanDuration is how long the next ban of a user with the given number of
+// earlier bans lasts.
+func BanDuration(previous int64) time.Duration {
+	cfg := values.GetConfig().App.Ban
+	d := time.Duration(float64(cfg.InitialBanDuration) * math.Pow(cfg.BanGrowthFactor, float64(previous)))
+	if cfg.MaxBanDuration > 0 && (d > cfg.MaxBanDuration || d <= 0) {
+		d = cfg.MaxBanDuration
+	}
+	return d
+}
+
+// BanUser bans the user and revokes their sessions. A zero duration escalates
+// with the number of earlier bans as configured under [app.ban].
+func BanUser(ctx context.Context, userID uint, reason string, duration time.Duration) (ban models.BanHistory, err error) {
+	if !values.GetConfig().App.Ban.UserBan {
+		return ban, ErrUserBanDisabled
+	}
+	var user models.User
+	err = models.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if duration <= 0 {
+			var previous int64
+			if err := tx.Model(&models.BanHistory{}).Where("user_id = ?", userID).Count(&previous).Error; err != nil {
+				return err
+			}
+			duration = BanDuration(previous)
+		}
+		ban = models.BanHistory{
+			UserID:    &user.ID,
+			ExpiresAt: time.Now().Add(duration).Unix(),
+			Context:   reason,
+		}
+		if err := tx.Create(&ban).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).Updates(map[string]any{
+			"ban":           true,
+			"token_version": gorm.Expr("token_version + 1"),
+		}).Error
+	})
+	if err != nil {
+		return
+	}
+	ForgetUser(ctx, user.ID)
+	ForgetLogin(ctx, user.Username)
+	var teamID uint
+	if user.TeamID != nil {
+		teamID = *user.TeamID
+	}
+	events.Publish(events.UserBanned, user.ID, teamID, map[string]any{
+		"username":   user.Username,
+		"reason":     reason,
+		"expires_at": time.Unix(ban.ExpiresAt, 0).UTC(),
+	})
+	return
+}
+
+// UnbanUser lifts the user's ban and ends any ban that is still running.
+func UnbanUser(ctx context.Context, userID uint) error {
+	var user models.User
+	err := models.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		now := time.Now().Unix()
+		if err := tx.Model(&models.BanHistory{}).Where("user_id = ? AND expires_at > ?", userID, now).
+			Update("expires_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).Update("ban", false).Error
+	})
+	if err != nil {
+		return err
+	}
+	ForgetUser(ctx, user.ID)
+	ForgetLogin(ctx, user.Username)
+	return nil
+}
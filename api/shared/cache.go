@@ -8,8 +8,12 @@ import (
 var UserCache cache.Cache[uint, models.User]
 var TeamCache cache.Cache[uint, models.Team]
 var LoginCache cache.Cache[string, models.User]
-var ResetPasswordCache cache.Cache[string, models.User]
+var ResetPasswordCache cache.Cache[string, models.PasswordReset]
 var BanHistoryCache cache.Cache[string, models.BanHistory]
 var TOTPCache cache.Cache[string, models.UserTOTPMeta]
 var OAuthCache cache.Cache[uint, models.UserOauthMeta]
-var OauthStateCache cache.Cache[string, struct{}]
+var OauthStateCache cache.Cache[string, string] // state -> invite code, if any
+var PoWChallengeCache cache.Cache[string, struct{}]
+var PoWRateCache cache.Counter[string]
+var AdminKeyCache cache.Cache[string, models.AdminAPIKey] // prefix -> key
+var StreamTicketCache cache.Cache[string, StreamTicket]   // ticket hash -> ticket
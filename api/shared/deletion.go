@@ -0,0 +1,109 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/avatar"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// AnonymiseAccount anonymises a user whose deletion grace period has ended.
+// It does nothing if the deletion was cancelled in the meantime or another
+// instance got there first.
+func AnonymiseAccount(ctx context.Context, userID uint) error {
+	var user models.User
+	var team models.Team
+	err := models.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("delete_after IS NOT NULL AND delete_after <= ?", time.Now()).
+			First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.TeamID != nil {
+			if err := tx.First(&team, *user.TeamID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+		return models.Anonymise(tx, &user)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ForgetUser(ctx, user.ID)
+	ForgetLogin(ctx, user.Username)
+	ForgetTOTP(ctx, user.Username)
+	OAuthCache.Delete(ctx, user.ID)
+	if user.AvatarKey != "" {
+		if err := avatar.Remove(ctx, AvatarStore, user.AvatarKey); err != nil {
+			utils.Logger.WithFields(logrus.Fields{
+				"user_id":    user.ID,
+				"avatar_key": user.AvatarKey,
+				"error":      err.Error(),
+			}).Warn("Failed to delete avatar files of a deleted account")
+		}
+	}
+	events.Publish(events.UserDeleted, user.ID, team.ID, nil)
+	if team.ID != 0 {
+		ForgetTeam(ctx, team.ID)
+		var after models.Team
+		err := models.DB.WithContext(ctx).First(&after, team.ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// the user was the last member
+			events.Publish(events.TeamDeleted, user.ID, team.ID, map[string]any{"name": team.Name})
+			return nil
+		}
+		events.Publish(events.TeamMemberLeft, user.ID, team.ID, map[string]any{"via": "deleted"})
+		var leader models.User
+		if err == nil && after.LeaderID != team.LeaderID &&
+			models.DB.WithContext(ctx).First(&leader, after.LeaderID).Error == nil {
+			events.Publish(events.TeamLeaderChanged, leader.ID, team.ID, map[string]any{"leader": leader.Username})
+		}
+	}
+	return nil
+}
+
+// RunDeletionPurge anonymises accounts whose grace period has ended, checking
+// every interval until ctx is cancelled.
+func RunDeletionPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purgeDueDeletions(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purgeDueDeletions(ctx context.Context) {
+	users, err := models.DueDeletions(models.DB, time.Now(), 50)
+	if err != nil {
+		utils.Logger.WithField("error", err.Error()).Warn("Failed to look up due account deletions")
+		return
+	}
+	for _, user := range users {
+		log := utils.Logger.WithFields(logrus.Fields{
+			"type":    "audit",
+			"event":   "anonymise_account",
+			"user_id": user.ID,
+		})
+		if err := AnonymiseAccount(ctx, user.ID); err != nil {
+			log.WithFields(logrus.Fields{
+				"status": "failure",
+				"error":  err.Error(),
+			}).Error("Failed to anonymise account")
+			continue
+		}
+		log.WithField("status", "success").Info("Account anonymised after its deletion grace period")
+	}
+}
@@ -0,0 +1,90 @@
+package shared
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// Viewer returns the signed-in caller, or nil for anonymous requests. The
+// route needs OptionalAuth or AuthRequired in front of it.
+func Viewer(ctx *gin.Context) *models.User {
+	userID := ctx.GetUint("user_id")
+	if userID == 0 {
+		return nil
+	}
+	user, ok := UserCache.Get(ctx, userID)
+	if !ok {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
+			return nil
+		}
+		UserCache.Set(ctx, userID, user)
+	}
+	return &user
+}
+
+// Pagination is the ?page= and ?per_page= of a listing, 1-based.
+type Pagination struct {
+	Page    int   `json:"page" example:"1"`
+	PerPage int   `json:"per_page" example:"20"`
+	Total   int64 `json:"total" example:"132"`
+}
+
+// ParsePagination reads the page parameters, falling back to the first page
+// of defaultPerPage and capping per_page at maxPerPage.
+func ParsePagination(ctx *gin.Context) Pagination {
+	p := Pagination{Page: 1, PerPage: defaultPerPage}
+	if n, err := strconv.Atoi(ctx.Query("page")); err == nil && n > 0 {
+		p.Page = n
+	}
+	if n, err := strconv.Atoi(ctx.Query("per_page")); err == nil && n > 0 {
+		p.PerPage = min(n, maxPerPage)
+	}
+	return p
+}
+
+// Apply counts the rows of query into Total and limits it to the page.
+func (p *Pagination) Apply(query *gorm.DB) (*gorm.DB, error) {
+	query = query.Session(&gorm.Session{}) // safe to reuse after counting
+	if err := query.Count(&p.Total).Error; err != nil {
+		return nil, err
+	}
+	return query.Offset((p.Page - 1) * p.PerPage).Limit(p.PerPage), nil
+}
+
+// SortOrder turns ?sort= into an ORDER BY over the allowed keys, each mapped
+// to its column; a leading "-" sorts descending. It writes the error response
+// itself for unknown keys.
+func SortOrder(ctx *gin.Context, allowed map[string]string, fallback string) (string, bool) {
+	key := ctx.DefaultQuery("sort", fallback)
+	dir := "ASC"
+	if strings.HasPrefix(key, "-") {
+		key, dir = key[1:], "DESC"
+	}
+	column, ok := allowed[key]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Unknown sort key " + key})
+		return "", false
+	}
+	return column + " " + dir + ", id " + dir, true
+}
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// Search narrows query to rows whose column contains q, ignoring case.
+func Search(query *gorm.DB, column, q string) *gorm.DB {
+	if q = strings.TrimSpace(q); q == "" {
+		return query
+	}
+	return query.Where("LOWER("+column+") LIKE ? ESCAPE '!'", "%"+likeEscaper.Replace(strings.ToLower(q))+"%")
+}
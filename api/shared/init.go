@@ -1,16 +1,33 @@
 package shared
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/intraware/rodan-authify/internal/cache"
 	"github.com/intraware/rodan-authify/internal/config"
 	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/utils/avatar"
+	"github.com/sirupsen/logrus"
 )
 
 func ptr[T any](v T) *T { return &v }
 
 func Init(config *config.AppConfig) {
+	if err := LoadFlags(); err != nil {
+		logrus.Fatalf("Failed to load feature flags: %v", err)
+	}
+	if err := LoadSchedule(); err != nil {
+		logrus.Fatalf("Failed to load the event schedule: %v", err)
+	}
+	// only a schedule stored after the flags last changed is still unapplied
+	scheduleChanged.Store(scheduleOverride.Load() != nil &&
+		time.Unix(0, scheduleVersion.Load()).After(LastFlagChange()))
+	store, err := avatar.NewStore(config.Avatar)
+	if err != nil {
+		logrus.Fatalf("Failed to open avatar storage: %v", err)
+	}
+	AvatarStore = store
 	UserCache = cache.NewCache[uint, models.User](&cache.CacheOpts{
 		TimeToLive:    3 * time.Minute,
 		CleanInterval: ptr(time.Hour * 2),
@@ -29,8 +46,8 @@ func Init(config *config.AppConfig) {
 		Revaluate:     ptr(true),
 		Prefix:        "login-cache",
 	})
-	ResetPasswordCache = cache.NewCache[string, models.User](&cache.CacheOpts{
-		TimeToLive:    time.Duration(config.TokenExpiry) * time.Minute,
+	ResetPasswordCache = cache.NewCache[string, models.PasswordReset](&cache.CacheOpts{
+		TimeToLive:    ResetTokenExpiry(config),
 		CleanInterval: ptr(time.Hour * 2),
 		Revaluate:     ptr(true),
 		Prefix:        "reset-password-cache",
@@ -53,15 +70,59 @@ func Init(config *config.AppConfig) {
 		Revaluate:     ptr(true),
 		Prefix:        "oauth-cache",
 	})
-	OauthStateCache = cache.NewCache[string, struct{}](&cache.CacheOpts{
+	OauthStateCache = cache.NewCache[string, string](&cache.CacheOpts{
 		TimeToLive:    5 * time.Minute,
 		CleanInterval: ptr(time.Hour),
 		Revaluate:     ptr(false),
 		Prefix:        "oauth-state-cache",
 	})
+	PoWChallengeCache = cache.NewCache[string, struct{}](&cache.CacheOpts{
+		TimeToLive:    config.PoW.ChallengeTTL,
+		CleanInterval: ptr(time.Hour),
+		Revaluate:     ptr(false),
+		Prefix:        "pow-challenge-cache",
+	})
+	PoWRateCache = cache.NewCounter[string](&cache.CacheOpts{
+		TimeToLive:    config.PoW.Window,
+		CleanInterval: ptr(time.Hour),
+		Revaluate:     ptr(false),
+		Prefix:        "pow-rate-cache",
+	})
+	AdminKeyCache = cache.NewCache[string, models.AdminAPIKey](&cache.CacheOpts{
+		TimeToLive:    time.Minute,
+		CleanInterval: ptr(time.Hour),
+		Revaluate:     ptr(false),
+		Prefix:        "admin-key-cache",
+	})
+	StreamTicketCache = cache.NewCache[string, StreamTicket](&cache.CacheOpts{
+		TimeToLive:    StreamTicketTTL,
+		CleanInterval: ptr(time.Hour),
+		Revaluate:     ptr(false),
+		Prefix:        "stream-ticket-cache",
+	})
+}
+
+// ResetTokenExpiry is how long a password reset token stays valid.
+func ResetTokenExpiry(config *config.AppConfig) time.Duration {
+	if config.ResetTokenExpiry > 0 {
+		return config.ResetTokenExpiry
+	}
+	return 15 * time.Minute
+}
+
+// DeletionGrace is how long a requested account deletion can be cancelled
+// before the account is anonymised.
+func DeletionGrace(config *config.AppConfig) time.Duration {
+	if config.DeletionGrace > 0 {
+		return config.DeletionGrace
+	}
+	return 7 * 24 * time.Hour
 }
 
 func init() {
-	allowLogin.Store(true)
-	allowSignUp.Store(true)
+	for name, enabled := range flagDefaults {
+		flag := &atomic.Bool{}
+		flag.Store(enabled)
+		flags[name] = flag
+	}
 }
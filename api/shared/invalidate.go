@@ -0,0 +1,109 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/intraware/rodan-authify/internal/cache"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const invalidateChannel = "rodan-authify:invalidate"
+
+const (
+	cacheAdminKey = "admin-key"
+	cacheUser     = "user"
+	cacheLogin    = "login"
+	cacheTeam     = "team"
+	cacheTOTP     = "totp"
+)
+
+type invalidation struct {
+	Cache string `json:"cache"`
+	Key   string `json:"key"`
+}
+
+// ForgetAdminKey drops the cached key with the prefix here and, through
+// Redis, on every other instance, in-process layers included.
+func ForgetAdminKey(ctx context.Context, prefix string) {
+	AdminKeyCache.Delete(ctx, prefix)
+	broadcastInvalidation(cacheAdminKey, prefix)
+}
+
+// ForgetUser drops the cached user everywhere, like ForgetAdminKey.
+func ForgetUser(ctx context.Context, userID uint) {
+	UserCache.Delete(ctx, userID)
+	broadcastInvalidation(cacheUser, strconv.FormatUint(uint64(userID), 10))
+}
+
+// ForgetLogin drops the cached login lookup for the username everywhere.
+func ForgetLogin(ctx context.Context, username string) {
+	LoginCache.Delete(ctx, username)
+	broadcastInvalidation(cacheLogin, username)
+}
+
+// ForgetTeam drops the cached team everywhere.
+func ForgetTeam(ctx context.Context, teamID uint) {
+	TeamCache.Delete(ctx, teamID)
+	broadcastInvalidation(cacheTeam, strconv.FormatUint(uint64(teamID), 10))
+}
+
+// ForgetTOTP drops the cached TOTP secret of the username everywhere.
+func ForgetTOTP(ctx context.Context, username string) {
+	TOTPCache.Delete(ctx, username)
+	broadcastInvalidation(cacheTOTP, username)
+}
+
+func broadcastInvalidation(name, key string) {
+	payload, _ := json.Marshal(invalidation{Cache: name, Key: key})
+	if err := cache.Publish(invalidateChannel, payload); err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"cache": name,
+			"key":   key,
+			"error": err.Error(),
+		}).Warn("Failed to publish cache invalidation; other instances keep the entry until it expires")
+	}
+}
+
+func applyInvalidation(ctx context.Context, change invalidation) {
+	switch change.Cache {
+	case cacheAdminKey:
+		AdminKeyCache.Delete(ctx, change.Key)
+	case cacheLogin:
+		LoginCache.Delete(ctx, change.Key)
+	case cacheTOTP:
+		TOTPCache.Delete(ctx, change.Key)
+	case cacheUser, cacheTeam:
+		id, err := strconv.ParseUint(change.Key, 10, 64)
+		if err != nil {
+			return
+		}
+		if change.Cache == cacheUser {
+			UserCache.Delete(ctx, uint(id))
+		} else {
+			TeamCache.Delete(ctx, uint(id))
+		}
+	}
+}
+
+// WatchInvalidations drops the cache entries other instances invalidated
+// until the context is cancelled. Without Redis there is nothing to watch.
+func WatchInvalidations(ctx context.Context) {
+	messages := cache.Subscribe(ctx, invalidateChannel)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var change invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &change); err == nil {
+				applyInvalidation(ctx, change)
+			}
+		}
+	}
+}
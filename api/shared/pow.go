@@ -0,0 +1,14 @@
+package shared
+
+import (
+	"context"
+
+	"github.com/intraware/rodan-authify/internal/config"
+)
+
+// NextPoWDifficulty records a challenge request from the IP and returns the
+// difficulty it should get, growing with the IP's volume inside the window.
+func NextPoWDifficulty(ctx context.Context, ip string, cfg *config.PoWConfig) int {
+	count := PoWRateCache.Incr(ctx, ip)
+	return min(cfg.BaseDifficulty+(count-1)/cfg.ScaleEvery, cfg.MaxDifficulty)
+}
@@ -0,0 +1,54 @@
+package shared
+
+import (
+	"errors"
+
+	"github.com/intraware/rodan-authify/internal/models"
+)
+
+// ProfileUpdate is the profile part of a user or team edit; fields left out
+// are kept and an empty string clears one.
+type ProfileUpdate struct {
+	Country     *string `json:"country" example:"IN"`
+	Affiliation *string `json:"affiliation" example:"IIT Madras"`
+	Website     *string `json:"website" example:"https://intraware.org"`
+	Bio         *string `json:"bio" example:"We break things for fun"`
+}
+
+// Changed reports whether any profile field was sent.
+func (u ProfileUpdate) Changed() bool {
+	return u.Country != nil || u.Affiliation != nil || u.Website != nil || u.Bio != nil
+}
+
+// Apply writes the sent fields into profile, normalized, and validates the
+// result; profile is left untouched when it fails.
+func (u ProfileUpdate) Apply(profile *models.Profile) error {
+	next := *profile
+	if u.Country != nil {
+		next.Country = *u.Country
+	}
+	if u.Affiliation != nil {
+		next.Affiliation = *u.Affiliation
+	}
+	if u.Website != nil {
+		next.Website = *u.Website
+	}
+	if u.Bio != nil {
+		next.Bio = *u.Bio
+	}
+	next.Normalize()
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	*profile = next
+	return nil
+}
+
+// ProfileErrorMessage is the client-facing message for an error of Apply.
+func ProfileErrorMessage(err error) string {
+	var profileErr *models.ProfileError
+	if errors.As(err, &profileErr) {
+		return "Invalid " + profileErr.Field
+	}
+	return "Invalid profile"
+}
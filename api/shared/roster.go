@@ -0,0 +1,69 @@
+package shared
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var ErrRosterFrozen = errors.New("team rosters are frozen")
+
+// RosterOpen lets a roster change by the user through while rosters are not
+// frozen, or while an admin exception covers it. teamID is the team whose
+// roster changes, or nil when a team is being created. A blocked change gets
+// the error response and an audit entry; the caller just returns.
+func RosterOpen(ctx *gin.Context, event string, userID uint, teamID *uint) bool {
+	if !RosterFrozen() {
+		return true
+	}
+	auditLog := utils.AuditLog(ctx)
+	exception, err := models.FindRosterException(models.DB.WithContext(ctx), userID, teamID)
+	if err == nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":        event,
+			"status":       "allowed",
+			"reason":       "roster_exception",
+			"user_id":      userID,
+			"team_id":      teamID,
+			"exception_id": exception.ID,
+			"ip":           ctx.ClientIP(),
+		}).Info("Roster change allowed by an admin exception")
+		return true
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
+		return false
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":   event,
+		"status":  "failure",
+		"reason":  "roster_frozen",
+		"user_id": userID,
+		"team_id": teamID,
+		"ip":      ctx.ClientIP(),
+	}).Warn("Roster change blocked by the roster freeze")
+	ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Team rosters are frozen; ask an admin for an exception"})
+	return false
+}
+
+// CheckInviteRoster refuses an invite code that would put a new user in a
+// team while rosters are frozen.
+func CheckInviteRoster(tx *gorm.DB, code string) error {
+	if !RosterFrozen() {
+		return nil
+	}
+	var invite models.Invite
+	if err := tx.Where("code = ?", code).Take(&invite).Error; err != nil {
+		return nil // redeeming reports unknown codes
+	}
+	if invite.TeamID != nil {
+		return ErrRosterFrozen
+	}
+	return nil
+}
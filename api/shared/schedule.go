@@ -0,0 +1,285 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/intraware/rodan-authify/internal/cache"
+	"github.com/intraware/rodan-authify/internal/config"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	PhaseUpcoming     = "upcoming"
+	PhaseRegistration = "registration"
+	PhaseRunning      = "running"
+	PhaseFrozen       = "frozen"
+	PhaseEnded        = "ended"
+)
+
+type EventSchedule struct {
+	SignupOpen   *time.Time `json:"signup_open" example:"2026-11-01T00:00:00Z"`
+	SignupClose  *time.Time `json:"signup_close" example:"2026-11-08T00:00:00Z"`
+	LoginOpen    *time.Time `json:"login_open" example:"2026-11-07T00:00:00Z"`
+	LoginClose   *time.Time `json:"login_close" example:"2026-11-10T00:00:00Z"`
+	RosterFreeze *time.Time `json:"roster_freeze" example:"2026-11-07T00:00:00Z"`
+	End          *time.Time `json:"end" example:"2026-11-09T00:00:00Z"`
+}
+
+type scheduleTransition struct {
+	at    time.Time
+	name  string
+	apply func() error
+}
+
+const scheduleChannel = "rodan-authify:schedule"
+
+var scheduleOverride atomic.Pointer[EventSchedule]
+var scheduleVersion atomic.Int64 // UnixNano of the override's updated_at
+var scheduleChanged atomic.Bool
+
+type scheduleMessage struct {
+	Schedule  EventSchedule `json:"schedule"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func ScheduleFromConfig(cfg *config.EventConfig) EventSchedule {
+	return EventSchedule{
+		SignupOpen:   optionalTime(cfg.SignupOpen),
+		SignupClose:  optionalTime(cfg.SignupClose),
+		LoginOpen:    optionalTime(cfg.LoginOpen),
+		LoginClose:   optionalTime(cfg.LoginClose),
+		RosterFreeze: optionalTime(cfg.RosterFreeze),
+		End:          optionalTime(cfg.End),
+	}
+}
+
+// Schedule returns the schedule set through the admin API, falling back to
+// the one in the config.
+func Schedule() EventSchedule {
+	if s := scheduleOverride.Load(); s != nil {
+		return *s
+	}
+	return ScheduleFromConfig(&values.GetConfig().App.Event)
+}
+
+// SetSchedule persists the schedule and propagates it to the other
+// instances. The scheduler re-applies it from scratch on its next tick.
+func SetSchedule(s EventSchedule) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	// millisecond precision survives every database, so versions compare equal
+	// when the row is read back
+	row := models.EventSchedule{
+		ID:           1,
+		SignupOpen:   s.SignupOpen,
+		SignupClose:  s.SignupClose,
+		LoginOpen:    s.LoginOpen,
+		LoginClose:   s.LoginClose,
+		RosterFreeze: s.RosterFreeze,
+		End:          s.End,
+		UpdatedAt:    time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if err := models.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error; err != nil {
+		return fmt.Errorf("failed to persist schedule: %w", err)
+	}
+	storeSchedule(s, row.UpdatedAt)
+	payload, _ := json.Marshal(scheduleMessage{Schedule: s, UpdatedAt: row.UpdatedAt})
+	if err := cache.Publish(scheduleChannel, payload); err != nil {
+		utils.Logger.WithField("error", err.Error()).
+			Warn("Failed to publish schedule change; other instances pick it up on the next sync")
+	}
+	return nil
+}
+
+// storeSchedule makes s the active schedule unless it is the one already in
+// place.
+func storeSchedule(s EventSchedule, updatedAt time.Time) {
+	if scheduleVersion.Swap(updatedAt.UnixNano()) == updatedAt.UnixNano() {
+		return
+	}
+	scheduleOverride.Store(&s)
+	scheduleChanged.Store(true)
+}
+
+// LoadSchedule picks up the schedule persisted through the admin API.
+func LoadSchedule() error {
+	var rows []models.EventSchedule
+	if err := models.DB.Where("id = ?", 1).Limit(1).Find(&rows).Error; err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	row := rows[0]
+	storeSchedule(EventSchedule{
+		SignupOpen:   row.SignupOpen,
+		SignupClose:  row.SignupClose,
+		LoginOpen:    row.LoginOpen,
+		LoginClose:   row.LoginClose,
+		RosterFreeze: row.RosterFreeze,
+		End:          row.End,
+	}, row.UpdatedAt)
+	return nil
+}
+
+// WatchSchedule applies schedule changes made on other instances, the same
+// way WatchFlags does for flags.
+func WatchSchedule(ctx context.Context, syncInterval time.Duration) {
+	messages := cache.Subscribe(ctx, scheduleChannel)
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				messages = nil
+				continue
+			}
+			var change scheduleMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &change); err != nil {
+				continue
+			}
+			storeSchedule(change.Schedule, change.UpdatedAt)
+		case <-ticker.C:
+			if err := LoadSchedule(); err != nil {
+				utils.Logger.WithField("error", err.Error()).Warn("Failed to sync the event schedule")
+			}
+		}
+	}
+}
+
+func (s EventSchedule) Validate() error {
+	for _, pair := range [][2]*time.Time{
+		{s.SignupOpen, s.SignupClose},
+		{s.LoginOpen, s.LoginClose},
+		{s.LoginOpen, s.End},
+	} {
+		if pair[0] != nil && pair[1] != nil && !pair[0].Before(*pair[1]) {
+			return fmt.Errorf("schedule is out of order: %s is not before %s", pair[0], pair[1])
+		}
+	}
+	return nil
+}
+
+func reached(t *time.Time, now time.Time) bool {
+	return t != nil && !now.Before(*t)
+}
+
+// Phase describes where the event is at the given time. Without a schedule it
+// is derived from the current toggles.
+func (s EventSchedule) Phase(now time.Time) string {
+	switch {
+	case reached(s.End, now):
+		return PhaseEnded
+	case RosterFrozen():
+		return PhaseFrozen
+	case AllowLogin() && (s.LoginOpen == nil || reached(s.LoginOpen, now)):
+		return PhaseRunning
+	case AllowSignup():
+		return PhaseRegistration
+	default:
+		return PhaseUpcoming
+	}
+}
+
+func (s EventSchedule) transitions() []scheduleTransition {
+	var list []scheduleTransition
+	add := func(t *time.Time, name string, apply func() error) {
+		if t != nil {
+			list = append(list, scheduleTransition{at: *t, name: name, apply: apply})
+		}
+	}
+	add(s.SignupOpen, "signup_open", func() error { return SetSignup(true) })
+	add(s.SignupClose, "signup_close", func() error { return SetSignup(false) })
+	add(s.LoginOpen, "login_open", func() error { return SetLogin(true) })
+	add(s.LoginClose, "login_close", func() error { return SetLogin(false) })
+	add(s.RosterFreeze, "roster_freeze", func() error { return SetRosterFrozen(true) })
+	add(s.End, "event_end", func() error {
+		if err := SetSignup(false); err != nil {
+			return err
+		}
+		return SetRosterFrozen(true)
+	})
+	sort.SliceStable(list, func(i, j int) bool { return list[i].at.Before(list[j].at) })
+	return list
+}
+
+// applySchedule fires every transition in (last, now]. With a zero last it
+// reconciles the toggles with the whole schedule, closing anything whose
+// opening time is still ahead.
+func applySchedule(s EventSchedule, last, now time.Time) {
+	if last.IsZero() {
+		if s.SignupOpen != nil && now.Before(*s.SignupOpen) && AllowSignup() {
+			logTransition("signup_pending", *s.SignupOpen, SetSignup(false))
+		}
+		if s.LoginOpen != nil && now.Before(*s.LoginOpen) && AllowLogin() {
+			logTransition("login_pending", *s.LoginOpen, SetLogin(false))
+		}
+	}
+	for _, t := range s.transitions() {
+		if t.at.After(last) && !t.at.After(now) {
+			logTransition(t.name, t.at, t.apply())
+		}
+	}
+}
+
+func logTransition(name string, at time.Time, err error) {
+	log := utils.Logger.WithFields(logrus.Fields{
+		"type":       "audit",
+		"event":      "schedule_transition",
+		"status":     "success",
+		"transition": name,
+		"at":         at,
+	})
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"status": "failure",
+			"reason": "flag_update_failed",
+			"error":  err.Error(),
+		}).Error("Failed to apply scheduled transition")
+		return
+	}
+	log.Info("Applied scheduled transition")
+}
+
+// RunScheduler drives the login, signup and roster toggles from the schedule
+// until the context is cancelled. Toggles flipped by hand stay as they are
+// until the next transition; on start only transitions newer than the last
+// persisted flag change are replayed.
+func RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := LastFlagChange()
+	for {
+		now := time.Now()
+		if scheduleChanged.Swap(false) {
+			last = time.Time{}
+		}
+		applySchedule(Schedule(), last, now)
+		last = now
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
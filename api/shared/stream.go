@@ -0,0 +1,45 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/lifecycle"
+)
+
+const streamHeartbeat = 25 * time.Second
+
+// StreamEvents writes events from the channel to the client as server-sent
+// events until it disconnects or the server begins shutting down. A comment line every few seconds keeps proxies
+// from closing an idle stream.
+func StreamEvents(ctx *gin.Context, ch <-chan events.Event) {
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-lifecycle.ShuttingDown():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(ctx.Writer, ": ping\n\n")
+		case e := <-ch:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(ctx.Writer, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+		}
+		ctx.Writer.Flush()
+	}
+}
@@ -0,0 +1,59 @@
+package shared
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// StreamTicketTTL is how long a stream ticket can be redeemed.
+const StreamTicketTTL = 30 * time.Second
+
+const (
+	StreamScopeTeam  = "team"
+	StreamScopeAdmin = "admin"
+)
+
+// StreamTicket lets a client that cannot set headers, such as a browser
+// EventSource, open one event stream without putting its session token in
+// the URL. Tickets are single-use and only open the stream of their scope.
+type StreamTicket struct {
+	Scope    string `json:"scope"`
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Actor    string `json:"actor"` // admin streams: who the audit log names
+}
+
+// IssueStreamTicket stores the ticket and returns the value the client
+// passes as ?ticket=.
+func IssueStreamTicket(ctx context.Context, ticket StreamTicket) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(random)
+	StreamTicketCache.Set(ctx, hashStreamTicket(token), ticket)
+	return token, nil
+}
+
+// RedeemStreamTicket claims the ticket if it exists and was issued for the
+// scope. A ticket is gone after its first redemption, whatever the scope.
+func RedeemStreamTicket(ctx context.Context, token, scope string) (StreamTicket, bool) {
+	ticket, ok := StreamTicketCache.Take(ctx, hashStreamTicket(token))
+	if !ok || ticket.Scope != scope {
+		return StreamTicket{}, false
+	}
+	return ticket, true
+}
+
+func hashStreamTicket(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type StreamTicketResponse struct {
+	Ticket    string `json:"ticket" example:"5d41402abc4b2a76b9719d911017c592..."`
+	ExpiresIn int    `json:"expires_in" example:"30"` // seconds
+}
@@ -1,24 +1,186 @@
 package shared
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
 	"sync/atomic"
+	"time"
+
+	"github.com/intraware/rodan-authify/internal/cache"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	FlagLogin         = "login"
+	FlagSignup        = "signup"
+	FlagTeamCreate    = "team_create"
+	FlagTeamJoin      = "team_join"
+	FlagTeamLeave     = "team_leave"
+	FlagProfileEdit   = "profile_edit"
+	FlagAccountDelete = "account_delete"
+	FlagRosterFrozen  = "roster_frozen"
 )
 
-var allowLogin atomic.Bool
-var allowSignUp atomic.Bool
+const flagsChannel = "rodan-authify:flags"
+
+// flagDefaults lists every known flag with the value it has until an admin
+// changes it.
+var flagDefaults = map[string]bool{
+	FlagLogin:         true,
+	FlagSignup:        true,
+	FlagTeamCreate:    true,
+	FlagTeamJoin:      true,
+	FlagTeamLeave:     true,
+	FlagProfileEdit:   true,
+	FlagAccountDelete: true,
+	FlagRosterFrozen:  false,
+}
+
+// flags is the local view of the feature_flags table. The set of keys never
+// changes after init, so the map itself needs no locking.
+var flags = map[string]*atomic.Bool{}
+
+type flagMessage struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// FlagExists reports whether the name is a known flag.
+func FlagExists(name string) bool {
+	_, ok := flags[name]
+	return ok
+}
+
+// Flag returns the current value of a flag; unknown flags are off.
+func Flag(name string) bool {
+	if f, ok := flags[name]; ok {
+		return f.Load()
+	}
+	return false
+}
+
+// Flags returns a snapshot of every flag.
+func Flags() map[string]bool {
+	snapshot := make(map[string]bool, len(flags))
+	for name, f := range flags {
+		snapshot[name] = f.Load()
+	}
+	return snapshot
+}
+
+// FlagNames returns the known flags in a stable order.
+func FlagNames() []string {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetFlag persists a flag and propagates it to the other instances.
+func SetFlag(name string, enabled bool) error {
+	f, ok := flags[name]
+	if !ok {
+		return fmt.Errorf("unknown flag %q", name)
+	}
+	if err := models.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&models.FeatureFlag{Name: name, Enabled: enabled}).Error; err != nil {
+		return fmt.Errorf("failed to persist flag %s: %w", name, err)
+	}
+	f.Store(enabled)
+	payload, _ := json.Marshal(flagMessage{Name: name, Enabled: enabled})
+	if err := cache.Publish(flagsChannel, payload); err != nil {
+		utils.Logger.WithFields(logrus.Fields{
+			"flag":  name,
+			"error": err.Error(),
+		}).Warn("Failed to publish flag change; other instances pick it up on the next sync")
+	}
+	return nil
+}
+
+// LoadFlags refreshes the local flags from the database.
+func LoadFlags() error {
+	var stored []models.FeatureFlag
+	if err := models.DB.Find(&stored).Error; err != nil {
+		return err
+	}
+	for _, flag := range stored {
+		if f, ok := flags[flag.Name]; ok {
+			f.Store(flag.Enabled)
+		}
+	}
+	return nil
+}
+
+// LastFlagChange is when any flag was last written, or the zero time if none
+// has been persisted yet.
+func LastFlagChange() time.Time {
+	var flag models.FeatureFlag
+	if err := models.DB.Order("updated_at DESC").Limit(1).Find(&flag).Error; err != nil {
+		return time.Time{}
+	}
+	return flag.UpdatedAt
+}
+
+// WatchFlags applies flag changes made on other instances: immediately
+// through Redis when it is configured, and by re-reading the table on every
+// sync interval regardless.
+func WatchFlags(ctx context.Context, syncInterval time.Duration) {
+	messages := cache.Subscribe(ctx, flagsChannel)
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				messages = nil
+				continue
+			}
+			var change flagMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &change); err != nil {
+				continue
+			}
+			if f, ok := flags[change.Name]; ok {
+				f.Store(change.Enabled)
+			}
+		case <-ticker.C:
+			if err := LoadFlags(); err != nil {
+				utils.Logger.WithField("error", err.Error()).Warn("Failed to sync feature flags")
+			}
+		}
+	}
+}
 
-func SetLogin(login bool) {
-	allowLogin.Store(login)
+func SetLogin(login bool) error {
+	return SetFlag(FlagLogin, login)
 }
 
 func AllowLogin() bool {
-	return allowLogin.Load()
+	return Flag(FlagLogin)
 }
 
-func SetSignup(signup bool) {
-	allowSignUp.Store(signup)
+func SetSignup(signup bool) error {
+	return SetFlag(FlagSignup, signup)
 }
 
 func AllowSignup() bool {
-	return allowSignUp.Load()
+	return Flag(FlagSignup)
+}
+
+func SetRosterFrozen(frozen bool) error {
+	return SetFlag(FlagRosterFrozen, frozen)
+}
+
+func RosterFrozen() bool {
+	return Flag(FlagRosterFrozen)
 }
@@ -0,0 +1,143 @@
+package team
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/avatar"
+	"github.com/sirupsen/logrus"
+)
+
+// uploadTeamAvatar replaces the team's avatar with an uploaded image, for
+// members whose role may rename the team.
+func uploadTeamAvatar(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	actor, team, ok := loadManagedTeam(ctx, "upload_team_avatar", models.TeamPermRename)
+	if !ok {
+		return
+	}
+	if !loadTeamAvatarKey(ctx, &team) {
+		return
+	}
+	files, ok := shared.ReceiveAvatar(ctx)
+	if !ok {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "upload_team_avatar",
+			"status":  "failure",
+			"reason":  "invalid_upload",
+			"user_id": actor.ID,
+			"team_id": team.ID,
+			"ip":      ctx.ClientIP(),
+		}).Warn("Rejected team avatar upload")
+		return
+	}
+	key, err := avatar.Save(ctx.Request.Context(), shared.AvatarStore, fmt.Sprintf("teams/%d", team.ID), files)
+	if err == nil {
+		err = models.DB.WithContext(ctx).Model(&models.Team{}).Where("id = ?", team.ID).Update("avatar_key", key).Error
+	}
+	if err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "upload_team_avatar",
+			"status":  "failure",
+			"reason":  "store_failed",
+			"user_id": actor.ID,
+			"team_id": team.ID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Error("Failed to store team avatar")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to store avatar"})
+		return
+	}
+	removeOldTeamAvatar(ctx, team, key)
+	shared.TeamCache.Delete(ctx, team.ID)
+	auditLog.WithFields(logrus.Fields{
+		"event":      "upload_team_avatar",
+		"status":     "success",
+		"user_id":    actor.ID,
+		"team_id":    team.ID,
+		"avatar_key": key,
+		"ip":         ctx.ClientIP(),
+	}).Info("Team avatar uploaded")
+	team.AvatarKey = key
+	ctx.JSON(http.StatusOK, avatarResponse{AvatarURL: shared.TeamAvatarURL(&team)})
+}
+
+// deleteTeamAvatar drops the uploaded avatar, leaving the identicon.
+func deleteTeamAvatar(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	actor, team, ok := loadManagedTeam(ctx, "delete_team_avatar", models.TeamPermRename)
+	if !ok {
+		return
+	}
+	if !loadTeamAvatarKey(ctx, &team) {
+		return
+	}
+	if err := models.DB.WithContext(ctx).Model(&models.Team{}).Where("id = ?", team.ID).Update("avatar_key", "").Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to remove avatar"})
+		return
+	}
+	removeOldTeamAvatar(ctx, team, "")
+	shared.TeamCache.Delete(ctx, team.ID)
+	auditLog.WithFields(logrus.Fields{
+		"event":   "delete_team_avatar",
+		"status":  "success",
+		"user_id": actor.ID,
+		"team_id": team.ID,
+		"ip":      ctx.ClientIP(),
+	}).Info("Team avatar removed")
+	team.AvatarKey = ""
+	ctx.JSON(http.StatusOK, avatarResponse{AvatarURL: shared.TeamAvatarURL(&team)})
+}
+
+// loadTeamAvatarKey reads the team's avatar key from the database: a cached
+// team may predate the last upload, and its key decides which files go.
+func loadTeamAvatarKey(ctx *gin.Context, team *models.Team) bool {
+	if err := models.DB.WithContext(ctx).Model(&models.Team{}).Where("id = ?", team.ID).
+		Select("avatar_key").Scan(&team.AvatarKey).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
+		return false
+	}
+	return true
+}
+
+// removeOldTeamAvatar deletes the files of the avatar the team had before,
+// unless an identical upload kept the same key. Leftover files are only
+// logged: the team no longer points at them.
+func removeOldTeamAvatar(ctx *gin.Context, team models.Team, newKey string) {
+	if team.AvatarKey == "" || team.AvatarKey == newKey {
+		return
+	}
+	if err := avatar.Remove(ctx.Request.Context(), shared.AvatarStore, team.AvatarKey); err != nil {
+		utils.AuditLog(ctx).WithFields(logrus.Fields{
+			"event":      "remove_team_avatar_files",
+			"status":     "failure",
+			"team_id":    team.ID,
+			"avatar_key": team.AvatarKey,
+			"error":      err.Error(),
+		}).Warn("Failed to delete old team avatar files")
+	}
+}
+
+// getTeamAvatar serves the team's avatar at ?size=, drawing an identicon for
+// teams without an upload.
+func getTeamAvatar(ctx *gin.Context) {
+	teamID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid team ID"})
+		return
+	}
+	team, ok := shared.TeamCache.Get(ctx, uint(teamID))
+	if !ok {
+		if err := models.DB.WithContext(ctx).First(&team, teamID).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Team not found"})
+			return
+		}
+	}
+	shared.ServeAvatar(ctx, team.AvatarKey, "", fmt.Sprintf("team:%d", team.ID))
+}
@@ -0,0 +1,93 @@
+package team
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+)
+
+var teamSortKeys = map[string]string{
+	"name":       "name",
+	"created_at": "created_at",
+}
+
+// listTeams is the public team directory. Banned teams are left out except
+// for staff.
+func listTeams(ctx *gin.Context) {
+	order, ok := shared.SortOrder(ctx, teamSortKeys, "name")
+	if !ok {
+		return
+	}
+	admin := models.AdminViewer(shared.Viewer(ctx))
+	query := shared.Search(models.DB.WithContext(ctx).Model(&models.Team{}), "name", ctx.Query("q"))
+	if !admin {
+		query = query.Where("ban = ?", false)
+	}
+	if divisionID := ctx.Query("division_id"); divisionID != "" {
+		query = query.Where("division_id = ?", divisionID)
+	}
+	page := shared.ParsePagination(ctx)
+	query, err := page.Apply(query)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch teams"})
+		return
+	}
+	var teams []models.Team
+	if err := query.Order(order).Find(&teams).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch teams"})
+		return
+	}
+	ids := make([]uint, len(teams))
+	for i, team := range teams {
+		ids[i] = team.ID
+	}
+	var counts []struct {
+		TeamID uint
+		Count  int
+	}
+	if len(ids) > 0 {
+		if err := models.DB.WithContext(ctx).Model(&models.User{}).Select("team_id, COUNT(*) AS count").
+			Where("team_id IN ?", ids).Group("team_id").Scan(&counts).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to count team members"})
+			return
+		}
+	}
+	members := make(map[uint]int, len(counts))
+	for _, c := range counts {
+		members[c.TeamID] = c.Count
+	}
+	var divisions []models.Division
+	if err := models.DB.WithContext(ctx).Find(&divisions).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch divisions"})
+		return
+	}
+	divisionNames := make(map[uint]string, len(divisions))
+	for _, d := range divisions {
+		divisionNames[d.ID] = d.Name
+	}
+	resp := teamListResponse{Teams: make([]teamSummary, len(teams)), Pagination: page}
+	for i, team := range teams {
+		summary := teamSummary{
+			ID:          team.ID,
+			Name:        team.Name,
+			AvatarURL:   shared.TeamAvatarURL(&team),
+			DivisionID:  team.DivisionID,
+			Country:     team.Country,
+			Affiliation: team.Affiliation,
+			Members:     members[team.ID],
+			JoinPolicy:  team.JoinPolicy,
+			Locked:      team.Locked,
+		}
+		if team.DivisionID != nil {
+			summary.Division = divisionNames[*team.DivisionID]
+		}
+		if admin {
+			summary.Ban = team.Ban
+		}
+		resp.Teams[i] = summary
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
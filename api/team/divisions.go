@@ -0,0 +1,77 @@
+package team
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func listDivisions(ctx *gin.Context) {
+	var divisions []models.Division
+	if err := models.DB.WithContext(ctx).Order("id").Find(&divisions).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch divisions"})
+		return
+	}
+	resp := make([]divisionResponse, len(divisions))
+	for i, division := range divisions {
+		resp[i] = divisionResponse{
+			ID:             division.ID,
+			Name:           division.Name,
+			Description:    division.Description,
+			TeamSize:       division.MaxTeamSize(),
+			OAuthProviders: division.OAuthProviders,
+		}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// pickDivision resolves the division a new team goes into and checks that
+// its founder meets the division's rules. A division has to be picked once
+// any exist. It writes the error response itself and reports whether the
+// handler may continue; the returned division is empty when there are none.
+func pickDivision(ctx *gin.Context, divisionID *uint, user *models.User) (division models.Division, ok bool) {
+	auditLog := utils.AuditLog(ctx)
+	if divisionID == nil {
+		var count int64
+		if err := models.DB.WithContext(ctx).Model(&models.Division{}).Count(&count).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
+			return division, false
+		}
+		if count > 0 {
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Pick a division for the team"})
+			return division, false
+		}
+		return division, true
+	}
+	if err := models.DB.WithContext(ctx).First(&division, *divisionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Division not found"})
+			return division, false
+		}
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
+		return division, false
+	}
+	if err := division.Admits(models.DB.WithContext(ctx), user); err != nil {
+		if errors.Is(err, models.ErrDivisionIneligible) {
+			auditLog.WithFields(logrus.Fields{
+				"event":       "create_team",
+				"status":      "failure",
+				"reason":      "division_ineligible",
+				"user_id":     user.ID,
+				"division_id": division.ID,
+				"ip":          ctx.ClientIP(),
+			}).Warn("User does not meet the division rules")
+			ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "You do not meet the rules of this division"})
+			return division, false
+		}
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to check division rules"})
+		return division, false
+	}
+	return division, true
+}
@@ -1,21 +1,22 @@
 package team
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/events"
 	"github.com/intraware/rodan-authify/internal/models"
 	"github.com/intraware/rodan-authify/internal/types"
 	"github.com/intraware/rodan-authify/internal/utils"
-	"github.com/intraware/rodan-authify/internal/utils/values"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 func createTeam(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	var req createTeamRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		auditLog.WithFields(logrus.Fields{
@@ -30,8 +31,8 @@ func createTeam(ctx *gin.Context) {
 	userID := ctx.GetUint("user_id")
 	var user models.User
 	cacheHit := false
-	if user, cacheHit = shared.UserCache.Get(userID); !cacheHit {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	if user, cacheHit = shared.UserCache.Get(ctx, userID); !cacheHit {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "create_team",
 				"status":  "failure",
@@ -56,11 +57,25 @@ func createTeam(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "User is already in a team"})
 		return
 	}
-	err := models.DB.Transaction(func(tx *gorm.DB) error {
-		team := models.Team{
-			Name:     req.Name,
-			LeaderID: userID,
-		}
+	if !shared.RosterOpen(ctx, "create_team", user.ID, nil) {
+		return
+	}
+	division, ok := pickDivision(ctx, req.DivisionID, &user)
+	if !ok {
+		return
+	}
+	team := models.Team{
+		Name:       req.Name,
+		LeaderID:   userID,
+		JoinPolicy: req.JoinPolicy,
+	}
+	if division.ID != 0 {
+		team.DivisionID = &division.ID
+	}
+	if team.JoinPolicy == "" {
+		team.JoinPolicy = models.JoinPolicyCode
+	}
+	err := models.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&team).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":     "create_team",
@@ -87,28 +102,33 @@ func createTeam(ctx *gin.Context) {
 			}).Error("Failed to associate user with team after creation")
 			return err
 		}
-		shared.UserCache.Delete(user.ID)
-		shared.TeamCache.Set(team.ID, team)
-		auditLog.WithFields(logrus.Fields{
-			"event":     "create_team",
-			"status":    "success",
-			"cache_hit": cacheHit,
-			"user_id":   user.ID,
-			"username":  user.Username,
-			"team_id":   team.ID,
-			"team_name": team.Name,
-			"ip":        ctx.ClientIP(),
-		}).Info("Team created successfully")
-		ctx.JSON(http.StatusCreated, buildTeamResponse(team))
 		return nil
 	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create team"})
+		return
 	}
+	if division.ID != 0 {
+		team.Division = &division
+	}
+	shared.UserCache.Delete(ctx, user.ID)
+	shared.TeamCache.Set(ctx, team.ID, team)
+	auditLog.WithFields(logrus.Fields{
+		"event":     "create_team",
+		"status":    "success",
+		"cache_hit": cacheHit,
+		"user_id":   user.ID,
+		"username":  user.Username,
+		"team_id":   team.ID,
+		"team_name": team.Name,
+		"ip":        ctx.ClientIP(),
+	}).Info("Team created successfully")
+	events.Publish(events.TeamCreated, user.ID, team.ID, map[string]any{"name": team.Name, "leader": user.Username})
+	ctx.JSON(http.StatusCreated, buildTeamResponse(team, models.ViewLeader))
 }
 
 func joinTeam(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	idStr := ctx.Param("id")
 	teamIDInt, err := strconv.Atoi(idStr)
 	if err != nil {
@@ -136,8 +156,9 @@ func joinTeam(ctx *gin.Context) {
 	}
 	userID := ctx.GetUint("user_id")
 	var user models.User
-	if user, cacheHit := shared.UserCache.Get(userID); !cacheHit {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	cacheHit := false
+	if user, cacheHit = shared.UserCache.Get(ctx, userID); !cacheHit {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "join_team",
 				"status":  "failure",
@@ -148,23 +169,23 @@ func joinTeam(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
 			return
 		}
-		shared.UserCache.Set(userID, user)
+		shared.UserCache.Set(ctx, userID, user)
 	}
 	if user.TeamID != nil {
 		auditLog.WithFields(logrus.Fields{
-			"event":    "join_team",
-			"status":   "failure",
-			"reason":   "already_in_team",
-			"user_id":  user.ID,
-			"username": user.Username,
-			"ip":       ctx.ClientIP(),
+			"event":     "join_team",
+			"status":    "failure",
+			"reason":    "already_in_team",
+			"cache_hit": cacheHit,
+			"user_id":   user.ID,
+			"username":  user.Username,
+			"ip":        ctx.ClientIP(),
 		}).Warn("User is already in a team")
 		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "User is already in a team"})
 		return
 	}
 	var team models.Team
-	team, _ = shared.TeamCache.Get(teamID)
-	if err := models.DB.Where("id = ?", teamID).First(&team).Error; err != nil {
+	if err := models.DB.WithContext(ctx).Where("id = ?", teamID).First(&team).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "join_team",
@@ -181,71 +202,78 @@ func joinTeam(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
 		return
 	}
-	shared.TeamCache.Set(team.ID, team)
-	if team.Code != req.Code {
+	if !shared.RosterOpen(ctx, "join_team", user.ID, &team.ID) {
+		return
+	}
+	if team.JoinPolicy == models.JoinPolicyClosed {
 		auditLog.WithFields(logrus.Fields{
 			"event":    "join_team",
 			"status":   "failure",
-			"reason":   "wrong_code",
+			"reason":   "team_closed",
 			"user_id":  user.ID,
 			"team_id":  team.ID,
 			"username": user.Username,
 			"ip":       ctx.ClientIP(),
-		}).Warn("Invalid code provided")
-		ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Invalid Code provided"})
+		}).Warn("Attempt to join a closed team")
+		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Team only takes members by invitation"})
 		return
 	}
-	if team.Ban {
+	if team.JoinPolicy != models.JoinPolicyOpen && team.Code != req.Code {
 		auditLog.WithFields(logrus.Fields{
 			"event":    "join_team",
 			"status":   "failure",
-			"reason":   "team_banned",
+			"reason":   "wrong_code",
 			"user_id":  user.ID,
 			"team_id":  team.ID,
 			"username": user.Username,
 			"ip":       ctx.ClientIP(),
-		}).Warn("Attempt to join a banned team")
-		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Team is banned"})
-		return
-	}
-	teamMaxCount := values.GetConfig().App.TeamSize
-	if err := models.DB.Preload("Members").First(&team).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to retrieve team members"})
+		}).Warn("Invalid code provided")
+		ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Invalid Code provided"})
 		return
 	}
-	if len(team.Members) >= teamMaxCount {
+	// AddTeamMember checks the ban, the lock, the division rules and the size
+	// with the team row locked, against the user as stored
+	err = models.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		_, err := models.AddTeamMember(tx, team.ID, &user)
+		return err
+	})
+	if err != nil {
+		status, message, reason := http.StatusInternalServerError, "Failed to join team", "db_error"
+		switch {
+		case errors.Is(err, models.ErrAlreadyInTeam):
+			status, message, reason = http.StatusBadRequest, "User is already in a team", "already_in_team"
+		case errors.Is(err, models.ErrTeamBanned):
+			status, message, reason = http.StatusForbidden, "Team is banned", "team_banned"
+		case errors.Is(err, models.ErrTeamLocked):
+			status, message, reason = http.StatusForbidden, "Team roster is locked", "team_locked"
+		case errors.Is(err, models.ErrTeamFull):
+			status, message, reason = http.StatusConflict, "Team max size reached", "team_full"
+		case errors.Is(err, models.ErrDivisionIneligible):
+			status, message, reason = http.StatusForbidden, "You do not meet the rules of the team's division", "division_ineligible"
+		}
 		auditLog.WithFields(logrus.Fields{
 			"event":    "join_team",
 			"status":   "failure",
-			"reason":   "team_full",
+			"reason":   reason,
 			"user_id":  user.ID,
 			"team_id":  team.ID,
 			"username": user.Username,
 			"ip":       ctx.ClientIP(),
-		}).Warn("Team max size reached")
-		ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "Team max size reached"})
-		return
-	}
-	user.TeamID = &team.ID
-	if err := models.DB.Save(&user).Error; err != nil {
-		auditLog.WithFields(logrus.Fields{
-			"event":   "join_team",
-			"status":  "failure",
-			"reason":  "user_save_failed",
-			"user_id": user.ID,
-			"team_id": team.ID,
-			"ip":      ctx.ClientIP(),
-		}).Error("Failed to join team")
-		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to join team"})
+			"error":    err.Error(),
+		}).Warn("Failed to join team")
+		ctx.JSON(status, types.ErrorResponse{Error: message})
 		return
 	}
-	shared.UserCache.Delete(user.ID)
+	shared.UserCache.Delete(ctx, user.ID)
 	var teamResponse models.Team
-	if err := models.DB.Preload("Members").First(&teamResponse, team.ID).Error; err != nil {
+	if err := models.DB.WithContext(ctx).Preload("Members").Preload("Division").First(&teamResponse, team.ID).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load team"})
 		return
 	}
-	shared.TeamCache.Set(team.ID, teamResponse)
+	shared.TeamCache.Set(ctx, team.ID, teamResponse)
 	auditLog.WithFields(logrus.Fields{
 		"event":     "join_team",
 		"status":    "success",
@@ -255,16 +283,21 @@ func joinTeam(ctx *gin.Context) {
 		"team_name": team.Name,
 		"ip":        ctx.ClientIP(),
 	}).Info("User joined team successfully")
-	ctx.JSON(http.StatusOK, buildTeamResponse(teamResponse))
+	via := "code"
+	if team.JoinPolicy == models.JoinPolicyOpen {
+		via = "open"
+	}
+	events.Publish(events.TeamMemberJoined, user.ID, team.ID, map[string]any{"username": user.Username, "via": via})
+	ctx.JSON(http.StatusOK, buildTeamResponse(teamResponse, models.TeamViewFor(&user, &teamResponse)))
 }
 
 func getMyTeam(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	userID := ctx.GetUint("user_id")
 	var user models.User
 	cacheHit := false
-	if user, cacheHit = shared.UserCache.Get(userID); !cacheHit {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	if user, cacheHit = shared.UserCache.Get(ctx, userID); !cacheHit {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "get_my_team",
 				"status":  "failure",
@@ -275,7 +308,7 @@ func getMyTeam(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
 			return
 		}
-		shared.UserCache.Set(userID, user)
+		shared.UserCache.Set(ctx, userID, user)
 	}
 	if user.TeamID == nil {
 		auditLog.WithFields(logrus.Fields{
@@ -290,8 +323,8 @@ func getMyTeam(ctx *gin.Context) {
 	}
 	var team models.Team
 	teamCacheHit := false
-	if team, teamCacheHit = shared.TeamCache.Get(*user.TeamID); !teamCacheHit {
-		if err := models.DB.Preload("Members").First(&team, *user.TeamID).Error; err != nil {
+	if team, teamCacheHit = shared.TeamCache.Get(ctx, *user.TeamID); !teamCacheHit {
+		if err := models.DB.WithContext(ctx).Preload("Members").Preload("Division").First(&team, *user.TeamID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "get_my_team",
 				"status":  "failure",
@@ -303,7 +336,7 @@ func getMyTeam(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Team not found"})
 			return
 		}
-		shared.TeamCache.Set(*user.TeamID, team)
+		shared.TeamCache.Set(ctx, *user.TeamID, team)
 	}
 	auditLog.WithFields(logrus.Fields{
 		"event":      "get_my_team",
@@ -314,11 +347,11 @@ func getMyTeam(ctx *gin.Context) {
 		"user_cache": cacheHit,
 		"team_cache": teamCacheHit,
 	}).Info("Fetched user's team successfully")
-	ctx.JSON(http.StatusOK, buildTeamResponse(team))
+	ctx.JSON(http.StatusOK, buildTeamResponse(team, models.TeamViewFor(&user, &team)))
 }
 
 func getTeam(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	teamIDStr := ctx.Param("id")
 	teamIDInt, err := strconv.Atoi(teamIDStr)
 	if err != nil {
@@ -335,8 +368,8 @@ func getTeam(ctx *gin.Context) {
 	teamID := uint(teamIDInt)
 	var team models.Team
 	cacheHit := false
-	if team, cacheHit = shared.TeamCache.Get(teamID); !cacheHit {
-		if err := models.DB.Preload("Members").First(&team, teamID).Error; err != nil {
+	if team, cacheHit = shared.TeamCache.Get(ctx, teamID); !cacheHit {
+		if err := models.DB.WithContext(ctx).Preload("Members").Preload("Division").First(&team, teamID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				auditLog.WithFields(logrus.Fields{
 					"event":   "get_team",
@@ -358,35 +391,26 @@ func getTeam(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
 			return
 		}
-		shared.TeamCache.Set(teamID, team)
-	}
-	userID, exists := ctx.Get("user_id")
-	isMember := false
-	if exists {
-		for _, member := range team.Members {
-			if member.ID == userID {
-				isMember = true
-				break
-			}
-		}
-	}
-	response := buildTeamResponse(team)
-	if !isMember {
-		response.Code = ""
+		shared.TeamCache.Set(ctx, teamID, team)
 	}
+	viewer := shared.Viewer(ctx)
+	view := models.TeamViewFor(viewer, &team)
 	auditLog.WithFields(logrus.Fields{
-		"event":     "get_team",
-		"status":    "success",
-		"user_id":   userID,
-		"team_id":   team.ID,
-		"is_member": isMember,
-		"ip":        ctx.ClientIP(),
-		"cache":     cacheHit,
+		"event":   "get_team",
+		"status":  "success",
+		"user_id": ctx.GetUint("user_id"),
+		"team_id": team.ID,
+		"view":    view,
+		"ip":      ctx.ClientIP(),
+		"cache":   cacheHit,
 	}).Info("Fetched team successfully")
-	ctx.JSON(http.StatusOK, response)
+	ctx.JSON(http.StatusOK, buildTeamResponse(team, view))
 }
 
-func buildTeamResponse(team models.Team) teamResponse {
+// buildTeamResponse shows the team as the given view sees it: the join code
+// only to those who may hand it out, and members who hide their team or
+// email not to the public.
+func buildTeamResponse(team models.Team, view string) teamResponse {
 	var teamObj teamResponse
 	var solves []models.Solve
 	if err := models.DB.Where("team_id = ? AND blood_count <= 3", team.ID).Find(&solves).Error; err == nil {
@@ -411,26 +435,49 @@ func buildTeamResponse(team models.Team) teamResponse {
 			teamObj.ThirdBlood = &third
 		}
 	}
-	members := make([]userInfo, len(team.Members))
-	for i, member := range team.Members {
-		members[i] = userInfo{
-			ID:        member.ID,
-			Username:  member.Username,
-			Email:     member.Email,
-			AvatarURL: member.AvatarURL,
-			TeamID:    member.TeamID,
+	members := make([]userInfo, 0, len(team.Members))
+	for _, member := range team.Members {
+		if !member.TeamVisible(view) {
+			continue
+		}
+		info := userInfo{
+			ID:          member.ID,
+			Username:    member.Username,
+			AvatarURL:   shared.UserAvatarURL(&member),
+			TeamID:      member.TeamID,
+			TeamRole:    models.TeamRoleOf(&team, &member),
+			Country:     member.Country,
+			Affiliation: member.Affiliation,
+		}
+		if member.EmailVisible(view) {
+			info.Email = member.Email
 		}
+		members = append(members, info)
 	}
 	teamObj.ID = team.ID
 	teamObj.Name = team.Name
-	teamObj.Code = team.Code
+	teamObj.AvatarURL = shared.TeamAvatarURL(&team)
+	if view == models.ViewLeader || view == models.ViewAdmin {
+		teamObj.Code = team.Code
+	}
+	if view == models.ViewAdmin {
+		teamObj.Ban = team.Ban
+		teamObj.Blacklist = team.Blacklist
+	}
 	teamObj.LeaderID = team.LeaderID
+	teamObj.JoinPolicy = team.JoinPolicy
+	teamObj.Locked = team.Locked
+	teamObj.DivisionID = team.DivisionID
+	teamObj.Profile = team.Profile
+	if team.Division != nil {
+		teamObj.Division = team.Division.Name
+	}
 	teamObj.Members = members
 	return teamObj
 }
 
 func editTeam(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	var req editTeamReq
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		auditLog.WithFields(logrus.Fields{
@@ -445,8 +492,8 @@ func editTeam(ctx *gin.Context) {
 	userID := ctx.GetUint("user_id")
 	var user models.User
 	var ok bool
-	if user, ok = shared.UserCache.Get(userID); !ok {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	if user, ok = shared.UserCache.Get(ctx, userID); !ok {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "edit_team",
 				"status":  "failure",
@@ -457,7 +504,7 @@ func editTeam(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
 			return
 		}
-		shared.UserCache.Set(userID, user)
+		shared.UserCache.Set(ctx, userID, user)
 	}
 	if user.TeamID == nil {
 		auditLog.WithFields(logrus.Fields{
@@ -472,8 +519,8 @@ func editTeam(ctx *gin.Context) {
 	}
 	var team models.Team
 	cacheHit := false
-	if team, cacheHit = shared.TeamCache.Get(*user.TeamID); !cacheHit {
-		if err := models.DB.Preload("Members").First(&team, *user.TeamID).Error; err != nil {
+	if team, cacheHit = shared.TeamCache.Get(ctx, *user.TeamID); !cacheHit {
+		if err := models.DB.WithContext(ctx).Preload("Members").Preload("Division").First(&team, *user.TeamID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "edit_team",
 				"status":  "failure",
@@ -485,19 +532,39 @@ func editTeam(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Team not found"})
 			return
 		}
-		shared.TeamCache.Set(*user.TeamID, team)
+		shared.TeamCache.Set(ctx, *user.TeamID, team)
 	}
-	if team.LeaderID != user.ID {
+	role := models.TeamRoleOf(&team, &user)
+	if (req.Name != nil || req.JoinPolicy != nil || req.ProfileUpdate.Changed()) && !models.TeamRoleHasPermission(role, models.TeamPermRename) ||
+		req.LeaderUsername != nil && !models.TeamRoleHasPermission(role, models.TeamPermRoles) {
 		auditLog.WithFields(logrus.Fields{
-			"event":    "edit_team",
-			"status":   "failure",
-			"reason":   "not_team_leader",
-			"user_id":  user.ID,
-			"team_id":  team.ID,
-			"username": user.Username,
-			"ip":       ctx.ClientIP(),
-		}).Warn("Non-leader tried to edit team")
-		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Only the team leader can edit the team"})
+			"event":     "edit_team",
+			"status":    "failure",
+			"reason":    "team_role_forbidden",
+			"user_id":   user.ID,
+			"team_id":   team.ID,
+			"username":  user.Username,
+			"team_role": role,
+			"ip":        ctx.ClientIP(),
+		}).Warn("Team member tried an edit their role does not allow")
+		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Your team role does not allow this"})
+		return
+	}
+	if req.LeaderUsername != nil && !shared.RosterOpen(ctx, "edit_team", user.ID, &team.ID) {
+		return
+	}
+	// the rest of the edit is team management, which the flag leaves alone
+	if req.ProfileUpdate.Changed() && !shared.Flag(shared.FlagProfileEdit) {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "edit_team",
+			"status":  "failure",
+			"reason":  "flag_off",
+			"flag":    shared.FlagProfileEdit,
+			"user_id": user.ID,
+			"team_id": team.ID,
+			"ip":      ctx.ClientIP(),
+		}).Warn("Team profile edit blocked by feature flag")
+		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "This action is disabled right now"})
 		return
 	}
 	updates := logrus.Fields{
@@ -508,13 +575,17 @@ func editTeam(ctx *gin.Context) {
 		"ip":      ctx.ClientIP(),
 		"cache":   cacheHit,
 	}
+	// only the changed columns are written: the team may come from the cache,
+	// and saving it whole would put back members who left in the meantime
+	columns := map[string]any{}
 	if req.Name != nil {
 		updates["new_name"] = *req.Name
 		team.Name = *req.Name
+		columns["name"] = team.Name
 	}
 	if req.LeaderUsername != nil {
 		var newLeader models.User
-		if err := models.DB.Where("username = ? AND team_id = ?", *req.LeaderUsername, team.ID).First(&newLeader).Error; err != nil {
+		if err := models.DB.WithContext(ctx).Where("username = ? AND team_id = ?", *req.LeaderUsername, team.ID).First(&newLeader).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":            "edit_team",
 				"status":           "failure",
@@ -528,10 +599,50 @@ func editTeam(ctx *gin.Context) {
 			return
 		}
 		team.LeaderID = newLeader.ID
+		columns["leader_id"] = team.LeaderID
 		updates["new_leader_id"] = newLeader.ID
 		updates["new_leader_username"] = newLeader.Username
 	}
-	if err := models.DB.Save(&team).Error; err != nil {
+	if req.JoinPolicy != nil {
+		updates["join_policy"] = *req.JoinPolicy
+		team.JoinPolicy = *req.JoinPolicy
+		columns["join_policy"] = team.JoinPolicy
+	}
+	if req.ProfileUpdate.Changed() {
+		if err := req.ProfileUpdate.Apply(&team.Profile); err != nil {
+			auditLog.WithFields(logrus.Fields{
+				"event":   "edit_team",
+				"status":  "failure",
+				"reason":  "invalid_profile",
+				"user_id": user.ID,
+				"team_id": team.ID,
+				"ip":      ctx.ClientIP(),
+				"error":   err.Error(),
+			}).Warn("Invalid team profile fields")
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: shared.ProfileErrorMessage(err)})
+			return
+		}
+		updates["country"] = team.Country
+		updates["affiliation"] = team.Affiliation
+		updates["website"] = team.Website
+		columns["country"] = team.Country
+		columns["affiliation"] = team.Affiliation
+		columns["website"] = team.Website
+		columns["bio"] = team.Bio
+	}
+	err := models.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if req.LeaderUsername != nil {
+			// the outgoing captain keeps their duties as a co-captain
+			if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("team_role", models.TeamRoleCoCaptain).Error; err != nil {
+				return err
+			}
+		}
+		if len(columns) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Team{}).Where("id = ?", team.ID).Updates(columns).Error
+	})
+	if err != nil {
 		auditLog.WithFields(logrus.Fields{
 			"event":   "edit_team",
 			"status":  "failure",
@@ -539,23 +650,33 @@ func editTeam(ctx *gin.Context) {
 			"user_id": user.ID,
 			"team_id": team.ID,
 			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
 		}).Error("Failed to update team")
 		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update team"})
 		return
 	}
-	shared.TeamCache.Set(team.ID, team)
+	if req.LeaderUsername != nil {
+		shared.ForgetUser(ctx, user.ID)
+	}
+	shared.ForgetTeam(ctx, team.ID)
 	updates["status"] = "success"
 	auditLog.WithFields(updates).Info("Team updated successfully")
+	if req.Name != nil {
+		events.Publish(events.TeamRenamed, user.ID, team.ID, map[string]any{"name": team.Name})
+	}
+	if req.LeaderUsername != nil {
+		events.Publish(events.TeamLeaderChanged, team.LeaderID, team.ID, map[string]any{"leader": updates["new_leader_username"]})
+	}
 	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Team updated successfully"})
 }
 
 func deleteTeam(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	userID := ctx.GetUint("user_id")
 	var user models.User
 	var ok bool
-	if user, ok = shared.UserCache.Get(userID); !ok {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	if user, ok = shared.UserCache.Get(ctx, userID); !ok {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "delete_team",
 				"status":  "failure",
@@ -566,7 +687,7 @@ func deleteTeam(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
 			return
 		}
-		shared.UserCache.Set(userID, user)
+		shared.UserCache.Set(ctx, userID, user)
 	}
 	if user.TeamID == nil {
 		auditLog.WithFields(logrus.Fields{
@@ -581,8 +702,8 @@ func deleteTeam(ctx *gin.Context) {
 	}
 	var team models.Team
 	cacheHit := false
-	if team, cacheHit = shared.TeamCache.Get(*user.TeamID); !cacheHit {
-		if err := models.DB.Preload("Members").First(&team, *user.TeamID).Error; err != nil {
+	if team, cacheHit = shared.TeamCache.Get(ctx, *user.TeamID); !cacheHit {
+		if err := models.DB.WithContext(ctx).Preload("Members").Preload("Division").First(&team, *user.TeamID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "delete_team",
 				"status":  "failure",
@@ -594,21 +715,25 @@ func deleteTeam(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Team not found"})
 			return
 		}
-		shared.TeamCache.Set(*user.TeamID, team)
+		shared.TeamCache.Set(ctx, *user.TeamID, team)
 	}
-	if team.LeaderID != user.ID {
+	if role := models.TeamRoleOf(&team, &user); !models.TeamRoleHasPermission(role, models.TeamPermDelete) {
 		auditLog.WithFields(logrus.Fields{
-			"event":   "delete_team",
-			"status":  "failure",
-			"reason":  "not_team_leader",
-			"user_id": user.ID,
-			"team_id": team.ID,
-			"ip":      ctx.ClientIP(),
-		}).Warn("Non-leader tried to delete team")
-		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Only the team leader can delete the team"})
+			"event":     "delete_team",
+			"status":    "failure",
+			"reason":    "team_role_forbidden",
+			"user_id":   user.ID,
+			"team_id":   team.ID,
+			"team_role": role,
+			"ip":        ctx.ClientIP(),
+		}).Warn("Team member tried to delete team without the role for it")
+		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Only the team captain can delete the team"})
 		return
 	}
-	if err := models.DB.Delete(&team).Error; err != nil {
+	if !shared.RosterOpen(ctx, "delete_team", user.ID, &team.ID) {
+		return
+	}
+	if err := models.DB.WithContext(ctx).Delete(&team).Error; err != nil {
 		auditLog.WithFields(logrus.Fields{
 			"event":   "delete_team",
 			"status":  "failure",
@@ -620,9 +745,9 @@ func deleteTeam(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to delete team from database"})
 		return
 	}
-	shared.TeamCache.Delete(team.ID)
+	shared.TeamCache.Delete(ctx, team.ID)
 	for _, member := range team.Members {
-		shared.UserCache.Delete(member.ID)
+		shared.UserCache.Delete(ctx, member.ID)
 	}
 	auditLog.WithFields(logrus.Fields{
 		"event":   "delete_team",
@@ -632,16 +757,17 @@ func deleteTeam(ctx *gin.Context) {
 		"ip":      ctx.ClientIP(),
 		"cache":   cacheHit,
 	}).Info("Team deleted successfully")
+	events.Publish(events.TeamDeleted, user.ID, team.ID, map[string]any{"name": team.Name})
 	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Deleted team successfully"})
 }
 
 func leaveTeam(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	userID := ctx.GetUint("user_id")
 	var user models.User
 	var ok bool
-	if user, ok = shared.UserCache.Get(userID); !ok {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	if user, ok = shared.UserCache.Get(ctx, userID); !ok {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "leave_team",
 				"status":  "failure",
@@ -652,7 +778,7 @@ func leaveTeam(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
 			return
 		}
-		shared.UserCache.Set(userID, user)
+		shared.UserCache.Set(ctx, userID, user)
 	}
 	if user.TeamID == nil {
 		auditLog.WithFields(logrus.Fields{
@@ -667,8 +793,8 @@ func leaveTeam(ctx *gin.Context) {
 	}
 	var team models.Team
 	cacheHit := false
-	if team, cacheHit = shared.TeamCache.Get(*user.TeamID); !cacheHit {
-		if err := models.DB.Preload("Members").First(&team, *user.TeamID).Error; err != nil {
+	if team, cacheHit = shared.TeamCache.Get(ctx, *user.TeamID); !cacheHit {
+		if err := models.DB.WithContext(ctx).Preload("Members").Preload("Division").First(&team, *user.TeamID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "leave_team",
 				"status":  "failure",
@@ -680,9 +806,9 @@ func leaveTeam(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Team not found"})
 			return
 		}
-		shared.TeamCache.Set(*user.TeamID, team)
+		shared.TeamCache.Set(ctx, *user.TeamID, team)
 	}
-	if team.LeaderID == user.ID {
+	if models.TeamRoleOf(&team, &user) == models.TeamRoleCaptain {
 		auditLog.WithFields(logrus.Fields{
 			"event":   "leave_team",
 			"status":  "failure",
@@ -694,7 +820,10 @@ func leaveTeam(ctx *gin.Context) {
 		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Team leader cannot leave the team. Transfer leadership or delete the team."})
 		return
 	}
-	if err := models.DB.Model(&user).Update("team_id", nil).Error; err != nil {
+	if !shared.RosterOpen(ctx, "leave_team", user.ID, &team.ID) {
+		return
+	}
+	if err := models.DB.WithContext(ctx).Model(&user).Updates(map[string]any{"team_id": nil, "team_role": models.TeamRoleMember}).Error; err != nil {
 		auditLog.WithFields(logrus.Fields{
 			"event":   "leave_team",
 			"status":  "failure",
@@ -706,14 +835,14 @@ func leaveTeam(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to leave team"})
 		return
 	}
-	shared.UserCache.Delete(user.ID)
+	shared.UserCache.Delete(ctx, user.ID)
 	for i, member := range team.Members {
 		if member.ID == user.ID {
 			team.Members = append(team.Members[:i], team.Members[i+1:]...)
 			break
 		}
 	}
-	shared.TeamCache.Set(team.ID, team)
+	shared.TeamCache.Set(ctx, team.ID, team)
 	auditLog.WithFields(logrus.Fields{
 		"event":   "leave_team",
 		"status":  "success",
@@ -722,5 +851,6 @@ func leaveTeam(ctx *gin.Context) {
 		"ip":      ctx.ClientIP(),
 		"cache":   cacheHit,
 	}).Info("User left the team")
+	events.Publish(events.TeamMemberLeft, user.ID, team.ID, map[string]any{"username": user.Username})
 	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Successfully left the team"})
 }
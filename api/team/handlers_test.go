@@ -0,0 +1,49 @@
+package team_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/intraware/rodan-authify/api/team"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// join asks to join pwners as user with the given code and returns the
+// status code.
+func join(t *testing.T, r http.Handler, user models.User, pwners models.Team, code string) int {
+	t.Helper()
+	return testutil.Do(r, http.MethodPost, fmt.Sprintf("/api/team/join/%d", pwners.ID),
+		testutil.Token(t, user), `{"code":"`+code+`"}`).Code
+}
+
+func TestJoinTeamHandler(t *testing.T) {
+	testutil.SetupAPI(t)
+	r := testutil.Router(team.LoadTeam)
+
+	leader := testutil.CreateUser(t, "leader")
+	pwners := testutil.CreateTeam(t, models.DB, leader)
+
+	alice := testutil.CreateUser(t, "alice")
+	require.Equal(t, http.StatusUnauthorized, join(t, r, alice, pwners, "wrong"))
+	require.Equal(t, http.StatusOK, join(t, r, alice, pwners, pwners.Code))
+	require.NoError(t, models.DB.First(&alice, alice.ID).Error)
+	require.NotNil(t, alice.TeamID)
+	require.Equal(t, pwners.ID, *alice.TeamID)
+	require.Equal(t, models.TeamRoleMember, alice.TeamRole)
+	var users int64
+	require.NoError(t, models.DB.Model(&models.User{}).Count(&users).Error)
+	require.EqualValues(t, 2, users)
+
+	// app.team-size is two
+	bob := testutil.CreateUser(t, "bob")
+	require.Equal(t, http.StatusConflict, join(t, r, bob, pwners, pwners.Code))
+	require.NoError(t, models.DB.First(&bob, bob.ID).Error)
+	require.Nil(t, bob.TeamID)
+
+	require.NoError(t, models.DB.Model(&alice).Update("team_id", nil).Error)
+	require.NoError(t, models.DB.Model(&pwners).Update("locked", true).Error)
+	require.Equal(t, http.StatusForbidden, join(t, r, bob, pwners, pwners.Code))
+}
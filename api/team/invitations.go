@@ -0,0 +1,247 @@
+package team
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/lifecycle"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/email"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func createTeamInvitation(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	var req createTeamInvitationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":  "create_team_invitation",
+			"status": "failure",
+			"reason": "invalid_request_body",
+			"ip":     ctx.ClientIP(),
+		}).Warn("Failed to parse team invitation request")
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		return
+	}
+	inviter, team, ok := loadManagedTeam(ctx, "create_team_invitation", models.TeamPermInvite)
+	if !ok {
+		return
+	}
+	if team.Locked {
+		ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "Unlock the team roster before inviting"})
+		return
+	}
+	// Checks that depend only on the team come first and answer plainly. Every
+	// outcome that depends on the invitee gets the same response, so that the
+	// endpoint does not reveal which usernames and emails have accounts.
+	division, err := models.TeamDivision(models.DB.WithContext(ctx), &team)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to check division rules"})
+		return
+	}
+	var members, pending int64
+	if err := models.DB.WithContext(ctx).Model(&models.User{}).Where("team_id = ?", team.ID).Count(&members).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to retrieve team members"})
+		return
+	}
+	if int(members) >= division.MaxTeamSize() {
+		ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "Team max size reached"})
+		return
+	}
+	accepted := func() {
+		ctx.JSON(http.StatusAccepted, types.SuccessResponse{Message: "If the user can join your team, they have been invited"})
+	}
+	notInvited := func(reason string, inviteeID uint) {
+		auditLog.WithFields(logrus.Fields{
+			"event":      "create_team_invitation",
+			"status":     "failure",
+			"reason":     reason,
+			"user_id":    inviter.ID,
+			"team_id":    team.ID,
+			"invitee_id": inviteeID,
+			"ip":         ctx.ClientIP(),
+		}).Warn("Team invitation not sent")
+		accepted()
+	}
+	var invitee models.User
+	query := models.DB.WithContext(ctx).Where("username = ?", req.Username)
+	if req.Username == "" {
+		query = models.DB.WithContext(ctx).Where("email = ?", req.Email)
+	}
+	if err := query.First(&invitee).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			notInvited("invitee_not_found", 0)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
+		return
+	}
+	switch {
+	case invitee.Ban || invitee.Blacklist:
+		notInvited("invitee_banned", invitee.ID)
+		return
+	case invitee.DeleteAfter != nil:
+		notInvited("invitee_deleting", invitee.ID)
+		return
+	case invitee.TeamID != nil:
+		notInvited("invitee_in_team", invitee.ID)
+		return
+	}
+	if err := division.Admits(models.DB.WithContext(ctx), &invitee); err != nil {
+		if errors.Is(err, models.ErrDivisionIneligible) {
+			notInvited("division_ineligible", invitee.ID)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to check division rules"})
+		return
+	}
+	if err := models.DB.WithContext(ctx).Model(&models.TeamInvitation{}).
+		Where("team_id = ? AND invitee_id = ? AND status = ? AND expires_at > ?", team.ID, invitee.ID, models.InvitationPending, time.Now()).
+		Count(&pending).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if pending > 0 {
+		notInvited("invitation_pending", invitee.ID)
+		return
+	}
+	expiresIn := teamInvitationExpiry
+	if req.ExpiresIn != nil {
+		expiresIn = time.Duration(*req.ExpiresIn) * time.Hour
+	}
+	invitation := models.TeamInvitation{
+		TeamID:    team.ID,
+		InviteeID: invitee.ID,
+		InviterID: inviter.ID,
+		Status:    models.InvitationPending,
+		ExpiresAt: time.Now().Add(expiresIn),
+	}
+	if err := models.DB.WithContext(ctx).Create(&invitation).Error; err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":      "create_team_invitation",
+			"status":     "failure",
+			"reason":     "db_error",
+			"user_id":    inviter.ID,
+			"team_id":    team.ID,
+			"invitee_id": invitee.ID,
+			"ip":         ctx.ClientIP(),
+			"error":      err.Error(),
+		}).Error("Failed to create team invitation")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create invitation"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":         "create_team_invitation",
+		"status":        "success",
+		"user_id":       inviter.ID,
+		"team_id":       team.ID,
+		"invitee_id":    invitee.ID,
+		"invitation_id": invitation.ID,
+		"ip":            ctx.ClientIP(),
+	}).Info("Team invitation created")
+	if emailCfg := values.GetConfig().App.Email; emailCfg.Enabled && emailCfg.InviteTemplate != "" {
+		ip := ctx.ClientIP()
+		sendCtx := context.WithoutCancel(ctx.Request.Context())
+		lifecycle.Go(func() {
+			if err := sendTeamInvitation(sendCtx, invitee, inviter, team, invitation); err != nil {
+				auditLog.WithFields(logrus.Fields{
+					"event":         "create_team_invitation",
+					"status":        "failure",
+					"reason":        "send_email_failed",
+					"invitee_id":    invitee.ID,
+					"invitation_id": invitation.ID,
+					"ip":            ip,
+				}).Errorf("Failed to send team invitation email: %v", err)
+			}
+		})
+	}
+	accepted()
+}
+
+func listTeamInvitations(ctx *gin.Context) {
+	_, team, ok := loadManagedTeam(ctx, "list_team_invitations", models.TeamPermInvite)
+	if !ok {
+		return
+	}
+	var invitations []models.TeamInvitation
+	if err := models.DB.WithContext(ctx).Preload("Invitee").Where("team_id = ?", team.ID).
+		Order("created_at DESC").Find(&invitations).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch invitations"})
+		return
+	}
+	resp := make([]teamInvitationResponse, len(invitations))
+	for i, invitation := range invitations {
+		resp[i] = buildTeamInvitationResponse(invitation, invitation.Invitee)
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func revokeTeamInvitation(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	invitationID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid invitation ID"})
+		return
+	}
+	inviter, team, ok := loadManagedTeam(ctx, "revoke_team_invitation", models.TeamPermInvite)
+	if !ok {
+		return
+	}
+	result := models.DB.WithContext(ctx).Model(&models.TeamInvitation{}).
+		Where("id = ? AND team_id = ? AND status = ?", invitationID, team.ID, models.InvitationPending).
+		Update("status", models.InvitationRevoked)
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to revoke invitation"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Pending invitation not found"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":         "revoke_team_invitation",
+		"status":        "success",
+		"user_id":       inviter.ID,
+		"team_id":       team.ID,
+		"invitation_id": invitationID,
+		"ip":            ctx.ClientIP(),
+	}).Info("Team invitation revoked")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Invitation revoked"})
+}
+
+func sendTeamInvitation(ctx context.Context, invitee, inviter models.User, team models.Team, invitation models.TeamInvitation) error {
+	emailCfg := values.GetConfig().App.Email
+	data := struct {
+		Invitee   string
+		Inviter   string
+		Team      string
+		Link      string
+		ExpiresAt time.Time
+	}{
+		Invitee:   invitee.Username,
+		Inviter:   inviter.Username,
+		Team:      team.Name,
+		Link:      emailCfg.InvitesURL,
+		ExpiresAt: invitation.ExpiresAt,
+	}
+	return email.Send(ctx, invitee.Email, emailCfg.InviteSubject, emailCfg.InviteTemplate, data)
+}
+
+func buildTeamInvitationResponse(invitation models.TeamInvitation, invitee models.User) teamInvitationResponse {
+	return teamInvitationResponse{
+		ID:        invitation.ID,
+		InviteeID: invitation.InviteeID,
+		Invitee:   invitee.Username,
+		Status:    invitation.State(),
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
@@ -0,0 +1,70 @@
+package team_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/intraware/rodan-authify/api/team"
+	"github.com/intraware/rodan-authify/api/user"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamInvitations(t *testing.T) {
+	testutil.SetupAPI(t)
+	r := testutil.Router(team.LoadTeam, user.LoadUser)
+
+	leader := testutil.CreateUser(t, "leader")
+	pwners := testutil.CreateTeam(t, models.DB, leader)
+	leaderToken := testutil.Token(t, leader)
+	invite := func(body string) int {
+		return testutil.Do(r, http.MethodPost, "/api/team/invites", leaderToken, body).Code
+	}
+	pending := func(invitee models.User) []models.TeamInvitation {
+		var invitations []models.TeamInvitation
+		require.NoError(t, models.DB.Where("invitee_id = ? AND status = ?", invitee.ID, models.InvitationPending).Find(&invitations).Error)
+		return invitations
+	}
+
+	// unknown invitees get the same answer as real ones
+	require.Equal(t, http.StatusAccepted, invite(`{"username":"nobody"}`))
+	var count int64
+	require.NoError(t, models.DB.Model(&models.TeamInvitation{}).Count(&count).Error)
+	require.Zero(t, count)
+
+	alice, bob := testutil.CreateUser(t, "alice"), testutil.CreateUser(t, "bob")
+	require.Equal(t, http.StatusAccepted, invite(`{"username":"alice"}`))
+	require.Equal(t, http.StatusAccepted, invite(`{"username":"alice"}`))
+	require.Len(t, pending(alice), 1)
+	require.Equal(t, http.StatusAccepted, invite(`{"email":"bob@example.com"}`))
+	require.Len(t, pending(bob), 1)
+
+	w := testutil.Do(r, http.MethodGet, "/api/user/invites", testutil.Token(t, alice), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed []struct {
+		ID     uint `json:"id"`
+		TeamID uint `json:"team_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	require.Equal(t, pwners.ID, listed[0].TeamID)
+
+	bobInvitation := pending(bob)[0]
+	require.Equal(t, http.StatusOK, testutil.Do(r, http.MethodPost,
+		fmt.Sprintf("/api/user/invites/%d/decline", bobInvitation.ID), testutil.Token(t, bob), "").Code)
+	require.Equal(t, http.StatusConflict, testutil.Do(r, http.MethodPost,
+		fmt.Sprintf("/api/user/invites/%d/accept", bobInvitation.ID), testutil.Token(t, bob), "").Code)
+
+	// only the invitee can accept
+	require.Equal(t, http.StatusNotFound, testutil.Do(r, http.MethodPost,
+		fmt.Sprintf("/api/user/invites/%d/accept", listed[0].ID), testutil.Token(t, bob), "").Code)
+	require.Equal(t, http.StatusOK, testutil.Do(r, http.MethodPost,
+		fmt.Sprintf("/api/user/invites/%d/accept", listed[0].ID), testutil.Token(t, alice), "").Code)
+	require.NoError(t, models.DB.First(&alice, alice.ID).Error)
+	require.NotNil(t, alice.TeamID)
+	require.Equal(t, pwners.ID, *alice.TeamID)
+	require.Empty(t, pending(alice))
+}
@@ -0,0 +1,209 @@
+package team
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// loadManagedTeam fetches the calling user and their team, and makes sure the
+// user's team role grants perm. It writes the error response itself and
+// reports whether the handler may continue.
+func loadManagedTeam(ctx *gin.Context, event, perm string) (user models.User, team models.Team, ok bool) {
+	auditLog := utils.AuditLog(ctx)
+	userID := ctx.GetUint("user_id")
+	if user, ok = shared.UserCache.Get(ctx, userID); !ok {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
+			auditLog.WithFields(logrus.Fields{
+				"event":   event,
+				"status":  "failure",
+				"reason":  "user_not_found",
+				"user_id": userID,
+				"ip":      ctx.ClientIP(),
+			}).Warn("User not found")
+			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+			return user, team, false
+		}
+		shared.UserCache.Set(ctx, userID, user)
+	}
+	if user.TeamID == nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   event,
+			"status":  "failure",
+			"reason":  "no_team",
+			"user_id": user.ID,
+			"ip":      ctx.ClientIP(),
+		}).Warn("User not in a team")
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User is not in a team"})
+		return user, team, false
+	}
+	if team, ok = shared.TeamCache.Get(ctx, *user.TeamID); !ok {
+		if err := models.DB.WithContext(ctx).Preload("Members").Preload("Division").First(&team, *user.TeamID).Error; err != nil {
+			auditLog.WithFields(logrus.Fields{
+				"event":   event,
+				"status":  "failure",
+				"reason":  "team_not_found",
+				"user_id": user.ID,
+				"team_id": *user.TeamID,
+				"ip":      ctx.ClientIP(),
+			}).Warn("Team not found")
+			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Team not found"})
+			return user, team, false
+		}
+		shared.TeamCache.Set(ctx, *user.TeamID, team)
+	}
+	if role := models.TeamRoleOf(&team, &user); !models.TeamRoleHasPermission(role, perm) {
+		auditLog.WithFields(logrus.Fields{
+			"event":     event,
+			"status":    "failure",
+			"reason":    "team_role_forbidden",
+			"user_id":   user.ID,
+			"team_id":   team.ID,
+			"team_role": role,
+			"ip":        ctx.ClientIP(),
+		}).Warn("Team member tried an action their role does not allow")
+		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Your team role does not allow this"})
+		return user, team, false
+	}
+	return user, team, true
+}
+
+func createTeamInviteCode(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	var req createInviteCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":  "create_team_invite_code",
+			"status": "failure",
+			"reason": "invalid_request_body",
+			"ip":     ctx.ClientIP(),
+		}).Warn("Failed to parse invite code request")
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		return
+	}
+	user, team, ok := loadManagedTeam(ctx, "create_team_invite_code", models.TeamPermInvite)
+	if !ok {
+		return
+	}
+	division, err := models.TeamDivision(models.DB.WithContext(ctx), &team)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to load the team's division"})
+		return
+	}
+	var members int64
+	if err := models.DB.WithContext(ctx).Model(&models.User{}).Where("team_id = ?", team.ID).Count(&members).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to retrieve team members"})
+		return
+	}
+	openSlots := division.MaxTeamSize() - int(members)
+	if openSlots <= 0 {
+		ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "Team max size reached"})
+		return
+	}
+	maxUses := req.MaxUses
+	if maxUses == 0 || maxUses > openSlots {
+		maxUses = openSlots
+	}
+	expiresIn := teamInviteCodeExpiry
+	if req.ExpiresIn != nil {
+		expiresIn = time.Duration(*req.ExpiresIn) * time.Hour
+	}
+	expiresAt := time.Now().Add(expiresIn)
+	invite := models.Invite{
+		MaxUses:     maxUses,
+		ExpiresAt:   &expiresAt,
+		Email:       req.Email,
+		TeamID:      &team.ID,
+		CreatedByID: &user.ID,
+	}
+	if err := models.DB.WithContext(ctx).Create(&invite).Error; err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "create_team_invite_code",
+			"status":  "failure",
+			"reason":  "db_error",
+			"user_id": user.ID,
+			"team_id": team.ID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Error("Failed to create team invite code")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create invite code"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":     "create_team_invite_code",
+		"status":    "success",
+		"user_id":   user.ID,
+		"team_id":   team.ID,
+		"invite_id": invite.ID,
+		"max_uses":  invite.MaxUses,
+		"ip":        ctx.ClientIP(),
+	}).Info("Team invite code created")
+	ctx.JSON(http.StatusCreated, buildInviteCodeResponse(invite))
+}
+
+func listTeamInviteCodes(ctx *gin.Context) {
+	_, team, ok := loadManagedTeam(ctx, "list_team_invite_codes", models.TeamPermInvite)
+	if !ok {
+		return
+	}
+	var invites []models.Invite
+	if err := models.DB.WithContext(ctx).Where("team_id = ?", team.ID).Order("created_at DESC").Find(&invites).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch invite codes"})
+		return
+	}
+	resp := make([]inviteCodeResponse, len(invites))
+	for i, invite := range invites {
+		resp[i] = buildInviteCodeResponse(invite)
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func revokeTeamInviteCode(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	inviteID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid invite ID"})
+		return
+	}
+	user, team, ok := loadManagedTeam(ctx, "revoke_team_invite_code", models.TeamPermInvite)
+	if !ok {
+		return
+	}
+	result := models.DB.WithContext(ctx).Model(&models.Invite{}).Where("id = ? AND team_id = ?", inviteID, team.ID).Update("revoked", true)
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to revoke invite code"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Invite code not found"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":     "revoke_team_invite_code",
+		"status":    "success",
+		"user_id":   user.ID,
+		"team_id":   team.ID,
+		"invite_id": inviteID,
+		"ip":        ctx.ClientIP(),
+	}).Info("Team invite code revoked")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Invite code revoked"})
+}
+
+func buildInviteCodeResponse(invite models.Invite) inviteCodeResponse {
+	return inviteCodeResponse{
+		ID:        invite.ID,
+		Code:      invite.Code,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		ExpiresAt: invite.ExpiresAt,
+		Email:     invite.Email,
+		Revoked:   invite.Revoked,
+	}
+}
@@ -0,0 +1,270 @@
+package team
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func requestToJoinTeam(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	teamID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid Team ID"})
+		return
+	}
+	var req joinRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":  "request_join_team",
+			"status": "failure",
+			"reason": "invalid_request_body",
+			"ip":     ctx.ClientIP(),
+		}).Warn("Failed to parse join request")
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		return
+	}
+	userID := ctx.GetUint("user_id")
+	var user models.User
+	if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		return
+	}
+	if user.TeamID != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "User is already in a team"})
+		return
+	}
+	var team models.Team
+	if err := models.DB.WithContext(ctx).First(&team, teamID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Team not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if team.Ban || team.Locked || team.JoinPolicy != models.JoinPolicyApproval {
+		auditLog.WithFields(logrus.Fields{
+			"event":       "request_join_team",
+			"status":      "failure",
+			"reason":      "requests_not_accepted",
+			"user_id":     user.ID,
+			"team_id":     team.ID,
+			"join_policy": team.JoinPolicy,
+			"ip":          ctx.ClientIP(),
+		}).Warn("Join request to a team that does not take them")
+		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "Team does not take join requests"})
+		return
+	}
+	division, err := models.TeamDivision(models.DB.WithContext(ctx), &team)
+	if err == nil {
+		err = division.Admits(models.DB.WithContext(ctx), &user)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrDivisionIneligible) {
+			ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "You do not meet the rules of the team's division"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to check division rules"})
+		return
+	}
+	var members, pending int64
+	if err := models.DB.WithContext(ctx).Model(&models.User{}).Where("team_id = ?", team.ID).Count(&members).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to retrieve team members"})
+		return
+	}
+	if int(members) >= division.MaxTeamSize() {
+		ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "Team max size reached"})
+		return
+	}
+	if err := models.DB.WithContext(ctx).Model(&models.TeamJoinRequest{}).
+		Where("team_id = ? AND user_id = ? AND status = ?", team.ID, user.ID, models.JoinRequestPending).
+		Count(&pending).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
+		return
+	}
+	if pending > 0 {
+		ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "A join request to this team is already pending"})
+		return
+	}
+	request := models.TeamJoinRequest{
+		TeamID:  team.ID,
+		UserID:  user.ID,
+		Message: req.Message,
+		Status:  models.JoinRequestPending,
+	}
+	if err := models.DB.WithContext(ctx).Create(&request).Error; err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "request_join_team",
+			"status":  "failure",
+			"reason":  "db_error",
+			"user_id": user.ID,
+			"team_id": team.ID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Error("Failed to create join request")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to create join request"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":      "request_join_team",
+		"status":     "success",
+		"user_id":    user.ID,
+		"username":   user.Username,
+		"team_id":    team.ID,
+		"request_id": request.ID,
+		"ip":         ctx.ClientIP(),
+	}).Info("Join request created")
+	events.Publish(events.TeamJoinRequested, user.ID, team.ID, map[string]any{"username": user.Username, "request_id": request.ID})
+	ctx.JSON(http.StatusCreated, buildJoinRequestResponse(request, user))
+}
+
+func cancelJoinRequest(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	teamID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid Team ID"})
+		return
+	}
+	userID := ctx.GetUint("user_id")
+	result := models.DB.WithContext(ctx).Model(&models.TeamJoinRequest{}).
+		Where("team_id = ? AND user_id = ? AND status = ?", teamID, userID, models.JoinRequestPending).
+		Update("status", models.JoinRequestCancelled)
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to cancel join request"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Pending join request not found"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":   "cancel_join_request",
+		"status":  "success",
+		"user_id": userID,
+		"team_id": teamID,
+		"ip":      ctx.ClientIP(),
+	}).Info("Join request cancelled")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Join request cancelled"})
+}
+
+func listJoinRequests(ctx *gin.Context) {
+	_, team, ok := loadManagedTeam(ctx, "list_join_requests", models.TeamPermInvite)
+	if !ok {
+		return
+	}
+	var requests []models.TeamJoinRequest
+	if err := models.DB.WithContext(ctx).Preload("User").Where("team_id = ? AND status = ?", team.ID, models.JoinRequestPending).
+		Order("created_at").Find(&requests).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch join requests"})
+		return
+	}
+	resp := make([]joinRequestResponse, len(requests))
+	for i, request := range requests {
+		resp[i] = buildJoinRequestResponse(request, request.User)
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func approveJoinRequest(ctx *gin.Context) {
+	decideJoinRequest(ctx, true)
+}
+
+func rejectJoinRequest(ctx *gin.Context) {
+	decideJoinRequest(ctx, false)
+}
+
+func decideJoinRequest(ctx *gin.Context, approve bool) {
+	auditLog := utils.AuditLog(ctx)
+	event := "reject_join_request"
+	if approve {
+		event = "approve_join_request"
+	}
+	requestID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid join request ID"})
+		return
+	}
+	decider, team, ok := loadManagedTeam(ctx, event, models.TeamPermInvite)
+	if !ok {
+		return
+	}
+	var request models.TeamJoinRequest
+	if approve {
+		if err := models.DB.WithContext(ctx).Where("id = ? AND team_id = ?", requestID, team.ID).Take(&request).Error; err == nil &&
+			!shared.RosterOpen(ctx, event, request.UserID, &team.ID) {
+			return
+		}
+	}
+	err = models.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		request, err = models.DecideJoinRequest(tx, uint(requestID), team.ID, approve, decider.ID)
+		return err
+	})
+	if err != nil {
+		status, message, reason := http.StatusInternalServerError, "Failed to answer join request", "db_error"
+		switch {
+		case errors.Is(err, models.ErrJoinRequestNotFound):
+			status, message, reason = http.StatusNotFound, "Join request not found", "join_request_not_found"
+		case errors.Is(err, models.ErrJoinRequestClosed):
+			status, message, reason = http.StatusConflict, "Join request is no longer pending", "join_request_closed"
+		case errors.Is(err, models.ErrAlreadyInTeam):
+			status, message, reason = http.StatusConflict, "User has joined another team", "already_in_team"
+		case errors.Is(err, models.ErrTeamBanned):
+			status, message, reason = http.StatusForbidden, "Team is banned", "team_banned"
+		case errors.Is(err, models.ErrTeamLocked):
+			status, message, reason = http.StatusConflict, "Team roster is locked", "team_locked"
+		case errors.Is(err, models.ErrTeamFull):
+			status, message, reason = http.StatusConflict, "Team max size reached", "team_full"
+		case errors.Is(err, models.ErrDivisionIneligible):
+			status, message, reason = http.StatusConflict, "User does not meet the rules of the team's division", "division_ineligible"
+		}
+		auditLog.WithFields(logrus.Fields{
+			"event":      event,
+			"status":     "failure",
+			"reason":     reason,
+			"user_id":    decider.ID,
+			"team_id":    team.ID,
+			"request_id": requestID,
+			"ip":         ctx.ClientIP(),
+			"error":      err.Error(),
+		}).Warn("Failed to answer join request")
+		ctx.JSON(status, types.ErrorResponse{Error: message})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":        event,
+		"status":       "success",
+		"user_id":      decider.ID,
+		"team_id":      team.ID,
+		"request_id":   request.ID,
+		"requester_id": request.UserID,
+		"ip":           ctx.ClientIP(),
+	}).Info("Join request answered")
+	events.Publish(events.TeamJoinRequestDecided, request.UserID, team.ID, map[string]any{"request_id": request.ID, "status": request.Status})
+	if approve {
+		shared.UserCache.Delete(ctx, request.UserID)
+		shared.TeamCache.Delete(ctx, team.ID)
+		events.Publish(events.TeamMemberJoined, request.UserID, team.ID, map[string]any{"username": request.User.Username, "via": "join_request"})
+	}
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Join request " + request.Status})
+}
+
+func buildJoinRequestResponse(request models.TeamJoinRequest, user models.User) joinRequestResponse {
+	return joinRequestResponse{
+		ID:        request.ID,
+		UserID:    request.UserID,
+		Username:  user.Username,
+		Message:   request.Message,
+		Status:    request.Status,
+		CreatedAt: request.CreatedAt,
+	}
+}
@@ -0,0 +1,70 @@
+package team_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/api/team"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinRequests(t *testing.T) {
+	testutil.SetupAPI(t)
+	r := testutil.Router(team.LoadTeam)
+
+	leader := testutil.CreateUser(t, "leader")
+	pwners := testutil.CreateTeam(t, models.DB, leader)
+	leaderToken := testutil.Token(t, leader)
+	ask := func(user models.User) (int, uint) {
+		w := testutil.Do(r, http.MethodPost, fmt.Sprintf("/api/team/join/%d/request", pwners.ID),
+			testutil.Token(t, user), `{"message":"I play pwn"}`)
+		var resp struct {
+			ID uint `json:"id"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.ID
+	}
+	decide := func(id uint, decision string) int {
+		return testutil.Do(r, http.MethodPost, fmt.Sprintf("/api/team/join-requests/%d/%s", id, decision), leaderToken, "").Code
+	}
+
+	alice, bob := testutil.CreateUser(t, "alice"), testutil.CreateUser(t, "bob")
+	status, _ := ask(alice)
+	require.Equal(t, http.StatusForbidden, status, "code-only teams take no requests")
+
+	require.NoError(t, models.DB.Model(&pwners).Update("join_policy", models.JoinPolicyApproval).Error)
+	status, aliceRequest := ask(alice)
+	require.Equal(t, http.StatusCreated, status)
+	status, _ = ask(alice)
+	require.Equal(t, http.StatusConflict, status)
+	status, bobRequest := ask(bob)
+	require.Equal(t, http.StatusCreated, status)
+
+	w := testutil.Do(r, http.MethodGet, "/api/team/join-requests", leaderToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed []json.RawMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed, 2)
+	// someone outside the team has no requests to see
+	require.Equal(t, http.StatusNotFound, testutil.Do(r, http.MethodGet, "/api/team/join-requests", testutil.Token(t, bob), "").Code)
+
+	require.Equal(t, http.StatusOK, decide(bobRequest, "reject"))
+	require.NoError(t, models.DB.First(&bob, bob.ID).Error)
+	require.Nil(t, bob.TeamID)
+
+	require.NoError(t, shared.SetFlag(shared.FlagTeamJoin, false))
+	t.Cleanup(func() { shared.SetFlag(shared.FlagTeamJoin, true) })
+	require.Equal(t, http.StatusForbidden, decide(aliceRequest, "approve"))
+	require.NoError(t, shared.SetFlag(shared.FlagTeamJoin, true))
+
+	require.Equal(t, http.StatusOK, decide(aliceRequest, "approve"))
+	require.Equal(t, http.StatusConflict, decide(aliceRequest, "approve"))
+	require.NoError(t, models.DB.First(&alice, alice.ID).Error)
+	require.NotNil(t, alice.TeamID)
+	require.Equal(t, pwners.ID, *alice.TeamID)
+}
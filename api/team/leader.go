@@ -0,0 +1,236 @@
+package team
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func kickTeamMember(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	memberID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid member ID"})
+		return
+	}
+	actor, team, ok := loadManagedTeam(ctx, "kick_team_member", models.TeamPermKick)
+	if !ok {
+		return
+	}
+	if uint(memberID) == actor.ID {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "You cannot kick yourself"})
+		return
+	}
+	var member models.User
+	if err := models.DB.WithContext(ctx).Where("id = ? AND team_id = ?", memberID, team.ID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			auditLog.WithFields(logrus.Fields{
+				"event":     "kick_team_member",
+				"status":    "failure",
+				"reason":    "member_not_found",
+				"user_id":   actor.ID,
+				"team_id":   team.ID,
+				"member_id": memberID,
+				"ip":        ctx.ClientIP(),
+			}).Warn("Kicked user is not in the team")
+			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Member not found in the team"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
+		return
+	}
+	actorRole, memberRole := models.TeamRoleOf(&team, &actor), models.TeamRoleOf(&team, &member)
+	if !models.TeamRoleOutranks(actorRole, memberRole) {
+		auditLog.WithFields(logrus.Fields{
+			"event":       "kick_team_member",
+			"status":      "failure",
+			"reason":      "member_outranks",
+			"user_id":     actor.ID,
+			"team_id":     team.ID,
+			"member_id":   member.ID,
+			"team_role":   actorRole,
+			"member_role": memberRole,
+			"ip":          ctx.ClientIP(),
+		}).Warn("Team member tried to kick someone of equal or higher rank")
+		ctx.JSON(http.StatusForbidden, types.ErrorResponse{Error: "You can only kick members ranked below you"})
+		return
+	}
+	if !shared.RosterOpen(ctx, "kick_team_member", member.ID, &team.ID) {
+		return
+	}
+	// the member's tokens still carry this team's ID; revoke them
+	if err := models.DB.WithContext(ctx).Model(&member).Updates(map[string]any{
+		"team_id":       nil,
+		"team_role":     models.TeamRoleMember,
+		"token_version": gorm.Expr("token_version + 1"),
+	}).Error; err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":     "kick_team_member",
+			"status":    "failure",
+			"reason":    "db_update_failed",
+			"user_id":   actor.ID,
+			"team_id":   team.ID,
+			"member_id": member.ID,
+			"ip":        ctx.ClientIP(),
+			"error":     err.Error(),
+		}).Error("Failed to kick team member")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to kick member"})
+		return
+	}
+	shared.ForgetUser(ctx, member.ID)
+	shared.ForgetLogin(ctx, member.Username)
+	shared.ForgetTeam(ctx, team.ID)
+	auditLog.WithFields(logrus.Fields{
+		"event":           "kick_team_member",
+		"status":          "success",
+		"user_id":         actor.ID,
+		"team_id":         team.ID,
+		"member_id":       member.ID,
+		"member_username": member.Username,
+		"ip":              ctx.ClientIP(),
+	}).Info("Team member kicked")
+	events.Publish(events.TeamMemberLeft, member.ID, team.ID, map[string]any{"username": member.Username, "via": "kicked", "by": actor.Username})
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Member removed from the team"})
+}
+
+func rotateTeamCode(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	actor, team, ok := loadManagedTeam(ctx, "rotate_team_code", models.TeamPermRotateCode)
+	if !ok {
+		return
+	}
+	code, err := models.NewTeamCode()
+	if err == nil {
+		err = models.DB.WithContext(ctx).Model(&models.Team{}).Where("id = ?", team.ID).Update("code", code).Error
+	}
+	if err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "rotate_team_code",
+			"status":  "failure",
+			"reason":  "db_update_failed",
+			"user_id": actor.ID,
+			"team_id": team.ID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Error("Failed to rotate team code")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to rotate team code"})
+		return
+	}
+	shared.ForgetTeam(ctx, team.ID)
+	auditLog.WithFields(logrus.Fields{
+		"event":   "rotate_team_code",
+		"status":  "success",
+		"user_id": actor.ID,
+		"team_id": team.ID,
+		"ip":      ctx.ClientIP(),
+	}).Info("Team code rotated")
+	ctx.JSON(http.StatusOK, rotateCodeResponse{Code: code})
+}
+
+// lockTeam stops anyone from joining the team, whatever its join policy,
+// until the actor unlocks it. Members can still leave or be kicked.
+func lockTeam(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	var req lockTeamRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	locked := req.Locked == nil || *req.Locked
+	actor, team, ok := loadManagedTeam(ctx, "lock_team", models.TeamPermLock)
+	if !ok {
+		return
+	}
+	if err := models.DB.WithContext(ctx).Model(&models.Team{}).Where("id = ?", team.ID).Update("locked", locked).Error; err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "lock_team",
+			"status":  "failure",
+			"reason":  "db_update_failed",
+			"user_id": actor.ID,
+			"team_id": team.ID,
+			"locked":  locked,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Error("Failed to change team lock")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update team"})
+		return
+	}
+	shared.ForgetTeam(ctx, team.ID)
+	auditLog.WithFields(logrus.Fields{
+		"event":   "lock_team",
+		"status":  "success",
+		"user_id": actor.ID,
+		"team_id": team.ID,
+		"locked":  locked,
+		"ip":      ctx.ClientIP(),
+	}).Info("Team lock changed")
+	if locked {
+		ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Team roster locked"})
+	} else {
+		ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Team roster unlocked"})
+	}
+}
+
+func setMemberRole(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	memberID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid member ID"})
+		return
+	}
+	var req setMemberRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: err.Error()})
+		return
+	}
+	actor, team, ok := loadManagedTeam(ctx, "set_team_role", models.TeamPermRoles)
+	if !ok {
+		return
+	}
+	if uint(memberID) == team.LeaderID {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Hand over the captaincy by changing the team leader"})
+		return
+	}
+	result := models.DB.WithContext(ctx).Model(&models.User{}).Where("id = ? AND team_id = ?", memberID, team.ID).Update("team_role", req.Role)
+	if result.Error != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":     "set_team_role",
+			"status":    "failure",
+			"reason":    "db_update_failed",
+			"user_id":   actor.ID,
+			"team_id":   team.ID,
+			"member_id": memberID,
+			"ip":        ctx.ClientIP(),
+			"error":     result.Error.Error(),
+		}).Error("Failed to change team role")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to change role"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Member not found in the team"})
+		return
+	}
+	shared.ForgetUser(ctx, uint(memberID))
+	shared.ForgetTeam(ctx, team.ID)
+	auditLog.WithFields(logrus.Fields{
+		"event":     "set_team_role",
+		"status":    "success",
+		"user_id":   actor.ID,
+		"team_id":   team.ID,
+		"member_id": memberID,
+		"team_role": req.Role,
+		"ip":        ctx.ClientIP(),
+	}).Info("Team role changed")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Role changed to " + req.Role})
+}
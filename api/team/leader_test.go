@@ -0,0 +1,76 @@
+package team_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/intraware/rodan-authify/api/team"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKickTeamMember(t *testing.T) {
+	testutil.SetupAPI(t)
+	r := testutil.Router(team.LoadTeam)
+
+	leader := testutil.CreateUser(t, "leader")
+	alice := testutil.CreateUser(t, "alice")
+	pwners := testutil.CreateTeam(t, models.DB, leader, alice)
+	aliceToken := testutil.Token(t, alice)
+	require.Equal(t, http.StatusOK, testutil.Do(r, http.MethodGet, "/api/team/me", aliceToken, "").Code)
+
+	// members cannot kick
+	require.Equal(t, http.StatusForbidden,
+		testutil.Do(r, http.MethodDelete, fmt.Sprintf("/api/team/members/%d", leader.ID), aliceToken, "").Code)
+
+	require.Equal(t, http.StatusOK,
+		testutil.Do(r, http.MethodDelete, fmt.Sprintf("/api/team/members/%d", alice.ID), testutil.Token(t, leader), "").Code)
+	require.NoError(t, models.DB.First(&alice, alice.ID).Error)
+	require.Nil(t, alice.TeamID)
+	require.Equal(t, http.StatusUnauthorized, testutil.Do(r, http.MethodGet, "/api/team/me", aliceToken, "").Code)
+
+	var members int64
+	require.NoError(t, models.DB.Model(&models.User{}).Where("team_id = ?", pwners.ID).Count(&members).Error)
+	require.EqualValues(t, 1, members)
+}
+
+func TestRotateTeamCode(t *testing.T) {
+	testutil.SetupAPI(t)
+	r := testutil.Router(team.LoadTeam)
+
+	leader := testutil.CreateUser(t, "leader")
+	pwners := testutil.CreateTeam(t, models.DB, leader)
+	w := testutil.Do(r, http.MethodPost, "/api/team/code/rotate", testutil.Token(t, leader), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Code string `json:"code"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Code)
+	require.NotEqual(t, pwners.Code, resp.Code)
+
+	alice := testutil.CreateUser(t, "alice")
+	require.Equal(t, http.StatusUnauthorized, join(t, r, alice, pwners, pwners.Code))
+	require.Equal(t, http.StatusOK, join(t, r, alice, pwners, resp.Code))
+}
+
+func TestLockTeam(t *testing.T) {
+	testutil.SetupAPI(t)
+	r := testutil.Router(team.LoadTeam)
+
+	leader := testutil.CreateUser(t, "leader")
+	pwners := testutil.CreateTeam(t, models.DB, leader)
+	leaderToken := testutil.Token(t, leader)
+	require.Equal(t, http.StatusOK, testutil.Do(r, http.MethodPost, "/api/team/lock", leaderToken, "").Code)
+
+	alice := testutil.CreateUser(t, "alice")
+	require.Equal(t, http.StatusForbidden, join(t, r, alice, pwners, pwners.Code))
+	require.NoError(t, models.DB.First(&alice, alice.ID).Error)
+	require.Nil(t, alice.TeamID)
+
+	require.Equal(t, http.StatusOK, testutil.Do(r, http.MethodPost, "/api/team/lock", leaderToken, `{"locked":false}`).Code)
+	require.Equal(t, http.StatusOK, join(t, r, alice, pwners, pwners.Code))
+}
@@ -2,22 +2,48 @@ package team
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
 	"github.com/intraware/rodan-authify/internal/utils/middleware"
 	"github.com/intraware/rodan-authify/internal/utils/values"
 )
 
 func LoadTeam(r *gin.RouterGroup) {
+	r.GET("/teams", middleware.OptionalAuth, middleware.ViewerCacheMiddleware, listTeams)
+
 	teamRouter := r.Group("/team")
 
-	teamRouter.GET("/:id", middleware.CacheMiddleware, getTeam)
+	teamRouter.GET("/divisions", listDivisions)
+	teamRouter.GET("/:id", middleware.OptionalAuth, middleware.ViewerCacheMiddleware, getTeam)
+	teamRouter.GET("/:id/avatar", getTeamAvatar)
+	teamRouter.GET("/stream", middleware.StreamTicketAuth(shared.StreamScopeTeam), streamTeamEvents)
 
 	protectedRouter := teamRouter.Group("/", middleware.AuthRequired)
-	protectedRouter.POST("/create", createTeam)
-	protectedRouter.POST("/join/:id", joinTeam)
+	protectedRouter.POST("/create", middleware.RequireFlag(shared.FlagTeamCreate), createTeam)
+	protectedRouter.POST("/join/:id", middleware.RequireFlag(shared.FlagTeamJoin), joinTeam)
+	protectedRouter.POST("/join/:id/request", middleware.RequireFlag(shared.FlagTeamJoin), requestToJoinTeam)
+	protectedRouter.DELETE("/join/:id/request", cancelJoinRequest)
+	protectedRouter.POST("/stream/ticket", issueTeamStreamTicket)
 	protectedRouter.GET("/me", middleware.CacheMiddleware, getMyTeam)
 	protectedRouter.PATCH("/edit", editTeam)
+	protectedRouter.POST("/avatar", middleware.RequireFlag(shared.FlagProfileEdit), uploadTeamAvatar)
+	protectedRouter.DELETE("/avatar", deleteTeamAvatar)
 	protectedRouter.DELETE("/delete", deleteTeam)
+	protectedRouter.PATCH("/members/:id", setMemberRole)
+	protectedRouter.DELETE("/members/:id", kickTeamMember)
+	protectedRouter.POST("/code/rotate", rotateTeamCode)
+	protectedRouter.POST("/lock", lockTeam)
+	protectedRouter.GET("/invites", listTeamInvitations)
+	protectedRouter.POST("/invites", createTeamInvitation)
+	protectedRouter.DELETE("/invites/:id", revokeTeamInvitation)
+	protectedRouter.GET("/join-requests", listJoinRequests)
+	protectedRouter.POST("/join-requests/:id/approve", middleware.RequireFlag(shared.FlagTeamJoin), approveJoinRequest)
+	protectedRouter.POST("/join-requests/:id/reject", rejectJoinRequest)
+	if values.GetConfig().App.LeaderInvites {
+		protectedRouter.GET("/invite-codes", listTeamInviteCodes)
+		protectedRouter.POST("/invite-codes", createTeamInviteCode)
+		protectedRouter.DELETE("/invite-codes/:id", revokeTeamInviteCode)
+	}
 	if values.GetConfig().App.AllowLeavingTeam {
-		protectedRouter.POST("/leave", leaveTeam)
+		protectedRouter.POST("/leave", middleware.RequireFlag(shared.FlagTeamLeave), leaveTeam)
 	}
 }
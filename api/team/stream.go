@@ -0,0 +1,96 @@
+package team
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// streamTeamEvents godoc
+// @Summary      Team event stream
+// @Description  Server-sent events for the caller's team: members joining or leaving, leader changes, renames and join requests, plus the answers to the caller's own join requests. Authenticate with a ticket from POST /team/stream/ticket
+// @Tags         team
+// @Produce      text/event-stream
+// @Param        ticket  query  string  true  "Single-use stream ticket"
+// @Success      200  {object}  events.Event
+// @Failure      401  {object}  types.ErrorResponse
+// @Router       /team/stream [get]
+func streamTeamEvents(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	var current atomic.Uint64
+	// listen before reading the user's team, so that a join or leave between
+	// the two is not missed
+	ch, stop := events.Listen(func(e events.Event) bool {
+		if !strings.HasPrefix(e.Type, "team.") || e.TeamID == nil {
+			return false
+		}
+		// follow the user into and out of teams while the stream is open
+		if e.UserID != nil && *e.UserID == userID {
+			switch e.Type {
+			case events.TeamMemberJoined, events.TeamCreated:
+				current.Store(uint64(*e.TeamID))
+				return true
+			case events.TeamMemberLeft:
+				current.CompareAndSwap(uint64(*e.TeamID), 0)
+				return true
+			case events.TeamJoinRequestDecided:
+				return true
+			}
+		}
+		if uint64(*e.TeamID) != current.Load() {
+			return false
+		}
+		if e.Type == events.TeamDeleted {
+			current.Store(0)
+		}
+		return true
+	})
+	defer stop()
+	var user models.User
+	if err := models.DB.WithContext(ctx.Request.Context()).First(&user, userID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		return
+	}
+	if user.TeamID != nil {
+		// an event that already moved the user takes precedence
+		current.CompareAndSwap(0, uint64(*user.TeamID))
+	}
+	utils.AuditLog(ctx).WithFields(logrus.Fields{
+		"event":   "team_stream",
+		"status":  "success",
+		"user_id": userID,
+		"team_id": user.TeamID,
+		"ip":      ctx.ClientIP(),
+	}).Debug("Team event stream opened")
+	shared.StreamEvents(ctx, ch)
+}
+
+// issueTeamStreamTicket godoc
+// @Summary      Team event stream ticket
+// @Description  Issues a single-use ticket, valid for 30 seconds, that opens the team event stream. It keeps the session token out of the stream URL
+// @Tags         team
+// @Produce      json
+// @Success      200  {object}  shared.StreamTicketResponse
+// @Failure      401  {object}  types.ErrorResponse
+// @Failure      500  {object}  types.ErrorResponse
+// @Router       /team/stream/ticket [post]
+func issueTeamStreamTicket(ctx *gin.Context) {
+	ticket, err := shared.IssueStreamTicket(ctx, shared.StreamTicket{
+		Scope:    shared.StreamScopeTeam,
+		UserID:   ctx.GetUint("user_id"),
+		Username: ctx.GetString("username"),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to issue stream ticket"})
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.StreamTicketResponse{Ticket: ticket, ExpiresIn: int(shared.StreamTicketTTL.Seconds())})
+}
@@ -0,0 +1,62 @@
+package team_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/admin"
+	"github.com/intraware/rodan-authify/api/team"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamTickets(t *testing.T) {
+	cfg := testutil.SetupAPI(t)
+	cfg.App.Admin.Endpoint = "/admin"
+	r := testutil.Router(team.LoadTeam, func(g *gin.RouterGroup) { admin.LoadAdminRouter(g, cfg.App.Admin) })
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close) // runs after the streams opened below are cancelled
+
+	leader := testutil.CreateUser(t, "leader")
+	testutil.CreateTeam(t, models.DB, leader)
+	issue := func() string {
+		w := testutil.Do(r, http.MethodPost, "/api/team/stream/ticket", testutil.Token(t, leader), "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Ticket string `json:"ticket"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotEmpty(t, resp.Ticket)
+		return resp.Ticket
+	}
+	open := func(path, ticket string) *http.Response {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path+"?ticket="+ticket, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	require.Equal(t, http.StatusUnauthorized, testutil.Do(r, http.MethodPost, "/api/team/stream/ticket", "", "").Code)
+	require.Equal(t, http.StatusUnauthorized, open("/api/team/stream", "made-up").StatusCode)
+
+	ticket := issue()
+	resp := open("/api/team/stream", ticket)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	// tickets are single-use
+	require.Equal(t, http.StatusUnauthorized, open("/api/team/stream", ticket).StatusCode)
+
+	// and only open the stream of their scope, even once refused
+	ticket = issue()
+	require.Equal(t, http.StatusUnauthorized, open("/api/admin/stream", ticket).StatusCode)
+	require.Equal(t, http.StatusUnauthorized, open("/api/team/stream", ticket).StatusCode)
+}
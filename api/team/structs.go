@@ -1,19 +1,40 @@
 package team
 
+import (
+	"time"
+
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+)
+
+// teamInviteCodeExpiry is used when a leader does not pick an expiry.
+const teamInviteCodeExpiry = 7 * 24 * time.Hour
+
 type createTeamRequest struct {
-	Name string `json:"name" binding:"required" example:"Avengers"`
+	Name       string `json:"name" binding:"required" example:"Avengers"`
+	JoinPolicy string `json:"join_policy" binding:"omitempty,oneof=code open approval closed" example:"code"`
+	DivisionID *uint  `json:"division_id" example:"2"` // required once divisions exist
 }
 
 type joinTeamRequest struct {
-	Code string `json:"code" binding:"required" example:"ABC123"`
+	Code string `json:"code" example:"ABC123"` // not needed for open teams
 }
 
 type teamResponse struct {
-	ID       uint       `json:"id" example:"1"`
-	Name     string     `json:"name" example:"Avengers"`
-	Code     string     `json:"code" example:"ABC123"`
-	LeaderID uint       `json:"leader_id" example:"42"`
-	Members  []userInfo `json:"members"`
+	ID         uint       `json:"id" example:"1"`
+	Name       string     `json:"name" example:"Avengers"`
+	AvatarURL  string     `json:"avatar_url" example:"/api/team/1/avatar"`
+	Code       string     `json:"code,omitempty" example:"ABC123"` // leaders and staff only
+	LeaderID   uint       `json:"leader_id" example:"42"`
+	JoinPolicy string     `json:"join_policy" example:"approval"`
+	Locked     bool       `json:"locked" example:"false"`
+	DivisionID *uint      `json:"division_id" example:"2"`
+	Division   string     `json:"division,omitempty" example:"student"`
+	Members    []userInfo `json:"members"`
+	Ban        bool       `json:"ban,omitempty" example:"false"`       // staff only
+	Blacklist  bool       `json:"blacklist,omitempty" example:"false"` // staff only
+
+	models.Profile
 
 	FirstBlood  *[]uint `json:"first_blood,omitempty" example:"[1,2,3]"`
 	SecondBlood *[]uint `json:"second_blood,omitempty" example:"[1,2,3]"`
@@ -21,14 +42,109 @@ type teamResponse struct {
 }
 
 type userInfo struct {
-	ID        uint   `json:"id" example:"42"`
-	Username  string `json:"username" example:"intraware"`
-	Email     string `json:"email" example:"example@intraware.org"`
-	AvatarURL string `json:"avatar_url" example:"https://.."`
-	TeamID    *uint  `json:"team_id" example:"1"`
+	ID          uint   `json:"id" example:"42"`
+	Username    string `json:"username" example:"intraware"`
+	Email       string `json:"email,omitempty" example:"example@intraware.org"`
+	AvatarURL   string `json:"avatar_url" example:"https://.."`
+	TeamID      *uint  `json:"team_id" example:"1"`
+	TeamRole    string `json:"team_role" example:"co_captain"`
+	Country     string `json:"country,omitempty" example:"IN"`
+	Affiliation string `json:"affiliation,omitempty" example:"IIT Madras"`
 }
 
 type editTeamReq struct {
 	Name           *string `json:"name" example:"New Avengers"`
 	LeaderUsername *string `json:"leader_username" example:"newleader"`
+	JoinPolicy     *string `json:"join_policy" binding:"omitempty,oneof=code open approval closed" example:"approval"`
+	shared.ProfileUpdate
+}
+
+type createInviteCodeRequest struct {
+	MaxUses   int     `json:"max_uses" binding:"omitempty,min=1" example:"2"`
+	ExpiresIn *int    `json:"expires_in_hours" binding:"omitempty,min=1,max=720" example:"48"`
+	Email     *string `json:"email" binding:"omitempty,email" example:"friend@example.org"`
+}
+
+type inviteCodeResponse struct {
+	ID        uint       `json:"id" example:"7"`
+	Code      string     `json:"code" example:"9f86d081884c7d65"`
+	MaxUses   int        `json:"max_uses" example:"2"`
+	Uses      int        `json:"uses" example:"1"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2026-11-01T00:00:00Z"`
+	Email     *string    `json:"email,omitempty" example:"friend@example.org"`
+	Revoked   bool       `json:"revoked" example:"false"`
+}
+
+// teamInvitationExpiry is used when a leader does not pick an expiry.
+const teamInvitationExpiry = 7 * 24 * time.Hour
+
+type createTeamInvitationRequest struct {
+	Username  string `json:"username" binding:"required_without=Email" example:"intraware"`
+	Email     string `json:"email" binding:"omitempty,email" example:"friend@example.org"`
+	ExpiresIn *int   `json:"expires_in_hours" binding:"omitempty,min=1,max=720" example:"48"`
+}
+
+type teamInvitationResponse struct {
+	ID        uint      `json:"id" example:"3"`
+	InviteeID uint      `json:"invitee_id" example:"42"`
+	Invitee   string    `json:"invitee" example:"intraware"`
+	Status    string    `json:"status" example:"pending"`
+	ExpiresAt time.Time `json:"expires_at" example:"2026-11-01T00:00:00Z"`
+	CreatedAt time.Time `json:"created_at" example:"2026-10-25T00:00:00Z"`
+}
+
+type joinRequestRequest struct {
+	Message string `json:"message" binding:"max=500" example:"I play crypto and pwn"`
+}
+
+type joinRequestResponse struct {
+	ID        uint      `json:"id" example:"5"`
+	UserID    uint      `json:"user_id" example:"42"`
+	Username  string    `json:"username" example:"intraware"`
+	Message   string    `json:"message" example:"I play crypto and pwn"`
+	Status    string    `json:"status" example:"pending"`
+	CreatedAt time.Time `json:"created_at" example:"2026-10-25T00:00:00Z"`
+}
+
+type lockTeamRequest struct {
+	Locked *bool `json:"locked" example:"true"` // defaults to true
+}
+
+type rotateCodeResponse struct {
+	Code string `json:"code" example:"9f86d081884c"`
+}
+
+type setMemberRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=co_captain member" example:"co_captain"`
+}
+
+type divisionResponse struct {
+	ID             uint     `json:"id" example:"2"`
+	Name           string   `json:"name" example:"student"`
+	Description    string   `json:"description" example:"Teams of enrolled students"`
+	TeamSize       int      `json:"team_size" example:"4"`
+	OAuthProviders []string `json:"oauth_providers,omitempty" example:"github"`
+}
+
+type teamSummary struct {
+	ID          uint   `json:"id" example:"1"`
+	Name        string `json:"name" example:"Avengers"`
+	AvatarURL   string `json:"avatar_url" example:"/api/team/1/avatar"`
+	DivisionID  *uint  `json:"division_id" example:"2"`
+	Division    string `json:"division,omitempty" example:"student"`
+	Country     string `json:"country" example:"IN"`
+	Affiliation string `json:"affiliation" example:"IIT Madras"`
+	Members     int    `json:"members" example:"3"`
+	JoinPolicy  string `json:"join_policy" example:"approval"`
+	Locked      bool   `json:"locked" example:"false"`
+	Ban         bool   `json:"ban,omitempty" example:"false"` // staff only
+}
+
+type teamListResponse struct {
+	Teams []teamSummary `json:"teams"`
+	shared.Pagination
+}
+
+type avatarResponse struct {
+	AvatarURL string `json:"avatar_url" example:"/avatars/teams/1/4f2a9c1e7b3d5a60/256.png"`
 }
@@ -0,0 +1,137 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/avatar"
+	"github.com/sirupsen/logrus"
+)
+
+// uploadAvatar replaces the caller's avatar with an uploaded image. It also
+// drops any avatar URL an admin or OAuth provider set.
+func uploadAvatar(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	userID := ctx.GetUint("user_id")
+	// the stored key decides which files go once the new ones are in, so a
+	// stale cached copy will not do
+	var user models.User
+	if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		return
+	}
+	files, ok := shared.ReceiveAvatar(ctx)
+	if !ok {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "upload_avatar",
+			"status":  "failure",
+			"reason":  "invalid_upload",
+			"user_id": user.ID,
+			"ip":      ctx.ClientIP(),
+		}).Warn("Rejected avatar upload")
+		return
+	}
+	key, err := avatar.Save(ctx.Request.Context(), shared.AvatarStore, fmt.Sprintf("users/%d", user.ID), files)
+	if err == nil {
+		err = models.DB.WithContext(ctx).Model(&models.User{}).Where("id = ?", user.ID).
+			Updates(map[string]any{"avatar_key": key, "avatar_url": ""}).Error
+	}
+	if err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "upload_avatar",
+			"status":  "failure",
+			"reason":  "store_failed",
+			"user_id": user.ID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Error("Failed to store avatar")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to store avatar"})
+		return
+	}
+	removeOldAvatar(ctx, user, key)
+	forgetUser(ctx, user)
+	auditLog.WithFields(logrus.Fields{
+		"event":      "upload_avatar",
+		"status":     "success",
+		"user_id":    user.ID,
+		"avatar_key": key,
+		"ip":         ctx.ClientIP(),
+	}).Info("Avatar uploaded")
+	user.AvatarKey, user.AvatarURL = key, ""
+	ctx.JSON(http.StatusOK, avatarResponse{AvatarURL: shared.UserAvatarURL(&user)})
+}
+
+// deleteAvatar drops the caller's avatar, leaving the identicon.
+func deleteAvatar(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	userID := ctx.GetUint("user_id")
+	var user models.User
+	if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		return
+	}
+	if err := models.DB.WithContext(ctx).Model(&user).Updates(map[string]any{"avatar_key": "", "avatar_url": ""}).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to remove avatar"})
+		return
+	}
+	removeOldAvatar(ctx, user, "")
+	forgetUser(ctx, user)
+	auditLog.WithFields(logrus.Fields{
+		"event":   "delete_avatar",
+		"status":  "success",
+		"user_id": user.ID,
+		"ip":      ctx.ClientIP(),
+	}).Info("Avatar removed")
+	ctx.JSON(http.StatusOK, avatarResponse{AvatarURL: shared.UserAvatarURL(&user)})
+}
+
+// removeOldAvatar deletes the files of the avatar the user had before,
+// unless an identical upload kept the same key.
+func removeOldAvatar(ctx *gin.Context, user models.User, newKey string) {
+	if user.AvatarKey == "" || user.AvatarKey == newKey {
+		return
+	}
+	if err := avatar.Remove(ctx.Request.Context(), shared.AvatarStore, user.AvatarKey); err != nil {
+		utils.AuditLog(ctx).WithFields(logrus.Fields{
+			"event":      "remove_avatar_files",
+			"status":     "failure",
+			"user_id":    user.ID,
+			"avatar_key": user.AvatarKey,
+			"error":      err.Error(),
+		}).Warn("Failed to delete old avatar files")
+	}
+}
+
+// forgetUser drops the cached copies that carry the user's avatar.
+func forgetUser(ctx context.Context, user models.User) {
+	shared.UserCache.Delete(ctx, user.ID)
+	if user.TeamID != nil {
+		shared.TeamCache.Delete(ctx, *user.TeamID)
+	}
+}
+
+// getAvatar serves a user's avatar at ?size=, drawing an identicon for users
+// without one.
+func getAvatar(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+	user, ok := shared.UserCache.Get(ctx, uint(userID))
+	if !ok {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+			return
+		}
+		shared.UserCache.Set(ctx, user.ID, user)
+	}
+	shared.ServeAvatar(ctx, user.AvatarKey, user.AvatarURL, fmt.Sprintf("user:%d", user.ID))
+}
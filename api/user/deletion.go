@@ -0,0 +1,199 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// recentLogin is how fresh the session of an account without a password
+// must be to delete it without a TOTP code.
+const recentLogin = 5 * time.Minute
+
+// deleteProfile schedules the caller's account for anonymisation once the
+// deletion grace period is over. The caller has to re-authenticate first.
+func deleteProfile(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	userID := ctx.GetUint("user_id")
+	var req deleteAccountRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid input"})
+			return
+		}
+	}
+	var user models.User
+	if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "delete_profile",
+			"status":  "failure",
+			"reason":  "user_not_found",
+			"user_id": userID,
+			"ip":      ctx.ClientIP(),
+		}).Warn("User not found in deleteProfile")
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		return
+	}
+	if reason := reauthenticate(ctx, user, req); reason != "" {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "delete_profile",
+			"status":  "failure",
+			"reason":  reason,
+			"user_id": user.ID,
+			"ip":      ctx.ClientIP(),
+		}).Warn("Re-authentication failed in deleteProfile")
+		ctx.JSON(http.StatusUnauthorized, types.ErrorResponse{Error: "Confirm with your password, or log in again if you have none, and a TOTP code if enabled"})
+		return
+	}
+	// Anonymising a member takes them off their team's roster
+	if user.TeamID != nil && !shared.RosterOpen(ctx, "delete_profile", user.ID, user.TeamID) {
+		return
+	}
+	cfg := values.GetConfig()
+	at := time.Now().Add(shared.DeletionGrace(&cfg.App)).UTC()
+	if err := models.ScheduleDeletion(models.DB.WithContext(ctx), &user, at); err != nil {
+		if errors.Is(err, models.ErrDeletionScheduled) {
+			ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "Account deletion is already scheduled"})
+			return
+		}
+		auditLog.WithFields(logrus.Fields{
+			"event":   "delete_profile",
+			"status":  "failure",
+			"reason":  "db_error",
+			"user_id": user.ID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Error("Failed to schedule account deletion")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Internal server error"})
+		return
+	}
+	shared.UserCache.Delete(ctx, user.ID)
+	auditLog.WithFields(logrus.Fields{
+		"event":        "delete_profile",
+		"status":       "success",
+		"user_id":      user.ID,
+		"delete_after": at,
+		"ip":           ctx.ClientIP(),
+	}).Info("Account scheduled for deletion")
+	ctx.JSON(http.StatusAccepted, deletionResponse{Message: "Account scheduled for deletion", DeleteAfter: at})
+}
+
+// reauthenticate checks that the caller just proved who they are: with their
+// password and, for accounts with TOTP, a current code. Accounts that only log
+// in through OAuth have no password; a TOTP code does for them, and without
+// TOTP a session from a moment ago does, as the OAuth callback is the only
+// place they get one. It returns the audit reason for a failure, or "".
+func reauthenticate(ctx *gin.Context, user models.User, req deleteAccountRequest) string {
+	hasPassword := user.HasPassword()
+	if hasPassword {
+		if req.Password == "" {
+			return "password_required"
+		}
+		if ok, err := user.ComparePassword(req.Password); err != nil || !ok {
+			return "invalid_password"
+		}
+	}
+	userTOTP, hasTOTP := models.UserTOTPMeta{}, false
+	if values.GetConfig().App.TOTP.Enabled {
+		if userTOTP, hasTOTP = shared.TOTPCache.Get(ctx, user.Username); !hasTOTP {
+			err := models.DB.WithContext(ctx).Where("user_id = ?", user.ID).First(&userTOTP).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return "db_error"
+			}
+			if hasTOTP = err == nil; hasTOTP {
+				shared.TOTPCache.Set(ctx, user.Username, userTOTP)
+			}
+		}
+	}
+	if hasTOTP {
+		if req.OTP == "" || !userTOTP.VerifyTOTP(req.OTP) {
+			return "invalid_otp"
+		}
+		return ""
+	}
+	if !hasPassword {
+		if authTime := ctx.GetTime("auth_time"); authTime.IsZero() || time.Since(authTime) > recentLogin {
+			return "reauthentication_required"
+		}
+	}
+	return ""
+}
+
+// cancelDeletion keeps the caller's account while the grace period lasts.
+func cancelDeletion(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	userID := ctx.GetUint("user_id")
+	var user models.User
+	if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		return
+	}
+	if err := models.CancelDeletion(models.DB.WithContext(ctx), &user); err != nil {
+		if errors.Is(err, models.ErrDeletionNotScheduled) {
+			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "No account deletion is scheduled"})
+			return
+		}
+		auditLog.WithFields(logrus.Fields{
+			"event":   "cancel_deletion",
+			"status":  "failure",
+			"reason":  "db_error",
+			"user_id": user.ID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Error("Failed to cancel account deletion")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Internal server error"})
+		return
+	}
+	shared.UserCache.Delete(ctx, user.ID)
+	auditLog.WithFields(logrus.Fields{
+		"event":   "cancel_deletion",
+		"status":  "success",
+		"user_id": user.ID,
+		"ip":      ctx.ClientIP(),
+	}).Info("Account deletion cancelled")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Account deletion cancelled"})
+}
+
+// exportData hands the caller everything stored about them as a JSON
+// download.
+func exportData(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	userID := ctx.GetUint("user_id")
+	var user models.User
+	if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
+		return
+	}
+	export, err := models.ExportUser(models.DB.WithContext(ctx), &user)
+	if err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "export_data",
+			"status":  "failure",
+			"reason":  "db_error",
+			"user_id": user.ID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Error("Failed to collect account data")
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to export account data"})
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":   "export_data",
+		"status":  "success",
+		"user_id": user.ID,
+		"ip":      ctx.ClientIP(),
+	}).Info("Account data exported")
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="account-%d.json"`, user.ID))
+	ctx.JSON(http.StatusOK, export)
+}
@@ -0,0 +1,65 @@
+package user_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/api/user"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountDeletion(t *testing.T) {
+	testutil.SetupAPI(t)
+	r := testutil.Router(user.LoadUser)
+
+	leader, alice := testutil.CreateUser(t, "leader"), testutil.CreateUser(t, "alice")
+	pwners := testutil.CreateTeam(t, models.DB, leader, alice)
+	token := testutil.Token(t, alice)
+	// reload into a fresh value, as gorm keeps a pointer field it scans NULL
+	// into; anonymised rows are soft-deleted
+	reload := func() models.User {
+		var u models.User
+		require.NoError(t, models.DB.Unscoped().First(&u, alice.ID).Error)
+		return u
+	}
+	remove := func(body string) int {
+		return testutil.Do(r, http.MethodDelete, "/api/user/delete", token, body).Code
+	}
+
+	require.Equal(t, http.StatusUnauthorized, remove(""))
+	require.Equal(t, http.StatusUnauthorized, remove(`{"password":"wrong"}`))
+	require.Equal(t, http.StatusAccepted, remove(`{"password":"hunter2-alice"}`))
+	require.Equal(t, http.StatusConflict, remove(`{"password":"hunter2-alice"}`))
+	deleteAfter := reload().DeleteAfter
+	require.NotNil(t, deleteAfter)
+	require.WithinDuration(t, time.Now().Add(shared.DeletionGrace(&values.GetConfig().App)), *deleteAfter, time.Minute)
+
+	require.Equal(t, http.StatusOK, testutil.Do(r, http.MethodPost, "/api/user/delete/cancel", token, "").Code)
+	require.Equal(t, http.StatusNotFound, testutil.Do(r, http.MethodPost, "/api/user/delete/cancel", token, "").Code)
+	require.Nil(t, reload().DeleteAfter)
+
+	w := testutil.Do(r, http.MethodGet, "/api/user/export", token, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `attachment; filename="account-`+strconv.Itoa(int(alice.ID))+`.json"`, w.Header().Get("Content-Disposition"))
+	require.True(t, json.Valid(w.Body.Bytes()))
+	require.Contains(t, w.Body.String(), "alice@example.com")
+
+	// the purge anonymises the account once the grace period is over
+	require.Equal(t, http.StatusAccepted, remove(`{"password":"hunter2-alice"}`))
+	require.NoError(t, models.DB.Model(&alice).Update("delete_after", time.Now().Add(-time.Minute)).Error)
+	require.NoError(t, shared.AnonymiseAccount(context.Background(), alice.ID))
+	require.Equal(t, http.StatusUnauthorized, testutil.Do(r, http.MethodGet, "/api/user/me", token, "").Code)
+	anonymised := reload()
+	require.Equal(t, "deleted-"+strconv.Itoa(int(alice.ID)), anonymised.Username)
+	require.Nil(t, anonymised.TeamID)
+	require.NoError(t, models.DB.First(&pwners, pwners.ID).Error)
+	require.Equal(t, leader.ID, pwners.LeaderID)
+}
@@ -0,0 +1,78 @@
+package user
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+)
+
+var userSortKeys = map[string]string{
+	"username":   "username",
+	"created_at": "created_at",
+}
+
+// buildUserInfo shows the user as the given view sees them.
+func buildUserInfo(user models.User, view string) userInfo {
+	info := userInfo{
+		ID:        user.ID,
+		Username:  user.Username,
+		AvatarURL: shared.UserAvatarURL(&user),
+		Profile:   user.Profile,
+	}
+	if user.EmailVisible(view) {
+		info.Email = user.Email
+	}
+	if user.TeamVisible(view) {
+		info.TeamID = user.TeamID
+	}
+	if view == models.ViewAdmin {
+		info.Ban = user.Ban
+	}
+	return info
+}
+
+// listUsers is the public user directory. Banned users, and for the public
+// the team of users who hide it, are left out except for staff.
+func listUsers(ctx *gin.Context) {
+	order, ok := shared.SortOrder(ctx, userSortKeys, "username")
+	if !ok {
+		return
+	}
+	viewer := shared.Viewer(ctx)
+	admin := models.AdminViewer(viewer)
+	query := shared.Search(models.DB.WithContext(ctx).Model(&models.User{}), "username", ctx.Query("q"))
+	if !admin {
+		query = query.Where("ban = ?", false)
+	}
+	if v := ctx.Query("team_id"); v != "" {
+		teamID, err := strconv.Atoi(v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid team_id"})
+			return
+		}
+		query = query.Where("team_id = ?", teamID)
+		if !admin && (viewer == nil || viewer.TeamID == nil || *viewer.TeamID != uint(teamID)) {
+			query = query.Where("hide_team = ?", false)
+		}
+	}
+	page := shared.ParsePagination(ctx)
+	query, err := page.Apply(query)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch users"})
+		return
+	}
+	var users []models.User
+	if err := query.Order(order).Find(&users).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch users"})
+		return
+	}
+	resp := userListResponse{Users: make([]userInfo, len(users)), Pagination: page}
+	for i, user := range users {
+		resp.Users[i] = buildUserInfo(user, models.UserViewFor(viewer, &user))
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
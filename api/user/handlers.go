@@ -15,12 +15,12 @@ import (
 )
 
 func getMyProfile(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	userID := ctx.GetUint("user_id")
 	var user models.User
 	cacheHit := false
-	if user, cacheHit = shared.UserCache.Get(userID); !cacheHit {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	if user, cacheHit = shared.UserCache.Get(ctx, userID); !cacheHit {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "get_my_profile",
 				"status":  "failure",
@@ -31,19 +31,23 @@ func getMyProfile(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found"})
 			return
 		} else {
-			shared.UserCache.Set(user.ID, user)
+			shared.UserCache.Set(ctx, user.ID, user)
 		}
 	}
 	userInfo := userInfo{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		AvatarURL: user.AvatarURL,
-		TeamID:    user.TeamID,
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		AvatarURL:   shared.UserAvatarURL(&user),
+		TeamID:      user.TeamID,
+		HideEmail:   &user.HideEmail,
+		HideTeam:    &user.HideTeam,
+		Profile:     user.Profile,
+		DeleteAfter: user.DeleteAfter,
 	}
 	if user.TeamID != nil {
 		var solves []models.Solve
-		if err := models.DB.Where("user_id = ? AND blood_count <= 3", *user.TeamID).Find(&solves).Error; err == nil {
+		if err := models.DB.WithContext(ctx).Where("user_id = ? AND blood_count <= 3", *user.TeamID).Find(&solves).Error; err == nil {
 			var first, second, third []uint
 			for _, s := range solves {
 				switch s.BloodCount {
@@ -78,7 +82,7 @@ func getMyProfile(ctx *gin.Context) {
 }
 
 func getUserProfile(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	userIDStr := ctx.Param("id")
 	userIDInt, err := strconv.Atoi(userIDStr)
 	if err != nil {
@@ -95,8 +99,8 @@ func getUserProfile(ctx *gin.Context) {
 	userID := uint(userIDInt)
 	var user models.User
 	cacheHit := false
-	if user, cacheHit = shared.UserCache.Get(userID); !cacheHit {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	if user, cacheHit = shared.UserCache.Get(ctx, userID); !cacheHit {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				auditLog.WithFields(logrus.Fields{
 					"event":   "get_user_profile",
@@ -119,18 +123,14 @@ func getUserProfile(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Database error"})
 			return
 		} else {
-			shared.UserCache.Set(userID, user)
+			shared.UserCache.Set(ctx, userID, user)
 		}
 	}
-	userInfo := userInfo{
-		ID:        user.ID,
-		Username:  user.Username,
-		AvatarURL: user.AvatarURL,
-		TeamID:    user.TeamID,
-	}
-	if user.TeamID != nil {
+	view := models.UserViewFor(shared.Viewer(ctx), &user)
+	userInfo := buildUserInfo(user, view)
+	if userInfo.TeamID != nil {
 		var solves []models.Solve
-		if err := models.DB.Where("user_id = ? AND blood_count <= 3", *user.TeamID).Find(&solves).Error; err == nil {
+		if err := models.DB.WithContext(ctx).Where("user_id = ? AND blood_count <= 3", *user.TeamID).Find(&solves).Error; err == nil {
 			var first, second, third []uint
 			for _, s := range solves {
 				switch s.BloodCount {
@@ -158,6 +158,7 @@ func getUserProfile(ctx *gin.Context) {
 		"status":   "success",
 		"user_id":  user.ID,
 		"username": user.Username,
+		"view":     view,
 		"ip":       ctx.ClientIP(),
 		"cache":    cacheHit,
 	}).Info("Fetched other user's profile")
@@ -165,7 +166,7 @@ func getUserProfile(ctx *gin.Context) {
 }
 
 func updateProfile(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	userID := ctx.GetUint("user_id")
 	var input updateUserRequest
 	if err := ctx.ShouldBindJSON(&input); err != nil {
@@ -181,8 +182,8 @@ func updateProfile(ctx *gin.Context) {
 	}
 	var user models.User
 	cacheHit := false
-	if user, cacheHit = shared.UserCache.Get(userID); !cacheHit {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	if user, cacheHit = shared.UserCache.Get(ctx, userID); !cacheHit {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "update_profile",
 				"status":  "failure",
@@ -195,29 +196,53 @@ func updateProfile(ctx *gin.Context) {
 		}
 	}
 	oldUsername := user.Username
-	oldAvatarURL := user.AvatarURL
-	if input.Username != nil {
+	if input.Username != nil && *input.Username != oldUsername {
+		if models.ReservedUsername(*input.Username) {
+			auditLog.WithFields(logrus.Fields{
+				"event":        "update_profile",
+				"status":       "failure",
+				"reason":       "reserved_username",
+				"user_id":      user.ID,
+				"new_username": *input.Username,
+				"ip":           ctx.ClientIP(),
+			}).Warn("Reserved username in updateProfile")
+			ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: models.ErrUsernameReserved.Error()})
+			return
+		}
 		user.Username = *input.Username
 	}
-	if input.AvatarURL != nil {
-		user.AvatarURL = *input.AvatarURL
+	if input.HideEmail != nil {
+		user.HideEmail = *input.HideEmail
+	}
+	if input.HideTeam != nil {
+		user.HideTeam = *input.HideTeam
+	}
+	if err := input.Apply(&user.Profile); err != nil {
+		auditLog.WithFields(logrus.Fields{
+			"event":   "update_profile",
+			"status":  "failure",
+			"reason":  "invalid_profile",
+			"user_id": user.ID,
+			"ip":      ctx.ClientIP(),
+			"error":   err.Error(),
+		}).Warn("Invalid profile fields in updateProfile")
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: shared.ProfileErrorMessage(err)})
+		return
 	}
-	if err := models.DB.Save(&user).Error; err != nil {
+	if err := models.DB.WithContext(ctx).Save(&user).Error; err != nil {
 		if strings.Contains(err.Error(), "UNIQUE") || strings.Contains(err.Error(), "duplicate") {
 			auditLog.WithFields(logrus.Fields{
 				"event":        "update_profile",
 				"status":       "failure",
-				"reason":       "duplicate_username_or_github",
+				"reason":       "duplicate_username",
 				"user_id":      user.ID,
 				"old_username": oldUsername,
 				"new_username": user.Username,
-				"old_avatar":   oldAvatarURL,
-				"new_avatar":   user.AvatarURL,
 				"ip":           ctx.ClientIP(),
 				"cache":        cacheHit,
 				"error":        err.Error(),
-			}).Warn("Username or AvatarURL already in use in updateProfile")
-			ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "Username or AvatarURL already in use"})
+			}).Warn("Username already in use in updateProfile")
+			ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "Username already in use"})
 			return
 		}
 		auditLog.WithFields(logrus.Fields{
@@ -231,54 +256,23 @@ func updateProfile(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to update profile"})
 		return
 	}
-	shared.UserCache.Delete(userID)
+	shared.UserCache.Delete(ctx, userID)
+	if user.TeamID != nil {
+		shared.TeamCache.Delete(ctx, *user.TeamID) // cached members carry the profile
+	}
 	auditLog.WithFields(logrus.Fields{
 		"event":        "update_profile",
 		"status":       "success",
 		"user_id":      user.ID,
 		"old_username": oldUsername,
 		"new_username": user.Username,
-		"old_avatar":   oldAvatarURL,
-		"new_avatar":   user.AvatarURL,
+		"hide_email":   user.HideEmail,
+		"hide_team":    user.HideTeam,
+		"country":      user.Country,
+		"affiliation":  user.Affiliation,
+		"website":      user.Website,
 		"ip":           ctx.ClientIP(),
 		"cache":        cacheHit,
 	}).Info("Profile updated successfully")
 	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Profile updated successfully"})
 }
-
-func deleteProfile(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
-	userID := ctx.GetUint("user_id")
-	result := models.DB.Delete(&models.User{}, userID)
-	if result.Error != nil {
-		auditLog.WithFields(logrus.Fields{
-			"event":   "delete_profile",
-			"status":  "failure",
-			"reason":  "db_error",
-			"user_id": userID,
-			"ip":      ctx.ClientIP(),
-			"error":   result.Error.Error(),
-		}).Error("Failed to delete user in deleteProfile")
-		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Internal server error"})
-		return
-	}
-	shared.UserCache.Delete(userID)
-	if result.RowsAffected == 0 {
-		auditLog.WithFields(logrus.Fields{
-			"event":   "delete_profile",
-			"status":  "failure",
-			"reason":  "not_found_or_already_deleted",
-			"user_id": userID,
-			"ip":      ctx.ClientIP(),
-		}).Warn("User not found or already deleted in deleteProfile")
-		ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "User not found or already deleted"})
-		return
-	}
-	auditLog.WithFields(logrus.Fields{
-		"event":   "delete_profile",
-		"status":  "success",
-		"user_id": userID,
-		"ip":      ctx.ClientIP(),
-	}).Info("User deleted successfully")
-	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "User deleted successfully"})
-}
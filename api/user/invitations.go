@@ -0,0 +1,148 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/types"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func listMyInvitations(ctx *gin.Context) {
+	userID := ctx.GetUint("user_id")
+	var invitations []models.TeamInvitation
+	if err := models.DB.WithContext(ctx).Preload("Team").Preload("Inviter").
+		Where("invitee_id = ? AND status = ? AND expires_at > ?", userID, models.InvitationPending, time.Now()).
+		Order("created_at DESC").Find(&invitations).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch invitations"})
+		return
+	}
+	resp := make([]invitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		if invitation.Team.ID == 0 {
+			continue // the team has been deleted since
+		}
+		resp = append(resp, invitationResponse{
+			ID:        invitation.ID,
+			TeamID:    invitation.TeamID,
+			TeamName:  invitation.Team.Name,
+			InviterID: invitation.InviterID,
+			Inviter:   invitation.Inviter.Username,
+			ExpiresAt: invitation.ExpiresAt,
+			CreatedAt: invitation.CreatedAt,
+		})
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func acceptInvitation(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	invitationID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid invitation ID"})
+		return
+	}
+	userID := ctx.GetUint("user_id")
+	var user models.User
+	var invitation models.TeamInvitation
+	if err := models.DB.WithContext(ctx).Where("id = ? AND invitee_id = ?", invitationID, userID).Take(&invitation).Error; err == nil &&
+		!shared.RosterOpen(ctx, "accept_team_invitation", userID, &invitation.TeamID) {
+		return
+	}
+	err = models.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		invitation, err = models.AcceptTeamInvitation(tx, uint(invitationID), &user)
+		return err
+	})
+	if err != nil {
+		status, message, reason := http.StatusInternalServerError, "Failed to accept invitation", "db_error"
+		switch {
+		case errors.Is(err, models.ErrInvitationNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+			status, message, reason = http.StatusNotFound, "Invitation not found", "invitation_not_found"
+		case errors.Is(err, models.ErrInvitationClosed):
+			status, message, reason = http.StatusConflict, "Invitation is no longer pending", "invitation_closed"
+		case errors.Is(err, models.ErrInvitationExpired):
+			status, message, reason = http.StatusGone, "Invitation has expired", "invitation_expired"
+		case errors.Is(err, models.ErrAlreadyInTeam):
+			status, message, reason = http.StatusBadRequest, "User is already in a team", "already_in_team"
+		case errors.Is(err, models.ErrTeamBanned):
+			status, message, reason = http.StatusForbidden, "Team is banned", "team_banned"
+		case errors.Is(err, models.ErrTeamLocked):
+			status, message, reason = http.StatusForbidden, "Team roster is locked", "team_locked"
+		case errors.Is(err, models.ErrTeamFull):
+			status, message, reason = http.StatusConflict, "Team max size reached", "team_full"
+		case errors.Is(err, models.ErrDivisionIneligible):
+			status, message, reason = http.StatusForbidden, "You do not meet the rules of the team's division", "division_ineligible"
+		}
+		auditLog.WithFields(logrus.Fields{
+			"event":         "accept_team_invitation",
+			"status":        "failure",
+			"reason":        reason,
+			"user_id":       userID,
+			"invitation_id": invitationID,
+			"ip":            ctx.ClientIP(),
+			"error":         err.Error(),
+		}).Warn("Failed to accept team invitation")
+		ctx.JSON(status, types.ErrorResponse{Error: message})
+		return
+	}
+	shared.UserCache.Delete(ctx, user.ID)
+	shared.TeamCache.Delete(ctx, invitation.TeamID)
+	auditLog.WithFields(logrus.Fields{
+		"event":         "accept_team_invitation",
+		"status":        "success",
+		"user_id":       user.ID,
+		"username":      user.Username,
+		"team_id":       invitation.TeamID,
+		"invitation_id": invitation.ID,
+		"ip":            ctx.ClientIP(),
+	}).Info("User joined team through an invitation")
+	events.Publish(events.TeamMemberJoined, user.ID, invitation.TeamID, map[string]any{"username": user.Username, "via": "invitation"})
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Joined " + invitation.Team.Name})
+}
+
+func declineInvitation(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	invitationID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, types.ErrorResponse{Error: "Invalid invitation ID"})
+		return
+	}
+	userID := ctx.GetUint("user_id")
+	user := models.User{Model: gorm.Model{ID: userID}}
+	var invitation models.TeamInvitation
+	err = models.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		invitation, err = models.DeclineTeamInvitation(tx, uint(invitationID), &user)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvitationNotFound):
+			ctx.JSON(http.StatusNotFound, types.ErrorResponse{Error: "Invitation not found"})
+		case errors.Is(err, models.ErrInvitationClosed):
+			ctx.JSON(http.StatusConflict, types.ErrorResponse{Error: "Invitation is no longer pending"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to decline invitation"})
+		}
+		return
+	}
+	auditLog.WithFields(logrus.Fields{
+		"event":         "decline_team_invitation",
+		"status":        "success",
+		"user_id":       userID,
+		"team_id":       invitation.TeamID,
+		"invitation_id": invitation.ID,
+		"ip":            ctx.ClientIP(),
+	}).Info("Team invitation declined")
+	ctx.JSON(http.StatusOK, types.SuccessResponse{Message: "Invitation declined"})
+}
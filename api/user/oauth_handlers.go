@@ -25,12 +25,12 @@ func listOAuthProviders(ctx *gin.Context) {
 }
 
 func getUserOAuth(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	userID := ctx.GetUint("user_id")
 	var user models.User
 	cacheHit := false
-	if user, cacheHit = shared.UserCache.Get(userID); !cacheHit {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	if user, cacheHit = shared.UserCache.Get(ctx, userID); !cacheHit {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "get_user_oauth",
 				"status":  "failure",
@@ -42,12 +42,12 @@ func getUserOAuth(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to get the user"})
 			return
 		}
-		shared.UserCache.Set(userID, user)
+		shared.UserCache.Set(ctx, userID, user)
 	}
 	var userOauth models.UserOauthMeta
 	var ok bool
-	if userOauth, ok = shared.OAuthCache.Get(user.ID); !ok {
-		if err := models.DB.Where("user_id = ?", user.ID).First(&userOauth).Error; err != nil {
+	if userOauth, ok = shared.OAuthCache.Get(ctx, user.ID); !ok {
+		if err := models.DB.WithContext(ctx).Where("user_id = ?", user.ID).First(&userOauth).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				ctx.JSON(http.StatusOK, gin.H{"oauth": nil})
 				return
@@ -63,7 +63,7 @@ func getUserOAuth(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to get the user oauth metadata"})
 			return
 		}
-		shared.OAuthCache.Set(user.ID, userOauth)
+		shared.OAuthCache.Set(ctx, user.ID, userOauth)
 	}
 	ctx.JSON(http.StatusOK, gin.H{
 		"provider":   userOauth.Provider,
@@ -73,11 +73,12 @@ func getUserOAuth(ctx *gin.Context) {
 }
 
 func unlinkUserOAuth(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	userID := ctx.GetUint("user_id")
 	var user models.User
-	if user, cacheHit := shared.UserCache.Get(userID); !cacheHit {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	var cacheHit bool
+	if user, cacheHit = shared.UserCache.Get(ctx, userID); !cacheHit {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "unlink_user_oauth",
 				"status":  "failure",
@@ -89,12 +90,12 @@ func unlinkUserOAuth(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to get the user"})
 			return
 		}
-		shared.UserCache.Set(userID, user)
+		shared.UserCache.Set(ctx, userID, user)
 	}
 	var userOauth models.UserOauthMeta
 	var ok bool
-	if userOauth, ok = shared.OAuthCache.Get(user.ID); !ok {
-		if err := models.DB.Where("user_id = ?", user.ID).First(&userOauth).Error; err != nil {
+	if userOauth, ok = shared.OAuthCache.Get(ctx, user.ID); !ok {
+		if err := models.DB.WithContext(ctx).Where("user_id = ?", user.ID).First(&userOauth).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "unlink_user_oauth",
 				"status":  "failure",
@@ -106,9 +107,9 @@ func unlinkUserOAuth(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to get the user oauth metadata"})
 			return
 		}
-		shared.OAuthCache.Set(user.ID, userOauth)
+		shared.OAuthCache.Set(ctx, user.ID, userOauth)
 	}
-	err := models.DB.Transaction(func(tx *gorm.DB) error {
+	err := models.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Delete(&userOauth).Error; err != nil {
 			return fmt.Errorf("delete_user_oauth: %w", err)
 		}
@@ -129,8 +130,8 @@ func unlinkUserOAuth(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to unlink oauth account"})
 		return
 	}
-	shared.OAuthCache.Delete(user.ID)
-	shared.UserCache.Delete(user.ID)
+	shared.OAuthCache.Delete(ctx, user.ID)
+	shared.UserCache.Delete(ctx, user.ID)
 	auditLog.WithFields(logrus.Fields{
 		"event":   "unlink_user_oauth",
 		"status":  "success",
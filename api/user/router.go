@@ -2,22 +2,33 @@ package user
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
 	"github.com/intraware/rodan-authify/internal/utils/middleware"
 	"github.com/intraware/rodan-authify/internal/utils/values"
 )
 
 func LoadUser(r *gin.RouterGroup) {
+	r.GET("/users", middleware.OptionalAuth, middleware.ViewerCacheMiddleware, listUsers)
+
 	userRouter := r.Group("/user")
 
 	protectedRouter := userRouter.Group("/", middleware.AuthRequired)
 	protectedRouter.GET("/me", middleware.CacheMiddleware, getMyProfile)
-	protectedRouter.PATCH("/edit", updateProfile)
-	protectedRouter.DELETE("/delete", deleteProfile)
+	protectedRouter.PATCH("/edit", middleware.RequireFlag(shared.FlagProfileEdit), updateProfile)
+	protectedRouter.DELETE("/delete", middleware.RequireFlag(shared.FlagAccountDelete), deleteProfile)
+	protectedRouter.POST("/delete/cancel", cancelDeletion)
+	protectedRouter.GET("/export", exportData)
+	protectedRouter.POST("/avatar", middleware.RequireFlag(shared.FlagProfileEdit), uploadAvatar)
+	protectedRouter.DELETE("/avatar", deleteAvatar)
+	protectedRouter.GET("/invites", listMyInvitations)
+	protectedRouter.POST("/invites/:id/accept", middleware.RequireFlag(shared.FlagTeamJoin), acceptInvitation)
+	protectedRouter.POST("/invites/:id/decline", declineInvitation)
 	if values.GetConfig().App.TOTP.Enabled {
 		protectedRouter.GET("/totp-qr", middleware.CacheMiddleware, profileTOTP)
 		protectedRouter.GET("/backup-code", profileBackupCode)
 	}
-	userRouter.GET("/:id", middleware.CacheMiddleware, getUserProfile)
+	userRouter.GET("/:id", middleware.OptionalAuth, middleware.ViewerCacheMiddleware, getUserProfile)
+	userRouter.GET("/:id/avatar", getAvatar)
 	if values.GetConfig().App.OAuth.Enabled {
 		userRouter.GET("/providers", middleware.CacheMiddleware, listOAuthProviders)
 		protectedRouter.GET("/oauth", middleware.CacheMiddleware, getUserOAuth)
@@ -1,11 +1,28 @@
 package user
 
+import (
+	"time"
+
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+)
+
 type userInfo struct {
 	ID        uint   `json:"id" example:"42"`
 	Username  string `json:"username" example:"intraware"`
-	Email     string `json:"email" example:"example@intraware.org"`
+	Email     string `json:"email,omitempty" example:"example@intraware.org"`
 	AvatarURL string `json:"avatar_url,omitempty" example:"https://.."`
 	TeamID    *uint  `json:"team_id" example:"1"`
+	Ban       bool   `json:"ban,omitempty" example:"false"` // staff only
+
+	models.Profile
+
+	// Own privacy settings, only on the user's own profile
+	HideEmail *bool `json:"hide_email,omitempty" example:"true"`
+	HideTeam  *bool `json:"hide_team,omitempty" example:"false"`
+
+	// When a requested deletion takes effect, only on the user's own profile
+	DeleteAfter *time.Time `json:"delete_after,omitempty" example:"2026-11-01T00:00:00Z"`
 
 	FirstBlood  *[]uint `json:"first_blood,omitempty" example:"[1,2,3]"`
 	SecondBlood *[]uint `json:"second_blood,omitempty" example:"[1,2,3]"`
@@ -14,9 +31,40 @@ type userInfo struct {
 
 type updateUserRequest struct {
 	Username  *string `json:"username"`
-	AvatarURL *string `json:"avatar_url"`
+	HideEmail *bool   `json:"hide_email"`
+	HideTeam  *bool   `json:"hide_team"`
+	shared.ProfileUpdate
+}
+
+type userListResponse struct {
+	Users []userInfo `json:"users"`
+	shared.Pagination
 }
 
 type providersList struct {
 	Providers []string `json:"providers" example:"[google,github,microsoft]"`
 }
+
+type invitationResponse struct {
+	ID        uint      `json:"id" example:"3"`
+	TeamID    uint      `json:"team_id" example:"1"`
+	TeamName  string    `json:"team_name" example:"Avengers"`
+	InviterID uint      `json:"inviter_id" example:"7"`
+	Inviter   string    `json:"inviter" example:"teamlead"`
+	ExpiresAt time.Time `json:"expires_at" example:"2026-11-01T00:00:00Z"`
+	CreatedAt time.Time `json:"created_at" example:"2026-10-25T00:00:00Z"`
+}
+
+type avatarResponse struct {
+	AvatarURL string `json:"avatar_url" example:"/avatars/users/42/4f2a9c1e7b3d5a60/256.png"`
+}
+
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+	OTP      string `json:"otp"`
+}
+
+type deletionResponse struct {
+	Message     string    `json:"message" example:"Account scheduled for deletion"`
+	DeleteAfter time.Time `json:"delete_after" example:"2026-11-01T00:00:00Z"`
+}
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,15 +11,16 @@ import (
 	"github.com/intraware/rodan-authify/internal/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
 )
 
 func profileTOTP(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	userID := ctx.GetUint("user_id")
 	var user models.User
 	cacheHit := false
-	if user, cacheHit = shared.UserCache.Get(userID); !cacheHit {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	if user, cacheHit = shared.UserCache.Get(ctx, userID); !cacheHit {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "profile_totp",
 				"status":  "failure",
@@ -30,12 +32,18 @@ func profileTOTP(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch user"})
 			return
 		}
-		shared.UserCache.Set(userID, user)
+		shared.UserCache.Set(ctx, userID, user)
 	}
 	var userTotp models.UserTOTPMeta
 	var ok bool
-	if userTotp, ok = shared.TOTPCache.Get(user.Username); !ok {
-		if err := models.DB.Where("user_id = ?", user.ID).First(&userTotp).Error; err != nil {
+	if userTotp, ok = shared.TOTPCache.Get(ctx, user.Username); !ok {
+		err := models.DB.WithContext(ctx).Where("user_id = ?", user.ID).First(&userTotp).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// first visit enrols the user
+			userTotp = models.UserTOTPMeta{UserID: user.ID, User: &user}
+			err = models.DB.WithContext(ctx).Omit("User").Create(&userTotp).Error
+		}
+		if err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "profile_totp",
 				"status":  "failure",
@@ -47,7 +55,7 @@ func profileTOTP(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch user"})
 			return
 		}
-		shared.TOTPCache.Set(user.Username, userTotp)
+		shared.TOTPCache.Set(ctx, user.Username, userTotp)
 	}
 	totpURL, _ := userTotp.TOTPUrl()
 	png, err := qrcode.Encode(totpURL, qrcode.Medium, 256)
@@ -75,12 +83,12 @@ func profileTOTP(ctx *gin.Context) {
 }
 
 func profileBackupCode(ctx *gin.Context) {
-	auditLog := utils.Logger.WithField("type", "audit")
+	auditLog := utils.AuditLog(ctx)
 	userID := ctx.GetUint("user_id")
 	var user models.User
 	cacheHit := false
-	if user, cacheHit = shared.UserCache.Get(userID); !cacheHit {
-		if err := models.DB.First(&user, userID).Error; err != nil {
+	if user, cacheHit = shared.UserCache.Get(ctx, userID); !cacheHit {
+		if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "profile_backup_code",
 				"status":  "failure",
@@ -92,12 +100,12 @@ func profileBackupCode(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch user"})
 			return
 		}
-		shared.UserCache.Set(userID, user)
+		shared.UserCache.Set(ctx, userID, user)
 	}
 	var userTotp models.UserTOTPMeta
 	var ok bool
-	if userTotp, ok = shared.TOTPCache.Get(user.Username); !ok {
-		if err := models.DB.Where("user_id = ?", user.ID).First(&userTotp).Error; err != nil {
+	if userTotp, ok = shared.TOTPCache.Get(ctx, user.Username); !ok {
+		if err := models.DB.WithContext(ctx).Where("user_id = ?", user.ID).First(&userTotp).Error; err != nil {
 			auditLog.WithFields(logrus.Fields{
 				"event":   "profile_totp",
 				"status":  "failure",
@@ -109,7 +117,7 @@ func profileBackupCode(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, types.ErrorResponse{Error: "Failed to fetch user"})
 			return
 		}
-		shared.TOTPCache.Set(user.Username, userTotp)
+		shared.TOTPCache.Set(ctx, user.Username, userTotp)
 	}
 	auditLog.WithFields(logrus.Fields{
 		"event":   "profile_backup_code",
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the config file and print the effective config with secrets redacted",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return err
+		}
+		out, err := toml.Marshal(values.GetConfig().Redacted())
+		if err != nil {
+			return fmt.Errorf("failed to render config: %w", err)
+		}
+		os.Stdout.Write(out)
+		fmt.Fprintln(os.Stderr, "config is valid")
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
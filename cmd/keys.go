@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage admin API keys",
+}
+
+var keysRotateCmd = &cobra.Command{
+	Use:   "rotate <name>",
+	Short: "Replace the secret of a named admin API key, keeping its scopes",
+	Long: "Replace the secret of a named admin API key. The key keeps its name, " +
+		"scopes and expiry; the old secret stops working at once and the new " +
+		"one is printed exactly once. A revoked key is reactivated.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		closeApp, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer closeApp()
+		var key models.AdminAPIKey
+		if err := models.DB.Where("name = ?", args[0]).First(&key).Error; err != nil {
+			return fmt.Errorf("API key %q: %w", args[0], err)
+		}
+		oldPrefix := key.Prefix
+		raw, err := models.NewAdminAPIKey(&key)
+		if err == nil {
+			err = models.DB.Model(&key).Updates(map[string]any{
+				"prefix":  key.Prefix,
+				"hash":    key.Hash,
+				"revoked": false,
+			}).Error
+		}
+		if err != nil {
+			cliLog().WithFields(logrus.Fields{
+				"event":  "cli_rotate_api_key",
+				"status": "failure",
+				"reason": "db_error",
+				"key_id": key.ID,
+				"name":   key.Name,
+				"error":  err.Error(),
+			}).Error("Failed to rotate API key")
+			return fmt.Errorf("failed to rotate key: %w", err)
+		}
+		shared.ForgetAdminKey(cmd.Context(), oldPrefix)
+		cliLog().WithFields(logrus.Fields{
+			"event":  "cli_rotate_api_key",
+			"status": "success",
+			"key_id": key.ID,
+			"name":   key.Name,
+			"scopes": key.Scopes,
+		}).Info("API key rotated from the CLI")
+		fmt.Println(raw)
+		return nil
+	},
+}
+
+func init() {
+	keysCmd.AddCommand(keysRotateCmd)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply, revert or inspect database migrations",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply every pending migration",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := connectDB(); err != nil {
+			return err
+		}
+		applied, err := models.MigrateUp(models.DB)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return nil
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down [steps]",
+	Short: "Revert the last applied migrations (one by default)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		steps := 1
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step count %q", args[0])
+			}
+			steps = n
+		}
+		if err := connectDB(); err != nil {
+			return err
+		}
+		reverted, err := models.MigrateDown(models.DB, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("nothing to revert")
+		}
+		return nil
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List migrations and whether they have been applied",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := connectDB(); err != nil {
+			return err
+		}
+		states, err := models.MigrationStatus(models.DB)
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, applied)
+		}
+		return nil
+	},
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd)
+}
+
+// connectDB opens the database without checking or changing its schema.
+func connectDB() error {
+	if err := loadConfig(); err != nil {
+		return err
+	}
+	models.Connect(values.GetConfig())
+	return nil
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/cache"
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/audit"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/intraware/rodan-authify/internal/utils/webhook"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var configFile string
+
+var rootCmd = &cobra.Command{
+	Use:           "rodan-authify",
+	Short:         "Authentication and team management for CTF events",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServer()
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", os.Getenv("CONFIG_FILE"), "path to the TOML config file (defaults to $CONFIG_FILE)")
+	rootCmd.AddCommand(serveCmd, migrateCmd, configCmd, userCmd, teamCmd, importCmd, exportCmd, keysCmd)
+}
+
+// Execute runs the command named on the command line; with none it serves.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func loadConfig() error {
+	if err := values.InitWithViper(configFile); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	return nil
+}
+
+// openApp prepares what an operator command needs to change state the way
+// the API does: a migrated database, the caches, event delivery and the audit
+// log. Running servers only see cache invalidations when Redis is configured;
+// with the in-app cache they keep serving their copies until those expire,
+// a few minutes at most. The returned function flushes the audit log and must
+// be called before exiting.
+func openApp() (func(), error) {
+	if err := loadConfig(); err != nil {
+		return nil, err
+	}
+	cfg := values.GetConfig()
+	utils.NewLogger(cfg.Server.Production)
+	models.Init(cfg)
+	ctx, stopAudit := context.WithCancel(context.Background())
+	auditHook := audit.NewHook(64)
+	utils.Logger.AddHook(auditHook)
+	auditDone := make(chan struct{})
+	go func() {
+		auditHook.Run(ctx)
+		close(auditDone)
+	}()
+	if !cfg.App.AppCache.InApp {
+		cache.InitRedis(context.Background())
+	} else {
+		fmt.Fprintln(os.Stderr, "note: the in-app cache is configured, so running servers may serve stale entries for a few minutes")
+	}
+	shared.Init(&cfg.App)
+	events.Subscribe(events.Fanout)
+	webhookCtx, stopWebhooks := context.WithCancel(context.Background())
+	webhookDone := make(chan struct{})
+	if cfg.App.Webhooks.Enabled {
+		events.Subscribe(webhook.Enqueue)
+		go func() {
+			webhook.RunQueue(webhookCtx)
+			close(webhookDone)
+		}()
+	} else {
+		close(webhookDone)
+	}
+	return func() {
+		stopWebhooks()
+		<-webhookDone
+		stopAudit()
+		<-auditDone
+		cache.Close()
+		if sqlDB, err := models.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}, nil
+}
+
+// cliLog returns an audit entry attributed to the operator running the
+// command.
+func cliLog() *logrus.Entry {
+	actor := "cli"
+	if u, err := user.Current(); err == nil {
+		actor = "cli:" + u.Username
+	}
+	return utils.Logger.WithFields(logrus.Fields{
+		"type":  "audit",
+		"actor": actor,
+		"ip":    "local",
+	})
+}
+
+// findUser resolves a user given by ID or username.
+func findUser(ref string) (models.User, error) {
+	var user models.User
+	query := models.DB.Where("username = ?", ref)
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		query = models.DB.Where("id = ? OR username = ?", id, ref)
+	}
+	if err := query.First(&user).Error; err != nil {
+		return user, fmt.Errorf("user %q: %w", ref, err)
+	}
+	return user, nil
+}
+
+// findTeam resolves a team given by ID, code or name.
+func findTeam(ref string) (models.Team, error) {
+	var team models.Team
+	query := models.DB.Where("code = ? OR name = ?", ref, ref)
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		query = models.DB.Where("id = ? OR code = ? OR name = ?", id, ref, ref)
+	}
+	if err := query.Preload("Members").First(&team).Error; err != nil {
+		return team, fmt.Errorf("team %q: %w", ref, err)
+	}
+	return team, nil
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/cache"
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/lifecycle"
+	"github.com/intraware/rodan-authify/internal/metrics"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/tracing"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/audit"
+	"github.com/intraware/rodan-authify/internal/utils/middleware"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/intraware/rodan-authify/internal/utils/webhook"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the HTTP server",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runServer()
+	},
+}
+
+func runServer() error {
+	if err := loadConfig(); err != nil {
+		return err
+	}
+	cfg := values.GetConfig()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	utils.NewLogger(cfg.Server.Production)
+	shutdownTracing := func(context.Context) error { return nil }
+	if cfg.Server.Tracing.Enabled {
+		var err error
+		shutdownTracing, err = tracing.Init(ctx, cfg.Server.Tracing)
+		if err != nil {
+			log.Fatalf("Failed to init tracing: %v", err)
+		}
+	}
+	models.Init(cfg)
+	sqlDB, err := models.DB.DB()
+	if err != nil {
+		log.Fatalf("Failed to get database handle: %v", err)
+	}
+	// The audit hook outlives the workers so that whatever they log while
+	// stopping is still persisted.
+	auditCtx, stopAudit := context.WithCancel(context.Background())
+	auditHook := audit.NewHook(1024)
+	utils.Logger.AddHook(auditHook)
+	auditDone := make(chan struct{})
+	go func() {
+		auditHook.Run(auditCtx)
+		close(auditDone)
+	}()
+	if cfg.Server.Production {
+		gin.SetMode(gin.ReleaseMode)
+	} else {
+		gin.SetMode(gin.DebugMode)
+	}
+	r := gin.New()
+	// handlers pass the gin context to GORM and the caches, which then find
+	// the request's span through it
+	r.ContextWithFallback = true
+	if cfg.Server.Tracing.Enabled {
+		r.Use(otelgin.Middleware(cfg.Server.Tracing.ServiceName))
+	}
+	r.Use(middleware.RequestID)
+	if cfg.Server.Metrics.Enabled {
+		metrics.Register(sqlDB)
+		utils.Logger.AddHook(metrics.AuditHook{})
+		r.Use(middleware.Metrics)
+		r.GET("/metrics", middleware.MetricsHandler())
+	}
+	r.Use(middleware.Logger())
+	r.Use(middleware.CORS(&cfg.Server))
+	r.Use(gin.Recovery())
+	if !cfg.App.AppCache.InApp {
+		cache.InitRedis(context.Background())
+	}
+	api.LoadRoutes(r)
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	lifecycle.Go(func() { shared.WatchFlags(workerCtx, time.Minute) })
+	lifecycle.Go(func() { shared.WatchSchedule(workerCtx, time.Minute) })
+	lifecycle.Go(func() { shared.WatchInvalidations(workerCtx) })
+	lifecycle.Go(func() { shared.RunScheduler(workerCtx, time.Second) })
+	lifecycle.Go(func() { shared.RunDeletionPurge(workerCtx, 10*time.Minute) })
+	events.Subscribe(events.Fanout)
+	lifecycle.Go(func() { events.RunFanout(workerCtx) })
+	if cfg.App.Webhooks.Enabled {
+		events.Subscribe(webhook.Enqueue)
+		lifecycle.Go(func() { webhook.RunQueue(workerCtx) })
+		lifecycle.Go(func() { webhook.RunWorker(workerCtx, 5*time.Second) })
+	}
+	srv := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler: r,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+	fmt.Printf("[ENGINE] Server started at %s:%d\n", cfg.Server.Host, cfg.Server.Port)
+
+	<-ctx.Done()
+	stop()
+	fmt.Println("[ENGINE] Shutting down")
+	lifecycle.BeginShutdown()
+	// /readyz now fails; give load balancers time to notice before new
+	// connections are refused
+	if cfg.Server.DrainDelay > 0 {
+		time.Sleep(cfg.Server.DrainDelay)
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		utils.Logger.Errorf("Failed to drain in-flight requests: %v", err)
+	}
+	stopWorkers()
+	if err := lifecycle.Wait(shutdownCtx); err != nil {
+		utils.Logger.Errorf("Background work did not finish before shutdown: %v", err)
+	}
+	stopAudit()
+	select {
+	case <-auditDone:
+	case <-shutdownCtx.Done():
+		fmt.Fprintln(os.Stderr, "[AUDIT] shutdown timed out before queued events were stored")
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		fmt.Fprintf(os.Stderr, "[ENGINE] failed to flush traces: %v\n", err)
+	}
+	if err := cache.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "[ENGINE] failed to close redis: %v\n", err)
+	}
+	if err := sqlDB.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "[ENGINE] failed to close database: %v\n", err)
+	}
+	fmt.Println("[ENGINE] Server stopped")
+	return nil
+}
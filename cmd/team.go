@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var teamCmd = &cobra.Command{
+	Use:   "team",
+	Short: "Manage teams",
+}
+
+var teamCreateOpts struct {
+	name   string
+	leader string
+}
+
+var teamCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a team led by an existing user who is not in a team",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		closeApp, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer closeApp()
+		leader, err := findUser(teamCreateOpts.leader)
+		if err != nil {
+			return err
+		}
+		if leader.TeamID != nil {
+			return fmt.Errorf("%s is already in team %d", leader.Username, *leader.TeamID)
+		}
+		team := models.Team{Name: teamCreateOpts.name, LeaderID: leader.ID}
+		err = models.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&team).Error; err != nil {
+				return err
+			}
+			return tx.Model(&leader).Update("team_id", team.ID).Error
+		})
+		if err != nil {
+			cliLog().WithFields(logrus.Fields{
+				"event":     "cli_team_create",
+				"status":    "failure",
+				"reason":    "db_error",
+				"user_id":   leader.ID,
+				"team_name": teamCreateOpts.name,
+				"error":     err.Error(),
+			}).Error("Failed to create team")
+			return fmt.Errorf("failed to create team: %w", err)
+		}
+		shared.ForgetUser(cmd.Context(), leader.ID)
+		cliLog().WithFields(logrus.Fields{
+			"event":     "cli_team_create",
+			"status":    "success",
+			"user_id":   leader.ID,
+			"team_id":   team.ID,
+			"team_name": team.Name,
+		}).Info("Team created from the CLI")
+		events.Publish(events.TeamCreated, leader.ID, team.ID, map[string]any{"name": team.Name, "leader": leader.Username})
+		fmt.Printf("created team %d (%s), code %s\n", team.ID, team.Name, team.Code)
+		return nil
+	},
+}
+
+var teamMoveForce bool
+
+var teamMoveMemberCmd = &cobra.Command{
+	Use:   "move-member <user> <team>",
+	Short: "Move a user into a team, taking them out of their current one",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		closeApp, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer closeApp()
+		user, err := findUser(args[0])
+		if err != nil {
+			return err
+		}
+		team, err := findTeam(args[1])
+		if err != nil {
+			return err
+		}
+		if user.TeamID != nil && *user.TeamID == team.ID {
+			return fmt.Errorf("%s is already in %s", user.Username, team.Name)
+		}
+		var oldTeam models.Team
+		if user.TeamID != nil {
+			if err := models.DB.First(&oldTeam, *user.TeamID).Error; err != nil {
+				return fmt.Errorf("failed to load current team: %w", err)
+			}
+			if oldTeam.LeaderID == user.ID {
+				return fmt.Errorf("%s leads %s; transfer leadership or delete that team first", user.Username, oldTeam.Name)
+			}
+		}
+		division, err := models.TeamDivision(models.DB, &team)
+		if err != nil {
+			return fmt.Errorf("failed to load the team's division: %w", err)
+		}
+		if size := division.MaxTeamSize(); len(team.Members) >= size && !teamMoveForce {
+			return fmt.Errorf("%s already has %d of %d members; use --force to exceed the limit", team.Name, len(team.Members), size)
+		}
+		if err := division.Admits(models.DB, &user); err != nil && !teamMoveForce {
+			return fmt.Errorf("%s cannot join the %s division (%w); use --force to move them anyway", user.Username, division.Name, err)
+		}
+		if shared.RosterFrozen() && !teamMoveForce {
+			_, err := models.FindRosterException(models.DB, user.ID, &team.ID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("team rosters are frozen and no exception covers %s; use --force to move them anyway", user.Username)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to check roster exceptions: %w", err)
+			}
+		}
+		// the session's token carries the old team, so it is revoked
+		if err := models.DB.Model(&user).Updates(map[string]any{
+			"team_id":       team.ID,
+			"team_role":     models.TeamRoleMember,
+			"token_version": gorm.Expr("token_version + 1"),
+		}).Error; err != nil {
+			cliLog().WithFields(logrus.Fields{
+				"event":   "cli_team_move_member",
+				"status":  "failure",
+				"reason":  "db_error",
+				"user_id": user.ID,
+				"team_id": team.ID,
+				"error":   err.Error(),
+			}).Error("Failed to move team member")
+			return fmt.Errorf("failed to move member: %w", err)
+		}
+		shared.ForgetUser(cmd.Context(), user.ID)
+		shared.ForgetLogin(cmd.Context(), user.Username)
+		shared.ForgetTeam(cmd.Context(), team.ID)
+		if oldTeam.ID != 0 {
+			shared.ForgetTeam(cmd.Context(), oldTeam.ID)
+			events.Publish(events.TeamMemberLeft, user.ID, oldTeam.ID, map[string]any{"username": user.Username, "via": "cli"})
+		}
+		cliLog().WithFields(logrus.Fields{
+			"event":       "cli_team_move_member",
+			"status":      "success",
+			"user_id":     user.ID,
+			"username":    user.Username,
+			"team_id":     team.ID,
+			"old_team_id": oldTeam.ID,
+			"forced":      teamMoveForce,
+		}).Info("Team member moved from the CLI")
+		events.Publish(events.TeamMemberJoined, user.ID, team.ID, map[string]any{"username": user.Username, "via": "cli"})
+		fmt.Printf("moved %s to %s\n", user.Username, team.Name)
+		return nil
+	},
+}
+
+func init() {
+	teamCreateCmd.Flags().StringVar(&teamCreateOpts.name, "name", "", "team name (required)")
+	teamCreateCmd.Flags().StringVar(&teamCreateOpts.leader, "leader", "", "leader's ID or username (required)")
+	teamCreateCmd.MarkFlagRequired("name")
+	teamCreateCmd.MarkFlagRequired("leader")
+
+	teamMoveMemberCmd.Flags().BoolVar(&teamMoveForce, "force", false, "move even if the team is full, the member does not meet its division rules or rosters are frozen")
+
+	teamCmd.AddCommand(teamCreateCmd, teamMoveMemberCmd)
+}
@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+const exportVersion = 1
+
+// exportFile is the portable form of users and teams. Teams and users refer
+// to each other by code and username rather than by database ID, so a file
+// can be imported into a fresh database.
+type exportFile struct {
+	Version    int          `json:"version"`
+	ExportedAt time.Time    `json:"exported_at"`
+	Users      []exportUser `json:"users"`
+	Teams      []exportTeam `json:"teams"`
+}
+
+type exportUser struct {
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	PasswordHash string `json:"password_hash"`
+	AvatarURL    string `json:"avatar_url"`
+	Role         string `json:"role"`
+	Active       bool   `json:"active"`
+	Ban          bool   `json:"ban"`
+	Blacklist    bool   `json:"blacklist"`
+	Team         string `json:"team,omitempty"` // team code
+	TeamRole     string `json:"team_role,omitempty"`
+	models.Profile
+}
+
+type exportTeam struct {
+	Name       string `json:"name"`
+	Code       string `json:"code"`
+	Leader     string `json:"leader"` // username
+	JoinPolicy string `json:"join_policy,omitempty"`
+	Locked     bool   `json:"locked"`
+	Division   string `json:"division,omitempty"` // division name
+	Ban        bool   `json:"ban"`
+	Blacklist  bool   `json:"blacklist"`
+	models.Profile
+}
+
+var exportOutput string
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write all users and teams, including password hashes, as JSON",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		closeApp, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer closeApp()
+		var users []models.User
+		if err := models.DB.Order("id").Find(&users).Error; err != nil {
+			return fmt.Errorf("failed to load users: %w", err)
+		}
+		var teams []models.Team
+		if err := models.DB.Order("id").Find(&teams).Error; err != nil {
+			return fmt.Errorf("failed to load teams: %w", err)
+		}
+		var divisions []models.Division
+		if err := models.DB.Find(&divisions).Error; err != nil {
+			return fmt.Errorf("failed to load divisions: %w", err)
+		}
+		divisionNames := make(map[uint]string, len(divisions))
+		for _, d := range divisions {
+			divisionNames[d.ID] = d.Name
+		}
+		codes := make(map[uint]string, len(teams))
+		for _, t := range teams {
+			codes[t.ID] = t.Code
+		}
+		usernames := make(map[uint]string, len(users))
+		file := exportFile{Version: exportVersion, ExportedAt: time.Now().UTC()}
+		for _, u := range users {
+			usernames[u.ID] = u.Username
+			entry := exportUser{
+				Username:     u.Username,
+				Email:        u.Email,
+				PasswordHash: u.Password,
+				AvatarURL:    u.AvatarURL,
+				Role:         u.Role,
+				Active:       u.Active,
+				Ban:          u.Ban,
+				Blacklist:    u.Blacklist,
+				Profile:      u.Profile,
+			}
+			if u.TeamID != nil {
+				entry.Team = codes[*u.TeamID]
+				entry.TeamRole = u.TeamRole
+			}
+			file.Users = append(file.Users, entry)
+		}
+		for _, t := range teams {
+			entry := exportTeam{
+				Name:       t.Name,
+				Code:       t.Code,
+				Leader:     usernames[t.LeaderID],
+				JoinPolicy: t.JoinPolicy,
+				Locked:     t.Locked,
+				Ban:        t.Ban,
+				Blacklist:  t.Blacklist,
+				Profile:    t.Profile,
+			}
+			if t.DivisionID != nil {
+				entry.Division = divisionNames[*t.DivisionID]
+			}
+			file.Teams = append(file.Teams, entry)
+		}
+		out := io.Writer(os.Stdout)
+		if exportOutput != "" && exportOutput != "-" {
+			f, err := os.OpenFile(exportOutput, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		cliLog().WithFields(logrus.Fields{
+			"event":  "cli_export",
+			"status": "success",
+			"users":  len(file.Users),
+			"teams":  len(file.Teams),
+		}).Info("Users and teams exported from the CLI")
+		fmt.Fprintf(os.Stderr, "exported %d users and %d teams\n", len(file.Users), len(file.Teams))
+		return nil
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Create the users and teams from an export file, skipping ones that already exist",
+	Long: "Create the users and teams from a file written by export (use - for stdin). " +
+		"Users that match an existing username or email and teams that match an " +
+		"existing code are left untouched. The import runs in one transaction.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in := io.Reader(os.Stdin)
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+		var file exportFile
+		if err := json.NewDecoder(in).Decode(&file); err != nil {
+			return fmt.Errorf("failed to parse import file: %w", err)
+		}
+		if file.Version != exportVersion {
+			return fmt.Errorf("unsupported export version %d", file.Version)
+		}
+		closeApp, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer closeApp()
+		var usersCreated, teamsCreated int
+		err = models.DB.Transaction(func(tx *gorm.DB) error {
+			// hooks would re-hash the password and replace the team code
+			raw := tx.Session(&gorm.Session{SkipHooks: true})
+			userIDs := map[string]uint{}
+			for _, u := range file.Users {
+				if !models.ValidRole(u.Role) {
+					return fmt.Errorf("user %s has unknown role %q", u.Username, u.Role)
+				}
+				var existing models.User
+				err := tx.Where("username = ? OR email = ?", u.Username, u.Email).First(&existing).Error
+				if err == nil {
+					userIDs[u.Username] = existing.ID
+					continue
+				}
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				if models.ReservedUsername(u.Username) {
+					return fmt.Errorf("user %s: %w", u.Username, models.ErrUsernameReserved)
+				}
+				user := models.User{
+					Username:  u.Username,
+					Email:     u.Email,
+					Password:  u.PasswordHash,
+					AvatarURL: u.AvatarURL,
+					Role:      u.Role,
+					Active:    u.Active,
+					Ban:       u.Ban,
+					Blacklist: u.Blacklist,
+					Profile:   u.Profile,
+				}
+				if err := raw.Create(&user).Error; err != nil {
+					return fmt.Errorf("user %s: %w", u.Username, err)
+				}
+				userIDs[u.Username] = user.ID
+				usersCreated++
+			}
+			teamIDs := map[string]uint{}
+			for _, t := range file.Teams {
+				var existing models.Team
+				err := tx.Where("code = ?", t.Code).First(&existing).Error
+				if err == nil {
+					teamIDs[t.Code] = existing.ID
+					continue
+				}
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				leaderID, ok := userIDs[t.Leader]
+				if !ok {
+					return fmt.Errorf("team %s: leader %q is not in the file", t.Name, t.Leader)
+				}
+				if t.JoinPolicy == "" {
+					t.JoinPolicy = models.JoinPolicyCode
+				}
+				if !models.ValidJoinPolicy(t.JoinPolicy) {
+					return fmt.Errorf("team %s has unknown join policy %q", t.Name, t.JoinPolicy)
+				}
+				team := models.Team{
+					Name:       t.Name,
+					Code:       t.Code,
+					LeaderID:   leaderID,
+					JoinPolicy: t.JoinPolicy,
+					Locked:     t.Locked,
+					Ban:        t.Ban,
+					Blacklist:  t.Blacklist,
+					Profile:    t.Profile,
+				}
+				if t.Division != "" {
+					var division models.Division
+					if err := tx.Where("name = ?", t.Division).First(&division).Error; err != nil {
+						return fmt.Errorf("team %s: division %q does not exist; create it first", t.Name, t.Division)
+					}
+					team.DivisionID = &division.ID
+				}
+				if err := raw.Create(&team).Error; err != nil {
+					return fmt.Errorf("team %s: %w", t.Name, err)
+				}
+				teamIDs[t.Code] = team.ID
+				teamsCreated++
+			}
+			for _, u := range file.Users {
+				if u.Team == "" {
+					continue
+				}
+				teamID, ok := teamIDs[u.Team]
+				if !ok {
+					return fmt.Errorf("user %s: team %q is not in the file", u.Username, u.Team)
+				}
+				role := models.TeamRoleMember
+				if models.ValidTeamRole(u.TeamRole) {
+					role = u.TeamRole
+				}
+				if err := tx.Model(&models.User{}).
+					Where("id = ? AND team_id IS NULL", userIDs[u.Username]).
+					Updates(map[string]any{"team_id": teamID, "team_role": role}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			cliLog().WithFields(logrus.Fields{
+				"event":  "cli_import",
+				"status": "failure",
+				"reason": "import_failed",
+				"error":  err.Error(),
+			}).Error("Import failed")
+			return fmt.Errorf("import failed, nothing was changed: %w", err)
+		}
+		// memberships of existing users may have changed
+		shared.UserCache.Reset()
+		shared.TeamCache.Reset()
+		cliLog().WithFields(logrus.Fields{
+			"event":         "cli_import",
+			"status":        "success",
+			"users_created": usersCreated,
+			"teams_created": teamsCreated,
+		}).Info("Users and teams imported from the CLI")
+		fmt.Printf("created %d users and %d teams\n", usersCreated, teamsCreated)
+		return nil
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "-", "file to write, - for stdout")
+}
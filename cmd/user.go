@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage user accounts",
+}
+
+var userCreateOpts struct {
+	username      string
+	email         string
+	passwordStdin bool
+	avatarURL     string
+	role          string
+}
+
+var userCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an active account; a random password is printed when none is given",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := userCreateOpts
+		if !models.ValidRole(opts.role) {
+			return fmt.Errorf("unknown role %q", opts.role)
+		}
+		if models.ReservedUsername(opts.username) {
+			return models.ErrUsernameReserved
+		}
+		// a password on the command line would end up in the shell history
+		// and the process list
+		var password string
+		if opts.passwordStdin {
+			line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("failed to read the password: %w", err)
+			}
+			password = strings.TrimRight(line, "\r\n")
+			if password == "" {
+				return fmt.Errorf("no password on stdin")
+			}
+		}
+		generated := password == ""
+		if generated {
+			raw := make([]byte, 12)
+			if _, err := rand.Read(raw); err != nil {
+				return err
+			}
+			password = base64.RawURLEncoding.EncodeToString(raw)
+		}
+		closeApp, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer closeApp()
+		user := models.User{
+			Username:  opts.username,
+			Email:     opts.email,
+			Password:  password,
+			AvatarURL: opts.avatarURL,
+			Role:      opts.role,
+			Active:    true,
+		}
+		if err := models.DB.Create(&user).Error; err != nil {
+			cliLog().WithFields(logrus.Fields{
+				"event":    "cli_user_create",
+				"status":   "failure",
+				"reason":   "db_error",
+				"username": opts.username,
+				"error":    err.Error(),
+			}).Error("Failed to create user")
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		cliLog().WithFields(logrus.Fields{
+			"event":    "cli_user_create",
+			"status":   "success",
+			"user_id":  user.ID,
+			"username": user.Username,
+			"role":     user.Role,
+		}).Info("User created from the CLI")
+		events.Publish(events.UserSignedUp, user.ID, 0, map[string]any{"username": user.Username, "via": "cli"})
+		fmt.Printf("created user %d (%s)\n", user.ID, user.Username)
+		if generated {
+			fmt.Printf("password: %s\n", password)
+		}
+		return nil
+	},
+}
+
+var userBanOpts struct {
+	reason   string
+	duration time.Duration
+}
+
+var userBanCmd = &cobra.Command{
+	Use:   "ban <user>",
+	Short: "Ban a user by ID or username and revoke their sessions",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		closeApp, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer closeApp()
+		user, err := findUser(args[0])
+		if err != nil {
+			return err
+		}
+		ban, err := shared.BanUser(cmd.Context(), user.ID, userBanOpts.reason, userBanOpts.duration)
+		if err != nil {
+			cliLog().WithFields(logrus.Fields{
+				"event":    "cli_user_ban",
+				"status":   "failure",
+				"reason":   "ban_failed",
+				"user_id":  user.ID,
+				"username": user.Username,
+				"error":    err.Error(),
+			}).Error("Failed to ban user")
+			return fmt.Errorf("failed to ban user: %w", err)
+		}
+		expires := time.Unix(ban.ExpiresAt, 0)
+		cliLog().WithFields(logrus.Fields{
+			"event":      "cli_user_ban",
+			"status":     "success",
+			"user_id":    user.ID,
+			"username":   user.Username,
+			"ban_reason": userBanOpts.reason,
+			"expires_at": expires.UTC(),
+		}).Info("User banned from the CLI")
+		fmt.Printf("banned %s until %s\n", user.Username, expires.Format(time.RFC3339))
+		return nil
+	},
+}
+
+var userResetTOTPCmd = &cobra.Command{
+	Use:   "reset-totp <user>",
+	Short: "Remove a user's TOTP secret so they can enrol again",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		closeApp, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer closeApp()
+		user, err := findUser(args[0])
+		if err != nil {
+			return err
+		}
+		result := models.DB.Unscoped().Where("user_id = ?", user.ID).Delete(&models.UserTOTPMeta{})
+		if result.Error != nil {
+			cliLog().WithFields(logrus.Fields{
+				"event":    "cli_reset_totp",
+				"status":   "failure",
+				"reason":   "db_error",
+				"user_id":  user.ID,
+				"username": user.Username,
+				"error":    result.Error.Error(),
+			}).Error("Failed to reset TOTP")
+			return fmt.Errorf("failed to reset TOTP: %w", result.Error)
+		}
+		shared.ForgetTOTP(cmd.Context(), user.Username)
+		cliLog().WithFields(logrus.Fields{
+			"event":    "cli_reset_totp",
+			"status":   "success",
+			"user_id":  user.ID,
+			"username": user.Username,
+		}).Info("TOTP reset from the CLI")
+		if result.RowsAffected == 0 {
+			fmt.Printf("%s had no TOTP secret\n", user.Username)
+		} else {
+			fmt.Printf("reset TOTP for %s\n", user.Username)
+		}
+		return nil
+	},
+}
+
+func init() {
+	flags := userCreateCmd.Flags()
+	flags.StringVar(&userCreateOpts.username, "username", "", "username (required)")
+	flags.StringVar(&userCreateOpts.email, "email", "", "email address (required)")
+	flags.BoolVar(&userCreateOpts.passwordStdin, "password-stdin", false, "read the password from the first line of stdin; generated and printed otherwise")
+	flags.StringVar(&userCreateOpts.avatarURL, "avatar-url", "", "avatar URL (required)")
+	flags.StringVar(&userCreateOpts.role, "role", models.RolePlayer, "role: admin, organiser, support or player")
+	userCreateCmd.MarkFlagRequired("username")
+	userCreateCmd.MarkFlagRequired("email")
+	userCreateCmd.MarkFlagRequired("avatar-url")
+
+	userBanCmd.Flags().StringVar(&userBanOpts.reason, "reason", "", "reason recorded with the ban")
+	userBanCmd.Flags().DurationVar(&userBanOpts.duration, "duration", 0, "ban length; escalates with earlier bans when 0")
+
+	userCmd.AddCommand(userCreateCmd, userBanCmd, userResetTOTPCmd)
+}
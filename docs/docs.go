@@ -15,9 +15,35 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/auth/challenge": {
+            "get": {
+                "description": "Issues a single-use puzzle required by signup, login and forgot password.\nFind a nonce such that sha256(challenge + nonce) starts with ` + "`" + `difficulty` + "`" + ` zero bits,\nthen send both in the X-PoW-Challenge and X-PoW-Nonce headers.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Proof-of-work challenge",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.challengeResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/auth/forgot-password": {
             "post": {
-                "description": "Initiates password reset process using OTP or backup code",
+                "description": "Initiates password reset process using email, OTP, or backup code.\nThe email flow answers identically whether or not the account exists.",
                 "consumes": [
                     "application/json"
                 ],
@@ -29,6 +55,17 @@ const docTemplate = `{
                 ],
                 "summary": "Forgot password",
                 "parameters": [
+                    {
+                        "enum": [
+                            "email",
+                            "totp"
+                        ],
+                        "type": "string",
+                        "default": "email",
+                        "description": "Reset method (email or totp)",
+                        "name": "type",
+                        "in": "query"
+                    },
                     {
                         "description": "Forgot password request",
                         "name": "request",
@@ -37,6 +74,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/auth.forgotPasswordRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Solved challenge from /auth/challenge, when proof of work is enabled",
+                        "name": "X-PoW-Challenge",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nonce solving the challenge",
+                        "name": "X-PoW-Nonce",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -58,12 +107,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/types.ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -75,7 +118,7 @@ const docTemplate = `{
         },
         "/auth/login": {
             "post": {
-                "description": "Authenticates a user and returns an access token",
+                "description": "Authenticates a user and returns an access token. Passing a valid TOTP code marks the session as MFA-verified, which staff need for the admin API",
                 "consumes": [
                     "application/json"
                 ],
@@ -95,6 +138,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/auth.loginRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Solved challenge from /auth/challenge, when proof of work is enabled",
+                        "name": "X-PoW-Challenge",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nonce solving the challenge",
+                        "name": "X-PoW-Nonce",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -133,7 +188,7 @@ const docTemplate = `{
         },
         "/auth/reset-password/{token}": {
             "post": {
-                "description": "Resets user password using a valid reset token",
+                "description": "Resets user password using a valid single-use reset token and revokes all sessions",
                 "consumes": [
                     "application/json"
                 ],
@@ -192,7 +247,7 @@ const docTemplate = `{
         },
         "/auth/signup": {
             "post": {
-                "description": "Registers a new user account in the system",
+                "description": "Registers a new user account in the system, optionally redeeming an invite code",
                 "consumes": [
                     "application/json"
                 ],
@@ -212,6 +267,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/auth.signUpRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Solved challenge from /auth/challenge, when proof of work is enabled",
+                        "name": "X-PoW-Challenge",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Nonce solving the challenge",
+                        "name": "X-PoW-Nonce",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -227,6 +294,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
                     "409": {
                         "description": "Conflict",
                         "schema": {
@@ -241,6 +314,93 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/event/status": {
+            "get": {
+                "description": "Returns the current event phase, the state of the signup/login/roster toggles and the schedule",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "event"
+                ],
+                "summary": "Event status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/event.statusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/team/stream": {
+            "get": {
+                "description": "Server-sent events for the caller's team: members joining or leaving, leader changes, renames and join requests, plus the answers to the caller's own join requests. Authenticate with a ticket from POST /team/stream/ticket",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "team"
+                ],
+                "summary": "Team event stream",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Single-use stream ticket",
+                        "name": "ticket",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/events.Event"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/team/stream/ticket": {
+            "post": {
+                "description": "Issues a single-use ticket, valid for 30 seconds, that opens the team event stream. It keeps the session token out of the stream URL",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "team"
+                ],
+                "summary": "Team event stream ticket",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/shared.StreamTicketResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/types.ErrorResponse"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
@@ -256,6 +416,27 @@ const docTemplate = `{
                 }
             }
         },
+        "auth.challengeResponse": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string",
+                    "example": "sha256"
+                },
+                "challenge": {
+                    "type": "string",
+                    "example": "3f2a...e1.18.1760000000.9c4b..."
+                },
+                "difficulty": {
+                    "type": "integer",
+                    "example": 18
+                },
+                "expires_at": {
+                    "type": "integer",
+                    "example": 1760000000
+                }
+            }
+        },
         "auth.forgotPasswordRequest": {
             "type": "object",
             "required": [
@@ -283,6 +464,10 @@ const docTemplate = `{
                 "username"
             ],
             "properties": {
+                "otp": {
+                    "type": "string",
+                    "example": "11223344"
+                },
                 "password": {
                     "type": "string",
                     "example": "mystrongpassword"
@@ -319,7 +504,6 @@ const docTemplate = `{
             "type": "object",
             "required": [
                 "email",
-                "github_username",
                 "password",
                 "username"
             ],
@@ -328,9 +512,9 @@ const docTemplate = `{
                     "type": "string",
                     "example": "example@intraware.org"
                 },
-                "github_username": {
+                "invite_code": {
                     "type": "string",
-                    "example": "intraware"
+                    "example": "9f86d081884c7d65"
                 },
                 "password": {
                     "type": "string",
@@ -346,13 +530,13 @@ const docTemplate = `{
         "auth.userInfo": {
             "type": "object",
             "properties": {
-                "email": {
+                "avatar_url": {
                     "type": "string",
-                    "example": "example@intraware.org"
+                    "example": "https://..."
                 },
-                "github_username": {
+                "email": {
                     "type": "string",
-                    "example": "intraware"
+                    "example": "example@intraware.org"
                 },
                 "id": {
                     "type": "integer",
@@ -368,6 +552,108 @@ const docTemplate = `{
                 }
             }
         },
+        "event.statusResponse": {
+            "type": "object",
+            "properties": {
+                "login_open": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "phase": {
+                    "type": "string",
+                    "enum": [
+                        "upcoming",
+                        "registration",
+                        "running",
+                        "frozen",
+                        "ended"
+                    ],
+                    "example": "running"
+                },
+                "roster_frozen": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "schedule": {
+                    "$ref": "#/definitions/shared.EventSchedule"
+                },
+                "server_time": {
+                    "type": "string",
+                    "example": "2026-11-07T12:00:00Z"
+                },
+                "signup_open": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "events.Event": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "id": {
+                    "type": "string"
+                },
+                "team_id": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "shared.EventSchedule": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string",
+                    "example": "2026-11-09T00:00:00Z"
+                },
+                "login_close": {
+                    "type": "string",
+                    "example": "2026-11-10T00:00:00Z"
+                },
+                "login_open": {
+                    "type": "string",
+                    "example": "2026-11-07T00:00:00Z"
+                },
+                "roster_freeze": {
+                    "type": "string",
+                    "example": "2026-11-07T00:00:00Z"
+                },
+                "signup_close": {
+                    "type": "string",
+                    "example": "2026-11-08T00:00:00Z"
+                },
+                "signup_open": {
+                    "type": "string",
+                    "example": "2026-11-01T00:00:00Z"
+                }
+            }
+        },
+        "shared.StreamTicketResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "seconds",
+                    "type": "integer",
+                    "example": 30
+                },
+                "ticket": {
+                    "type": "string",
+                    "example": "5d41402abc4b2a76b9719d911017c592..."
+                }
+            }
+        },
         "types.ErrorResponse": {
             "type": "object",
             "properties": {
@@ -1,16 +1,23 @@
 package cache
 
 import (
+	"context"
 	"time"
 
 	"github.com/intraware/rodan-authify/internal/config"
 	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+// Cache is a typed key-value cache. The context carries the trace of the
+// request a call is made for; the in-app cache ignores it.
 type Cache[K comparable, V any] interface {
-	Get(key K) (V, bool)
-	Set(key K, value V)
-	Delete(key K)
+	Get(ctx context.Context, key K) (V, bool)
+	// Take gets the value and removes it in one step, so that of several
+	// concurrent callers only one gets it.
+	Take(ctx context.Context, key K) (V, bool)
+	Set(ctx context.Context, key K, value V)
+	Delete(ctx context.Context, key K)
 	Reset()
 }
 
@@ -23,15 +30,55 @@ type CacheOpts struct {
 
 var cfg *config.CacheConfig = nil
 
+// Requests counts lookups per cache prefix; it is registered by the metrics
+// package.
+var Requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "rodan",
+	Name:      "cache_requests_total",
+	Help:      "Cache lookups by cache prefix and result.",
+}, []string{"cache", "result"})
+
+type countingCache[K comparable, V any] struct {
+	Cache[K, V]
+	hits   prometheus.Counter
+	misses prometheus.Counter
+}
+
+func (c *countingCache[K, V]) Get(ctx context.Context, key K) (V, bool) {
+	val, ok := c.Cache.Get(ctx, key)
+	if ok {
+		c.hits.Inc()
+	} else {
+		c.misses.Inc()
+	}
+	return val, ok
+}
+
+func (c *countingCache[K, V]) Take(ctx context.Context, key K) (V, bool) {
+	val, ok := c.Cache.Take(ctx, key)
+	if ok {
+		c.hits.Inc()
+	} else {
+		c.misses.Inc()
+	}
+	return val, ok
+}
+
 func NewCache[K comparable, V any](opts *CacheOpts) Cache[K, V] {
 	if cfg == nil {
 		cfg = &values.GetConfig().App.AppCache
 	}
+	var inner Cache[K, V]
 	if cfg.InApp {
-		return newAppCache[K, V](opts)
+		inner = newAppCache[K, V](opts)
 	} else if cfg.ServiceType == "redis" {
-		return newRedisCache[K, V](opts)
+		inner = newRedisCache[K, V](opts)
 	} else {
-		return newAppCache[K, V](opts)
+		inner = newAppCache[K, V](opts)
+	}
+	return &countingCache[K, V]{
+		Cache:  inner,
+		hits:   Requests.WithLabelValues(opts.Prefix, "hit"),
+		misses: Requests.WithLabelValues(opts.Prefix, "miss"),
 	}
 }
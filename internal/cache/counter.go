@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/redis/go-redis/v9"
+)
+
+// Counter counts hits per key. A key's count lapses once it has not been
+// hit for the TTL of the options.
+type Counter[K comparable] interface {
+	// Incr adds one to the key's count and returns the new count.
+	Incr(ctx context.Context, key K) int
+}
+
+func NewCounter[K comparable](opts *CacheOpts) Counter[K] {
+	if cfg == nil {
+		cfg = &values.GetConfig().App.AppCache
+	}
+	if !cfg.InApp && cfg.ServiceType == "redis" {
+		return &redisCounter[K]{client: redisObj, opts: opts}
+	}
+	return &appCounter[K]{cache: newAppCache[K, int](opts)}
+}
+
+type appCounter[K comparable] struct {
+	mu    sync.Mutex
+	cache Cache[K, int]
+}
+
+func (c *appCounter[K]) Incr(ctx context.Context, key K) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count, _ := c.cache.Get(ctx, key)
+	count++
+	c.cache.Set(ctx, key, count)
+	return count
+}
+
+type redisCounter[K comparable] struct {
+	client RedisClient
+	opts   *CacheOpts
+}
+
+func (r *redisCounter[K]) Incr(ctx context.Context, key K) int {
+	keyStr := fmt.Sprintf("%s_%v", r.opts.Prefix, key)
+	var incr *redis.IntCmd
+	_, err := r.client.ring.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, keyStr)
+		pipe.Expire(ctx, keyStr, r.opts.TimeToLive)
+		return nil
+	})
+	if err != nil {
+		// count as the first hit rather than fail the request
+		return 1
+	}
+	return int(incr.Val())
+}
@@ -1,9 +1,17 @@
 package cache
 
 import (
+	"context"
+	"sync"
+
 	"github.com/AnimeKaizoku/cacher"
 )
 
+type appCache[K comparable, V any] struct {
+	cacher *cacher.Cacher[K, V]
+	mu     sync.Mutex // serialises Take
+}
+
 func newAppCache[K comparable, V any](opts *CacheOpts) Cache[K, V] {
 	newOpts := &cacher.NewCacherOpts{
 		TimeToLive:  opts.TimeToLive,
@@ -15,5 +23,31 @@ func newAppCache[K comparable, V any](opts *CacheOpts) Cache[K, V] {
 	if opts.Revaluate != nil {
 		newOpts.Revaluate = *opts.Revaluate
 	}
-	return cacher.NewCacher[K, V](newOpts)
+	return &appCache[K, V]{cacher: cacher.NewCacher[K, V](newOpts)}
+}
+
+func (c *appCache[K, V]) Get(_ context.Context, key K) (V, bool) {
+	return c.cacher.Get(key)
+}
+
+func (c *appCache[K, V]) Take(_ context.Context, key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	val, ok := c.cacher.Get(key)
+	if ok {
+		c.cacher.Delete(key)
+	}
+	return val, ok
+}
+
+func (c *appCache[K, V]) Set(_ context.Context, key K, value V) {
+	c.cacher.Set(key, value)
+}
+
+func (c *appCache[K, V]) Delete(_ context.Context, key K) {
+	c.cacher.Delete(key)
+}
+
+func (c *appCache[K, V]) Reset() {
+	c.cacher.Reset()
 }
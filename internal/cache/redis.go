@@ -6,7 +6,9 @@ import (
 
 	"github.com/intraware/rodan-authify/internal/utils/values"
 	redis_cache "github.com/intraware/rodan-authify/pkg/cache"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
 )
 
 type redisCache[K comparable, V any] struct {
@@ -18,6 +20,8 @@ type redisCache[K comparable, V any] struct {
 
 type RedisClient struct {
 	redis *redis_cache.Cache
+	local *redis_cache.TinyLFU
+	ring  *redis.Ring
 	ctx   context.Context
 }
 
@@ -28,23 +32,95 @@ func InitRedis(ctx context.Context) {
 	ring := redis.NewRing(&redis.RingOptions{
 		Addrs: map[string]string{"redis-server": cacheCfg.ServiceUrl},
 	})
-	internalCache := func() *redis_cache.TinyLFU {
-		if cacheCfg.SkipLocalCache {
-			return nil
+	if values.GetConfig().Server.Tracing.Enabled {
+		if err := redisotel.InstrumentTracing(ring); err != nil {
+			logrus.Errorf("Failed to enable redis tracing: %v", err)
 		}
-		return redis_cache.NewTinyLFU(cacheCfg.InternalCacheSize, cacheCfg.InternalCacheDuration)
 	}
-	redisCache := redis_cache.New(&redis_cache.Options{
-		Redis:      ring,
-		LocalCache: internalCache(),
-	})
+	var local *redis_cache.TinyLFU
+	if !cacheCfg.SkipLocalCache {
+		local = redis_cache.NewTinyLFU(cacheCfg.InternalCacheSize, cacheCfg.InternalCacheDuration)
+	}
+	options := &redis_cache.Options{
+		Redis:        ring,
+		StatsEnabled: true,
+	}
+	if local != nil {
+		// a nil *TinyLFU in the interface would not compare equal to nil
+		options.LocalCache = local
+	}
+	redisCache := redis_cache.New(options)
 	redisTemp := RedisClient{
 		redis: redisCache,
+		local: local,
+		ring:  ring,
 		ctx:   ctx,
 	}
 	redisObj = redisTemp
 }
 
+// RedisEnabled reports whether InitRedis has set up a Redis connection.
+func RedisEnabled() bool {
+	return redisObj.ring != nil
+}
+
+// Ping checks that every Redis shard answers. It returns nil when Redis is
+// not configured.
+func Ping(ctx context.Context) error {
+	if !RedisEnabled() {
+		return nil
+	}
+	return redisObj.ring.ForEachShard(ctx, func(ctx context.Context, client *redis.Client) error {
+		return client.Ping(ctx).Err()
+	})
+}
+
+// Close releases the Redis connections.
+func Close() error {
+	if !RedisEnabled() {
+		return nil
+	}
+	return redisObj.ring.Close()
+}
+
+// RedisStats reports the hits and misses of the Redis-backed cache and the
+// evictions of its in-process layer. ok is false when Redis is not in use.
+func RedisStats() (hits, misses, evictions uint64, ok bool) {
+	if !RedisEnabled() {
+		return
+	}
+	if stats := redisObj.redis.Stats(); stats != nil {
+		hits, misses = stats.Hits, stats.Misses
+	}
+	if redisObj.local != nil {
+		evictions = redisObj.local.Evictions()
+	}
+	return hits, misses, evictions, true
+}
+
+// Publish sends a message to every instance subscribed to the channel. It is
+// a no-op when Redis is not configured.
+func Publish(channel string, payload []byte) error {
+	if !RedisEnabled() {
+		return nil
+	}
+	return redisObj.ring.Publish(redisObj.ctx, channel, payload).Err()
+}
+
+// Subscribe listens on a Redis channel until the context is cancelled. It
+// returns nil when Redis is not configured.
+func Subscribe(ctx context.Context, channel string) <-chan *redis.Message {
+	if !RedisEnabled() {
+		return nil
+	}
+	sub := redisObj.ring.Subscribe(ctx, channel)
+	go func() {
+		<-ctx.Done()
+		sub.Close()
+	}()
+	return sub.Channel()
+}
+
 func newRedisCache[K comparable, V any](opts *CacheOpts) Cache[K, V] {
 	var prefix string
 	var version int
@@ -66,27 +142,40 @@ func newRedisCache[K comparable, V any](opts *CacheOpts) Cache[K, V] {
 	}
 }
 
-func (r *redisCache[K, V]) Get(key K) (val V, exists bool) {
+func (r *redisCache[K, V]) Get(ctx context.Context, key K) (val V, exists bool) {
 	keyStr := fmt.Sprintf("%s_%d_%v", r.prefix, r.version, key)
-	err := r.client.redis.Get(r.client.ctx, keyStr, &val)
-	exists = (err == nil) || (err == redis.Nil)
+	err := r.client.redis.Get(ctx, keyStr, &val)
+	exists = err == nil
+	return
+}
+
+// Take uses GETDEL, so the key is gone for everyone once one caller has it.
+// The in-process layer is bypassed; it could still hold a copy.
+func (r *redisCache[K, V]) Take(ctx context.Context, key K) (val V, exists bool) {
+	keyStr := fmt.Sprintf("%s_%d_%v", r.prefix, r.version, key)
+	r.client.redis.DeleteFromLocalCache(keyStr)
+	b, err := r.client.ring.GetDel(ctx, keyStr).Bytes()
+	if err != nil {
+		return
+	}
+	exists = r.client.redis.Unmarshal(b, &val) == nil
 	return
 }
 
-func (r *redisCache[K, V]) Set(key K, val V) {
+func (r *redisCache[K, V]) Set(ctx context.Context, key K, val V) {
 	keyStr := fmt.Sprintf("%s_%d_%v", r.prefix, r.version, key)
 	r.client.redis.DeleteFromLocalCache(keyStr)
 	r.client.redis.Set(&redis_cache.Item{ // TODO: change the set function to handle the error
-		Ctx:   r.client.ctx,
+		Ctx:   ctx,
 		Key:   keyStr,
 		Value: val,
 		TTL:   r.opts.TimeToLive,
 	})
 }
 
-func (r *redisCache[K, V]) Delete(key K) {
+func (r *redisCache[K, V]) Delete(ctx context.Context, key K) {
 	keyStr := fmt.Sprintf("%s_%d_%v", r.prefix, r.version, key)
-	r.client.redis.Delete(r.client.ctx, keyStr)
+	r.client.redis.Delete(ctx, keyStr)
 }
 
 func (r *redisCache[K, V]) Reset() { // TODO: change the function to handle the errror
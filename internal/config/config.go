@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"regexp"
 	"time"
@@ -19,17 +20,42 @@ type ServerConfig struct {
 	Production bool           `mapstructure:"production" reload:"true"`
 	CORSURL    []string       `mapstructure:"cors-url" reload:"true"`
 	Security   SecurityConfig `mapstructure:"security" reload:"true"`
+	Metrics    MetricsConfig  `mapstructure:"metrics" reload:"true"`
+	Tracing    TracingConfig  `mapstructure:"tracing"`
+	// ShutdownTimeout bounds how long in-flight requests and background
+	// work get to finish after SIGTERM.
+	ShutdownTimeout time.Duration `mapstructure:"shutdown-timeout"`
+	// DrainDelay is how long the server keeps serving after SIGTERM with
+	// /readyz failing, so load balancers stop routing here before the
+	// listener closes.
+	DrainDelay time.Duration `mapstructure:"drain-delay"`
+}
+
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Token   string `mapstructure:"token" reload:"true" secret:"true"` // bearer token required to scrape, if set
+}
+
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Exporter    string  `mapstructure:"exporter"` // otlp or stdout
+	Endpoint    string  `mapstructure:"endpoint"` // OTLP/HTTP collector, host:port
+	Insecure    bool    `mapstructure:"insecure"`
+	ServiceName string  `mapstructure:"service-name"`
+	SampleRatio float64 `mapstructure:"sample-ratio"` // unset or 0 records every trace
 }
 
 type SecurityConfig struct {
-	JWTSecret string `mapstructure:"jwt-secret" reload:"true"`
+	JWTSecret string `mapstructure:"jwt-secret" reload:"true" secret:"true"`
 }
 
 type DatabaseConfig struct {
+	Driver       string `mapstructure:"driver"` // postgres, sqlite or mysql
+	Path         string `mapstructure:"path"`   // database file, sqlite only
 	Host         string `mapstructure:"host"`
 	Port         int    `mapstructure:"port"`
 	Username     string `mapstructure:"username"`
-	Password     string `mapstructure:"password"`
+	Password     string `mapstructure:"password" secret:"true"`
 	DatabaseName string `mapstructure:"database-name"`
 	SSLMode      string `mapstructure:"ssl-mode"`
 	MaxTries     int    `mapstructure:"max-tries"`
@@ -37,26 +63,35 @@ type DatabaseConfig struct {
 
 type AppConfig struct {
 	TokenExpiry       time.Duration  `mapstructure:"token-expiry" reload:"true"`
+	ResetTokenExpiry  time.Duration  `mapstructure:"reset-token-expiry"`
+	DeletionGrace     time.Duration  `mapstructure:"deletion-grace" reload:"true"`
 	TeamSize          int            `mapstructure:"team-size" reload:"true"`
 	EmailRegex        string         `mapstructure:"email-regex" reload:"true"`
 	CompiledEmail     *regexp.Regexp `mapstructure:"-"`
 	AllowLeavingTeam  bool           `mapstructure:"allow-leave-team"`
 	AllowOutsideEmail bool           `mapstructure:"allow-outside-email"`
+	InviteOnly        bool           `mapstructure:"invite-only" reload:"true"`
+	LeaderInvites     bool           `mapstructure:"leader-invites"`
 	EmailsCSV         string         `mapstructure:"emails-csv"`
 	CacheDuration     time.Duration  `mapstructure:"frontend-cache-duration"`
 
-	Email    EmailConfig `mapstructure:"email" reload:"true"`
-	OAuth    OAuthConfig `mapstructure:"oauth" reload:"true"`
-	TOTP     TOTPConfig  `mapstructure:"totp" reload:"true"`
-	Ban      BanConfig   `mapstructure:"ban" reload:"true"`
-	AppCache CacheConfig `mapstructure:"cache"`
-	Admin    AdminConfig `mapstructure:"admin"`
+	Email    EmailConfig   `mapstructure:"email" reload:"true"`
+	OAuth    OAuthConfig   `mapstructure:"oauth" reload:"true"`
+	TOTP     TOTPConfig    `mapstructure:"totp" reload:"true"`
+	Ban      BanConfig     `mapstructure:"ban" reload:"true"`
+	PoW      PoWConfig     `mapstructure:"pow" reload:"true"`
+	Event    EventConfig   `mapstructure:"event" reload:"true"`
+	Webhooks WebhookConfig `mapstructure:"webhooks" reload:"true"`
+	Avatar   AvatarConfig  `mapstructure:"avatar"`
+	AppCache CacheConfig   `mapstructure:"cache"`
+	Admin    AdminConfig   `mapstructure:"admin"`
 }
 
 type AdminConfig struct {
-	Endpoint     string `mapstructure:"endpoint"`
-	APIKey       string `mapstructure:"api-key"`
-	HashedAPIKey string `mapstructure:"-"`
+	Endpoint        string `mapstructure:"endpoint"`
+	APIKey          string `mapstructure:"api-key" secret:"true"` // bootstrap key with every permission; leave empty once named keys exist
+	HashedAPIKey    string `mapstructure:"-"`
+	AllowWithoutMFA bool   `mapstructure:"allow-without-mfa" reload:"true"`
 }
 
 type EmailConfig struct {
@@ -66,6 +101,10 @@ type EmailConfig struct {
 	AllowedEmailCompilexRegex *regexp.Regexp      `mapstructure:"-"`
 	EmailTemplate             string              `mapstructure:"email-template" reload:"true"`
 	EmailSubject              string              `mapstructure:"email-subject" reload:"true"`
+	ResetURL                  string              `mapstructure:"reset-url" reload:"true"`
+	InviteTemplate            string              `mapstructure:"invite-template" reload:"true"`
+	InviteSubject             string              `mapstructure:"invite-subject" reload:"true"`
+	InvitesURL                string              `mapstructure:"invites-url" reload:"true"`
 	Provider                  EmailProviderConfig `mapstructure:"provider" reload:"true"`
 }
 
@@ -74,12 +113,12 @@ type EmailProviderConfig struct {
 	Host     string `mapstructure:"host" reload:"true"`
 	Port     int    `mapstructure:"port" reload:"true"`
 	Username string `mapstructure:"username" reload:"true"`
-	Password string `mapstructure:"password" reload:"true"`
+	Password string `mapstructure:"password" reload:"true" secret:"true"`
 
 	// Microsoft Graph (if used)
 	TenantID     string `mapstructure:"tenant-id" reload:"true"`
 	ClientID     string `mapstructure:"client_id" reload:"true"`
-	ClientSecret string `mapstructure:"client_secret" reload:"true"`
+	ClientSecret string `mapstructure:"client_secret" reload:"true" secret:"true"`
 }
 
 type OAuthConfig struct {
@@ -91,7 +130,7 @@ type OAuthConfig struct {
 
 type OAuthProviderConfig struct {
 	ClientID     string            `mapstructure:"client_id" reload:"true"`
-	ClientSecret string            `mapstructure:"client_secret" reload:"true"`
+	ClientSecret string            `mapstructure:"client_secret" reload:"true" secret:"true"`
 	Scopes       []string          `mapstructure:"scopes" reload:"true"`
 	AuthURL      string            `mapstructure:"auth_url" reload:"true"`
 	TokenURL     string            `mapstructure:"token_url" reload:"true"`
@@ -107,9 +146,53 @@ type TOTPConfig struct {
 	Algorithm string `mapstructure:"algorithm"`
 }
 
+// EventConfig holds the event timeline. Unset times leave the matching toggle
+// to the admin API.
+type EventConfig struct {
+	SignupOpen   time.Time `mapstructure:"signup-open" reload:"true"`
+	SignupClose  time.Time `mapstructure:"signup-close" reload:"true"`
+	LoginOpen    time.Time `mapstructure:"login-open" reload:"true"`
+	LoginClose   time.Time `mapstructure:"login-close" reload:"true"`
+	RosterFreeze time.Time `mapstructure:"roster-freeze" reload:"true"`
+	End          time.Time `mapstructure:"end" reload:"true"`
+}
+
+type PoWConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	BaseDifficulty int           `mapstructure:"base-difficulty" reload:"true"`
+	MaxDifficulty  int           `mapstructure:"max-difficulty" reload:"true"`
+	ScaleEvery     int           `mapstructure:"scale-every" reload:"true"`
+	Window         time.Duration `mapstructure:"window"`
+	ChallengeTTL   time.Duration `mapstructure:"challenge-ttl" reload:"true"`
+}
+
+type WebhookConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Timeout     time.Duration `mapstructure:"timeout" reload:"true"`
+	MaxAttempts int           `mapstructure:"max-attempts" reload:"true"`
+}
+
+// AvatarConfig holds where uploaded avatars are stored and served from.
+type AvatarConfig struct {
+	MaxSize   int64    `mapstructure:"max-size"`   // bytes accepted per upload
+	Storage   string   `mapstructure:"storage"`    // local or s3
+	Path      string   `mapstructure:"path"`       // directory, local only
+	PublicURL string   `mapstructure:"public-url"` // base URL of stored files; local defaults to /avatars on this server
+	S3        S3Config `mapstructure:"s3"`
+}
+
+// S3Config is any S3-compatible object store, addressed path-style.
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"` // e.g. https://s3.eu-west-1.amazonaws.com
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access-key"`
+	SecretKey string `mapstructure:"secret-key" secret:"true"`
+}
+
 type CacheConfig struct {
 	InApp                 bool          `mapstructure:"in-app"`
-	ServiceUrl            string        `mapstructure:"service-url"`
+	ServiceUrl            string        `mapstructure:"service-url" secret:"true"` // may carry credentials
 	ServiceType           string        `mapstructure:"service-type"`
 	InternalCacheSize     int           `mapstructure:"internal-cache-size"`
 	InternalCacheDuration time.Duration `mapstructure:"internal-cache-duration"`
@@ -126,13 +209,50 @@ type BanConfig struct {
 
 func (cfg *Config) Validate() error {
 	cache := cfg.App.AppCache
-	if cache.InApp {
+	if !cache.InApp {
 		if cache.ServiceType != "redis" {
 			return fmt.Errorf("only supported service is redis")
 		} else if cache.ServiceUrl == "" {
 			return fmt.Errorf("service-url cannot be empty")
 		}
 	}
+	switch cfg.Database.Driver {
+	case "":
+		cfg.Database.Driver = "postgres"
+	case "postgres", "mysql":
+	case "sqlite":
+		if cfg.Database.Path == "" && os.Getenv("DATABASE_URL") == "" {
+			return fmt.Errorf("the sqlite driver requires a database path")
+		}
+	default:
+		return fmt.Errorf("unsupported database driver: %s (must be 'postgres', 'sqlite' or 'mysql')", cfg.Database.Driver)
+	}
+	if cfg.Server.ShutdownTimeout <= 0 {
+		cfg.Server.ShutdownTimeout = 15 * time.Second
+	}
+	if cfg.Server.DrainDelay < 0 {
+		return fmt.Errorf("server drain-delay must not be negative")
+	}
+	if tracing := cfg.Server.Tracing; tracing.Enabled {
+		switch tracing.Exporter {
+		case "otlp":
+			if tracing.Endpoint == "" {
+				return fmt.Errorf("otlp tracing exporter requires an endpoint")
+			}
+		case "stdout":
+		default:
+			return fmt.Errorf("unsupported tracing exporter: %s (must be 'otlp' or 'stdout')", tracing.Exporter)
+		}
+		if tracing.SampleRatio < 0 || tracing.SampleRatio > 1 {
+			return fmt.Errorf("tracing sample-ratio must be between 0 and 1")
+		}
+		if tracing.SampleRatio == 0 {
+			cfg.Server.Tracing.SampleRatio = 1
+		}
+		if tracing.ServiceName == "" {
+			cfg.Server.Tracing.ServiceName = "rodan-authify"
+		}
+	}
 	if cfg.App.EmailRegex != "" {
 		re, err := regexp.Compile(cfg.App.EmailRegex)
 		if err != nil {
@@ -147,6 +267,19 @@ func (cfg *Config) Validate() error {
 		if cfg.App.Email.Provider.Type != "smtp" && cfg.App.Email.Provider.Type != "microsoft-graph" {
 			return fmt.Errorf("unsupported email provider type: %s (must be 'smtp' or 'microsoft-graph')", cfg.App.Email.Provider.Type)
 		}
+		if cfg.App.Email.AllowedEmailRegex != "" {
+			re, err := regexp.Compile(cfg.App.Email.AllowedEmailRegex)
+			if err != nil {
+				return fmt.Errorf("invalid allowed email regex: %w", err)
+			}
+			cfg.App.Email.AllowedEmailCompilexRegex = re
+		}
+		if cfg.App.Email.ResetURL == "" {
+			return fmt.Errorf("email auth requires a reset-url pointing at the frontend reset page")
+		}
+		if u, err := url.Parse(cfg.App.Email.ResetURL); err != nil || !u.IsAbs() {
+			return fmt.Errorf("reset-url must be an absolute URL, got %q", cfg.App.Email.ResetURL)
+		}
 		if cfg.App.Email.EmailTemplate != "" {
 			if _, err := os.Stat(cfg.App.Email.EmailTemplate); err != nil {
 				if os.IsNotExist(err) {
@@ -155,6 +288,84 @@ func (cfg *Config) Validate() error {
 				return fmt.Errorf("failed to access email template file %s: %w", cfg.App.Email.EmailTemplate, err)
 			}
 		}
+		if cfg.App.Email.InviteTemplate != "" {
+			if _, err := os.Stat(cfg.App.Email.InviteTemplate); err != nil {
+				return fmt.Errorf("invite template file is not readable: %w", err)
+			}
+		}
+		if cfg.App.Email.InviteSubject == "" {
+			cfg.App.Email.InviteSubject = "You have been invited to join a team"
+		}
+		if cfg.App.Email.InvitesURL != "" {
+			if u, err := url.Parse(cfg.App.Email.InvitesURL); err != nil || !u.IsAbs() {
+				return fmt.Errorf("invites-url must be an absolute URL, got %q", cfg.App.Email.InvitesURL)
+			}
+		}
+	}
+	if cfg.App.PoW.Enabled {
+		pow := cfg.App.PoW
+		if pow.BaseDifficulty < 1 || pow.BaseDifficulty > 32 {
+			return fmt.Errorf("pow base-difficulty must be between 1 and 32, got %d", pow.BaseDifficulty)
+		}
+		if pow.MaxDifficulty < pow.BaseDifficulty || pow.MaxDifficulty > 32 {
+			return fmt.Errorf("pow max-difficulty must be between base-difficulty and 32, got %d", pow.MaxDifficulty)
+		}
+		if pow.ScaleEvery < 1 {
+			return fmt.Errorf("pow scale-every must be > 0")
+		}
+		if pow.Window <= 0 || pow.ChallengeTTL <= 0 {
+			return fmt.Errorf("pow window and challenge-ttl must be > 0")
+		}
+	}
+	if cfg.App.Webhooks.Enabled {
+		if cfg.App.Webhooks.Timeout <= 0 {
+			return fmt.Errorf("webhooks timeout must be > 0")
+		}
+		if cfg.App.Webhooks.MaxAttempts < 1 {
+			return fmt.Errorf("webhooks max-attempts must be at least 1")
+		}
+	}
+	avatar := &cfg.App.Avatar
+	if avatar.MaxSize <= 0 {
+		avatar.MaxSize = 2 << 20
+	}
+	switch avatar.Storage {
+	case "":
+		avatar.Storage = "local"
+		fallthrough
+	case "local":
+		if avatar.Path == "" {
+			avatar.Path = "avatars"
+		}
+	case "s3":
+		s3 := avatar.S3
+		if s3.Endpoint == "" || s3.Region == "" || s3.Bucket == "" || s3.AccessKey == "" || s3.SecretKey == "" {
+			return fmt.Errorf("s3 avatar storage requires endpoint, region, bucket, access-key and secret-key")
+		}
+		if u, err := url.Parse(s3.Endpoint); err != nil || !u.IsAbs() {
+			return fmt.Errorf("avatar s3 endpoint must be an absolute URL, got %q", s3.Endpoint)
+		}
+		if avatar.PublicURL == "" {
+			return fmt.Errorf("s3 avatar storage requires a public-url the bucket is served from")
+		}
+	default:
+		return fmt.Errorf("unsupported avatar storage: %s (must be 'local' or 's3')", avatar.Storage)
+	}
+	event := cfg.App.Event
+	for _, pair := range [][2]time.Time{
+		{event.SignupOpen, event.SignupClose},
+		{event.LoginOpen, event.LoginClose},
+		{event.LoginOpen, event.End},
+	} {
+		if !pair[0].IsZero() && !pair[1].IsZero() && !pair[0].Before(pair[1]) {
+			return fmt.Errorf("event schedule is out of order: %s is not before %s", pair[0], pair[1])
+		}
+	}
+	if cfg.App.ResetTokenExpiry < 0 {
+		return fmt.Errorf("reset-token-expiry must not be negative")
+	}
+	if cfg.App.DeletionGrace < 0 {
+		return fmt.Errorf("deletion-grace must not be negative")
 	}
 	if cfg.App.OAuth.Enabled {
 		if len(cfg.App.OAuth.Providers) == 0 {
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"reflect"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// Redacted returns the effective configuration keyed by its TOML names, with
+// every field tagged secret:"true" masked, so that it can be printed.
+func (cfg *Config) Redacted() map[string]any {
+	return redactStruct(reflect.ValueOf(*cfg))
+}
+
+func redactStruct(v reflect.Value) map[string]any {
+	out := map[string]any{}
+	typ := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := typ.Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" || name == "-" {
+			continue
+		}
+		value := v.Field(i)
+		if field.Tag.Get("secret") == "true" {
+			if !value.IsZero() {
+				out[name] = redacted
+			} else {
+				out[name] = ""
+			}
+			continue
+		}
+		out[name] = redactValue(value)
+	}
+	return out
+}
+
+func redactValue(v reflect.Value) any {
+	switch val := v.Interface().(type) {
+	case time.Duration:
+		return val.String()
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		return val
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return redactStruct(v)
+	case reflect.Map:
+		out := map[string]any{}
+		for _, key := range v.MapKeys() {
+			out[key.String()] = redactValue(v.MapIndex(key))
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return []any{}
+		}
+	}
+	return v.Interface()
+}
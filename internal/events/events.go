@@ -0,0 +1,92 @@
+// Package events is the in-process bus for account and team lifecycle events.
+// Webhooks and the live stream subscribe to it.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+const (
+	UserSignedUp      = "user.signed_up"
+	UserLogin         = "user.login"
+	UserBanned        = "user.banned"
+	UserDeleted       = "user.deleted"
+	TeamCreated       = "team.created"
+	TeamMemberJoined  = "team.member_joined"
+	TeamMemberLeft    = "team.member_left"
+	TeamDeleted       = "team.deleted"
+	TeamRenamed       = "team.renamed"
+	TeamLeaderChanged = "team.leader_changed"
+
+	TeamJoinRequested      = "team.join_requested"
+	TeamJoinRequestDecided = "team.join_request_decided"
+	TeamDivisionChanged    = "team.division_changed"
+)
+
+// Types lists every event a subscriber can ask for.
+func Types() []string {
+	return []string{UserSignedUp, UserLogin, UserBanned, UserDeleted, TeamCreated, TeamMemberJoined, TeamMemberLeft, TeamDeleted, TeamRenamed, TeamLeaderChanged, TeamJoinRequested, TeamJoinRequestDecided, TeamDivisionChanged}
+}
+
+func ValidType(typ string) bool {
+	for _, t := range Types() {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+type Event struct {
+	ID     string         `json:"id"`
+	Type   string         `json:"type"`
+	Time   time.Time      `json:"time"`
+	UserID *uint          `json:"user_id,omitempty"`
+	TeamID *uint          `json:"team_id,omitempty"`
+	Data   map[string]any `json:"data,omitempty"`
+}
+
+type Handler func(Event)
+
+var (
+	mu       sync.RWMutex
+	handlers []Handler
+)
+
+// Subscribe registers a handler for every event published in this process.
+// Handlers run on the publisher's goroutine and must not block for long.
+func Subscribe(h Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers = append(handlers, h)
+}
+
+func optionalID(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// Publish builds an event and hands it to every subscriber. A zero user or
+// team ID means the event is not about one.
+func Publish(typ string, userID, teamID uint, data map[string]any) {
+	random := make([]byte, 12)
+	rand.Read(random)
+	e := Event{
+		ID:     hex.EncodeToString(random),
+		Type:   typ,
+		Time:   time.Now().UTC(),
+		UserID: optionalID(userID),
+		TeamID: optionalID(teamID),
+		Data:   data,
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, h := range handlers {
+		h(e)
+	}
+}
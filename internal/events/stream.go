@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/intraware/rodan-authify/internal/cache"
+)
+
+const streamChannel = "rodan-authify:events"
+
+// listenerBuffer is how many events a slow listener may fall behind before
+// events are dropped for it.
+const listenerBuffer = 32
+
+type listener struct {
+	ch     chan Event
+	filter func(Event) bool
+}
+
+var (
+	listenersMu sync.RWMutex
+	listeners   = map[*listener]struct{}{}
+)
+
+// Listen returns a channel receiving every streamed event the filter accepts,
+// from this instance and, with Redis configured, from the others. Call the
+// returned function to stop listening.
+func Listen(filter func(Event) bool) (<-chan Event, func()) {
+	l := &listener{ch: make(chan Event, listenerBuffer), filter: filter}
+	listenersMu.Lock()
+	listeners[l] = struct{}{}
+	listenersMu.Unlock()
+	return l.ch, func() {
+		listenersMu.Lock()
+		delete(listeners, l)
+		listenersMu.Unlock()
+	}
+}
+
+func broadcast(e Event) {
+	listenersMu.RLock()
+	defer listenersMu.RUnlock()
+	for l := range listeners {
+		if !l.filter(e) {
+			continue
+		}
+		select {
+		case l.ch <- e:
+		default:
+		}
+	}
+}
+
+// Fanout forwards a published event to the listeners of every instance. It
+// is registered with Subscribe.
+func Fanout(e Event) {
+	if !cache.RedisEnabled() {
+		broadcast(e)
+		return
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := cache.Publish(streamChannel, payload); err != nil {
+		// still reach the listeners on this instance
+		broadcast(e)
+	}
+}
+
+// RunFanout relays events published by any instance to local listeners. It
+// returns at once when Redis is not configured.
+func RunFanout(ctx context.Context) {
+	messages := cache.Subscribe(ctx, streamChannel)
+	if messages == nil {
+		return
+	}
+	for msg := range messages {
+		var e Event
+		if err := json.Unmarshal([]byte(msg.Payload), &e); err == nil {
+			broadcast(e)
+		}
+	}
+}
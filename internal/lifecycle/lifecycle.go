@@ -0,0 +1,62 @@
+// Package lifecycle tracks the work that has to finish before the process
+// exits and tells long-lived handlers when shutdown has begun.
+package lifecycle
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	wg           sync.WaitGroup
+	shutdown     = make(chan struct{})
+	shutdownOnce sync.Once
+)
+
+// Go runs fn in a goroutine that Wait waits for. Use it for work started
+// from a request that must not be lost when the server stops.
+func Go(fn func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		fn()
+	}()
+}
+
+// Wait blocks until every goroutine started with Go has returned or ctx is
+// done, whichever comes first.
+func Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// BeginShutdown marks the process as shutting down. It is safe to call more
+// than once.
+func BeginShutdown() {
+	shutdownOnce.Do(func() { close(shutdown) })
+}
+
+// ShuttingDown is closed once BeginShutdown has been called, so streaming
+// handlers can end their responses and let the server drain.
+func ShuttingDown() <-chan struct{} {
+	return shutdown
+}
+
+// Draining reports whether BeginShutdown has been called.
+func Draining() bool {
+	select {
+	case <-shutdown:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,95 @@
+// Package metrics defines the Prometheus metrics exported on /metrics.
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/intraware/rodan-authify/internal/cache"
+	"github.com/intraware/rodan-authify/internal/utils/audit"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/sirupsen/logrus"
+)
+
+const namespace = "rodan"
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	AuthOutcomes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_outcomes_total",
+		Help:      "Signup, login and password reset outcomes by audit reason.",
+	}, []string{"event", "status", "reason"})
+)
+
+// authEvents are the audit events counted in AuthOutcomes.
+var authEvents = map[string]bool{
+	"sign_up":              true,
+	"login":                true,
+	"oauth_callback":       true,
+	"forgot_password":      true,
+	"forgot_password_auth": true,
+	"reset_password":       true,
+}
+
+// Register adds every collector, including the connection pool stats of db,
+// to the default registry.
+func Register(db *sql.DB) {
+	prometheus.MustRegister(HTTPRequests, HTTPDuration, AuthOutcomes, cache.Requests, audit.Dropped)
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "rodan"))
+	stat := func(pick func(hits, misses, evictions uint64) uint64) func() float64 {
+		return func() float64 {
+			hits, misses, evictions, _ := cache.RedisStats()
+			return float64(pick(hits, misses, evictions))
+		}
+	}
+	prometheus.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "redis_cache_hits_total",
+			Help:      "Hits of the Redis-backed cache, local layer included.",
+		}, stat(func(h, _, _ uint64) uint64 { return h })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "redis_cache_misses_total",
+			Help:      "Misses of the Redis-backed cache.",
+		}, stat(func(_, m, _ uint64) uint64 { return m })),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "local_cache_evictions_total",
+			Help:      "Entries the in-process TinyLFU layer evicted for lack of space.",
+		}, stat(func(_, _, e uint64) uint64 { return e })),
+	)
+}
+
+// AuditHook counts auth outcomes from the audit log entries the handlers
+// already write.
+type AuditHook struct{}
+
+func (AuditHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (AuditHook) Fire(entry *logrus.Entry) error {
+	if entry.Data["type"] != "audit" {
+		return nil
+	}
+	event, _ := entry.Data["event"].(string)
+	if !authEvents[event] {
+		return nil
+	}
+	status, _ := entry.Data["status"].(string)
+	reason, _ := entry.Data["reason"].(string)
+	AuthOutcomes.WithLabelValues(event, status, reason).Inc()
+	return nil
+}
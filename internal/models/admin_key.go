@@ -0,0 +1,86 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const adminKeyPrefix = "rak"
+
+// AdminAPIKey is a named credential for automation against the admin API.
+// Only the SHA-256 of the secret is stored; the prefix identifies the key so
+// that lookups do not depend on the secret.
+type AdminAPIKey struct {
+	gorm.Model
+	Name        string     `json:"name" gorm:"unique;not null"`
+	Prefix      string     `json:"prefix" gorm:"unique;not null"`
+	Hash        string     `json:"-" gorm:"not null"`
+	Scopes      []string   `json:"scopes" gorm:"serializer:json"`
+	CreatedByID *uint      `json:"created_by_id"` // nil when created with the bootstrap key
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	Revoked     bool       `json:"revoked" gorm:"default:false"`
+}
+
+func (AdminAPIKey) TableName() string {
+	return "admin_api_keys"
+}
+
+func hashAdminKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAdminAPIKey fills in a fresh prefix and hash for the key and returns the
+// full key, which is shown to the caller exactly once.
+func NewAdminAPIKey(key *AdminAPIKey) (string, error) {
+	prefix := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	key.Prefix = hex.EncodeToString(prefix)
+	raw := adminKeyPrefix + "_" + key.Prefix + "_" + hex.EncodeToString(secret)
+	key.Hash = hashAdminKey(raw)
+	return raw, nil
+}
+
+// AdminKeyPrefix extracts the lookup prefix from a full key.
+func AdminKeyPrefix(raw string) (string, bool) {
+	parts := strings.Split(raw, "_")
+	if len(parts) != 3 || parts[0] != adminKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// Matches compares the full key against the stored hash in constant time.
+func (k *AdminAPIKey) Matches(raw string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashAdminKey(raw)), []byte(k.Hash)) == 1
+}
+
+// Active reports whether the key can still be used.
+func (k *AdminAPIKey) Active() bool {
+	if k.Revoked {
+		return false
+	}
+	return k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt)
+}
+
+func (k *AdminAPIKey) Allows(perm string) bool {
+	for _, scope := range k.Scopes {
+		if scope == perm {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrAuditImmutable = errors.New("audit events are append-only")
+
+// AuditEvent is one persisted audit log entry. Each row carries the hash of
+// the previous one, so editing or removing a row breaks the chain from there
+// on.
+type AuditEvent struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index;not null"`
+	Level        string    `json:"level"`
+	Event        string    `json:"event" gorm:"index"`
+	Status       string    `json:"status" gorm:"index"`
+	Reason       string    `json:"reason"`
+	Actor        string    `json:"actor"`
+	ActorID      *uint     `json:"actor_id" gorm:"index"`
+	TargetUserID *uint     `json:"target_user_id" gorm:"index"`
+	TeamID       *uint     `json:"team_id" gorm:"index"`
+	IP           string    `json:"ip"`
+	RequestID    string    `json:"request_id" gorm:"index"`
+	Message      string    `json:"message"`
+	Fields       string    `json:"fields" gorm:"type:text"` // remaining log fields as JSON
+	PrevHash     string    `json:"prev_hash"`
+	Hash         string    `json:"hash" gorm:"not null"`
+	Redacted     bool      `json:"redacted" gorm:"not null;default:false"` // personal data removed, see RedactAuditEvents
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+func (*AuditEvent) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditImmutable
+}
+
+func (*AuditEvent) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditImmutable
+}
+
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+// ComputeHash hashes the event together with the hash of its predecessor.
+func (e *AuditEvent) ComputeHash() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		e.PrevHash,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.Level,
+		e.Event,
+		e.Status,
+		e.Reason,
+		e.Actor,
+		optionalID(e.ActorID),
+		optionalID(e.TargetUserID),
+		optionalID(e.TeamID),
+		e.IP,
+		e.RequestID,
+		e.Message,
+		e.Fields,
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// keptHash hashes what redaction leaves of the event: everything but the
+// actor, the IP and the extra fields. It includes the event's own hash, which
+// redaction keeps too.
+func (e *AuditEvent) keptHash() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		e.PrevHash,
+		e.Hash,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.Level,
+		e.Event,
+		e.Status,
+		e.Reason,
+		optionalID(e.ActorID),
+		optionalID(e.TargetUserID),
+		optionalID(e.TeamID),
+		e.RequestID,
+		e.Message,
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// auditChainLock is the single row every writer locks before reading the end
+// of the chain, so that two writers, on this instance or another, never link
+// to the same predecessor. Locking the last event instead would lock nothing
+// while the table is empty.
+type auditChainLock struct {
+	ID       uint `gorm:"primaryKey"`
+	LockedAt time.Time
+}
+
+func (auditChainLock) TableName() string {
+	return "audit_chain_locks"
+}
+
+// AppendAuditEvent links the event to the current end of the chain and
+// stores it.
+func AppendAuditEvent(e *AuditEvent) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return appendAuditEvent(tx, e)
+	})
+}
+
+func appendAuditEvent(tx *gorm.DB, e *AuditEvent) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	// the database keeps microseconds; hash what will be read back
+	e.CreatedAt = e.CreatedAt.UTC().Truncate(time.Microsecond)
+	// an UPDATE takes the row lock on every dialect, SQLite included,
+	// where SELECT ... FOR UPDATE is not supported
+	if err := tx.Model(&auditChainLock{}).Where("id = ?", 1).
+		Update("locked_at", e.CreatedAt).Error; err != nil {
+		return err
+	}
+	var last AuditEvent
+	if err := tx.Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+	e.PrevHash = last.Hash
+	e.Hash = e.ComputeHash()
+	return tx.Create(e).Error
+}
+
+// AuditRedactedEvent names the events RedactAuditEvents blanked.
+const AuditRedactedEvent = "audit_redacted"
+
+// auditRedaction is what an audit_redacted event records: the keptHash of
+// every event it redacted, by ID.
+type auditRedaction struct {
+	Kept map[uint]string `json:"kept"`
+}
+
+// RedactAuditEvents blanks the actor, IP and extra fields of the events about
+// the user, which hold their username, email and addresses. The events keep
+// their hashes, so the chain still links. As their content no longer matches
+// them, an audit_redacted event appended to the chain records a hash of what
+// is left of each, and VerifyAuditChain checks redacted events against that.
+func RedactAuditEvents(tx *gorm.DB, userID uint) error {
+	var events []AuditEvent
+	if err := tx.Where("(actor_id = ? OR target_user_id = ?) AND redacted = ? AND event <> ?", userID, userID, false, AuditRedactedEvent).
+		Order("id").Find(&events).Error; err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(events))
+	redaction := auditRedaction{Kept: make(map[uint]string, len(events))}
+	for i, e := range events {
+		ids[i] = e.ID
+		redaction.Kept[e.ID] = e.keptHash()
+	}
+	// the hooks refuse every update, which is the point everywhere else
+	if err := tx.Session(&gorm.Session{SkipHooks: true}).Model(&AuditEvent{}).Where("id IN ?", ids).
+		Updates(map[string]any{"actor": "", "ip": "", "fields": "", "redacted": true}).Error; err != nil {
+		return err
+	}
+	fields, err := json.Marshal(redaction)
+	if err != nil {
+		return err
+	}
+	return appendAuditEvent(tx, &AuditEvent{
+		Level:        "info",
+		Event:        AuditRedactedEvent,
+		Status:       "success",
+		Reason:       "account_deleted",
+		TargetUserID: &userID,
+		Message:      "Personal data removed from the audit events of a deleted account",
+		Fields:       string(fields),
+	})
+}
+
+// VerifyAuditChain walks the whole chain and returns the ID of the first
+// event whose hash does not match, or 0 if the chain is intact. A redacted
+// event is checked against the hash an audit_redacted event recorded for it
+// instead; that event is checked in turn as the walk reaches it.
+func VerifyAuditChain() (checked int, brokenAt uint, err error) {
+	var redactions []AuditEvent
+	if err = DB.Where("event = ?", AuditRedactedEvent).Find(&redactions).Error; err != nil {
+		return
+	}
+	kept := map[uint]string{}
+	for _, r := range redactions {
+		var redaction auditRedaction
+		if json.Unmarshal([]byte(r.Fields), &redaction) != nil {
+			continue
+		}
+		for id, hash := range redaction.Kept {
+			kept[id] = hash
+		}
+	}
+	prev := ""
+	var batch []AuditEvent
+	err = DB.Order("id").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, e := range batch {
+			intact := e.ComputeHash() == e.Hash
+			if e.Redacted {
+				hash, ok := kept[e.ID]
+				intact = ok && hash == e.keptHash()
+			}
+			if e.PrevHash != prev || !intact {
+				brokenAt = e.ID
+				return errAuditChainBroken
+			}
+			prev = e.Hash
+			checked++
+		}
+		return nil
+	}).Error
+	if errors.Is(err, errAuditChainBroken) {
+		err = nil
+	}
+	return
+}
+
+var errAuditChainBroken = errors.New("audit chain broken")
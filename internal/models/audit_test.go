@@ -0,0 +1,68 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditChain(t *testing.T) {
+	testutil.SetupDB(t)
+	for _, event := range []string{"login", "team_create", "logout"} {
+		require.NoError(t, models.AppendAuditEvent(&models.AuditEvent{Event: event, Status: "success"}))
+	}
+	checked, brokenAt, err := models.VerifyAuditChain()
+	require.NoError(t, err)
+	require.Equal(t, 3, checked)
+	require.Zero(t, brokenAt)
+
+	// tamper behind the model's back, as someone with database access would
+	require.NoError(t, models.DB.Exec("UPDATE audit_events SET status = ? WHERE event = ?", "failure", "team_create").Error)
+	_, brokenAt, err = models.VerifyAuditChain()
+	require.NoError(t, err)
+	require.Equal(t, uint(2), brokenAt)
+}
+
+func TestRedactAuditEvents(t *testing.T) {
+	testutil.SetupDB(t)
+	alice, bob := testutil.CreateUser(t, "alice"), testutil.CreateUser(t, "bob")
+	for _, userID := range []uint{alice.ID, bob.ID, alice.ID} {
+		require.NoError(t, models.AppendAuditEvent(&models.AuditEvent{
+			Event: "login", Status: "success", Actor: "someone", TargetUserID: &userID,
+			IP: "192.0.2.1", Fields: `{"username":"someone"}`,
+		}))
+	}
+	require.NoError(t, models.RedactAuditEvents(models.DB, alice.ID))
+
+	var events []models.AuditEvent
+	require.NoError(t, models.DB.Order("id").Find(&events).Error)
+	require.Len(t, events, 4)
+	for _, e := range events[:3] {
+		require.Equal(t, *e.TargetUserID == alice.ID, e.Redacted)
+		if e.Redacted {
+			require.Empty(t, e.Actor)
+			require.Empty(t, e.IP)
+			require.Empty(t, e.Fields)
+		}
+	}
+	require.Equal(t, models.AuditRedactedEvent, events[3].Event)
+	checked, brokenAt, err := models.VerifyAuditChain()
+	require.NoError(t, err)
+	require.Equal(t, 4, checked)
+	require.Zero(t, brokenAt)
+
+	// what redaction keeps is still covered
+	require.NoError(t, models.DB.Exec("UPDATE audit_events SET status = ? WHERE id = ?", "failure", events[2].ID).Error)
+	_, brokenAt, err = models.VerifyAuditChain()
+	require.NoError(t, err)
+	require.Equal(t, events[2].ID, brokenAt)
+	require.NoError(t, models.DB.Exec("UPDATE audit_events SET status = ? WHERE id = ?", "success", events[2].ID).Error)
+
+	// marking an event redacted does not hide a change to it
+	require.NoError(t, models.DB.Exec("UPDATE audit_events SET ip = '', redacted = ? WHERE id = ?", true, events[1].ID).Error)
+	_, brokenAt, err = models.VerifyAuditChain()
+	require.NoError(t, err)
+	require.Equal(t, events[1].ID, brokenAt)
+}
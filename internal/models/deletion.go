@@ -0,0 +1,111 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDeletionScheduled    = errors.New("account deletion is already scheduled")
+	ErrDeletionNotScheduled = errors.New("account deletion is not scheduled")
+	ErrUsernameReserved     = fmt.Errorf("usernames starting with %q are reserved", DeletedUsernamePrefix)
+)
+
+// DeletedUsernamePrefix starts the username Anonymise gives an account.
+const DeletedUsernamePrefix = "deleted-"
+
+// ReservedUsername tells whether a username could clash with an anonymised
+// account. Case is ignored, as MySQL compares usernames without it.
+func ReservedUsername(username string) bool {
+	return strings.HasPrefix(strings.ToLower(username), DeletedUsernamePrefix)
+}
+
+// ScheduleDeletion marks the user for anonymisation at the given time. Until
+// then the user can still log in and cancel.
+func ScheduleDeletion(tx *gorm.DB, u *User, at time.Time) error {
+	if u.DeleteAfter != nil {
+		return ErrDeletionScheduled
+	}
+	if err := tx.Model(u).Update("delete_after", at).Error; err != nil {
+		return err
+	}
+	u.DeleteAfter = &at
+	return nil
+}
+
+// CancelDeletion keeps an account whose deletion was scheduled.
+func CancelDeletion(tx *gorm.DB, u *User) error {
+	if u.DeleteAfter == nil {
+		return ErrDeletionNotScheduled
+	}
+	if err := tx.Model(u).Update("delete_after", nil).Error; err != nil {
+		return err
+	}
+	u.DeleteAfter = nil
+	return nil
+}
+
+// DueDeletions returns up to limit users whose grace period ended by now.
+func DueDeletions(tx *gorm.DB, now time.Time, limit int) ([]User, error) {
+	var users []User
+	err := tx.Where("delete_after IS NOT NULL AND delete_after <= ?", now).
+		Order("delete_after").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// Anonymise strips the personal data from the user and their audit events and
+// soft-deletes the row. The row itself stays so solves keep pointing at it and
+// scoreboard history is unchanged.
+func Anonymise(tx *gorm.DB, u *User) error {
+	// hands the team over if the user leads it, or deletes it if they are
+	// its last member
+	if err := tx.Delete(u).Error; err != nil {
+		return err
+	}
+	for _, model := range []any{&UserTOTPMeta{}, &UserOauthMeta{}, &TeamJoinRequest{}, &RosterException{}} {
+		if err := tx.Unscoped().Where("user_id = ?", u.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Unscoped().Where("invitee_id = ?", u.ID).Delete(&TeamInvitation{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&InviteRedemption{}).Where("user_id = ?", u.ID).Update("ip", "").Error; err != nil {
+		return err
+	}
+	if err := RedactAuditEvents(tx, u.ID); err != nil {
+		return err
+	}
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return err
+	}
+	ghost := User{Password: hex.EncodeToString(random)} // nobody knows it
+	if err := ghost.SetPassword(ghost.Password); err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(&User{}).Where("id = ?", u.ID).Updates(map[string]any{
+		"username":      fmt.Sprintf("%s%d", DeletedUsernamePrefix, u.ID),
+		"email":         fmt.Sprintf("deleted-%d@deleted.invalid", u.ID),
+		"password":      ghost.Password,
+		"avatar_url":    "",
+		"avatar_key":    "",
+		"country":       "",
+		"affiliation":   "",
+		"website":       "",
+		"bio":           "",
+		"active":        false,
+		"team_id":       nil,
+		"team_role":     TeamRoleMember,
+		"hide_email":    true,
+		"hide_team":     true,
+		"delete_after":  nil,
+		"token_version": gorm.Expr("token_version + 1"),
+	}).Error
+}
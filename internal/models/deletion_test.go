@@ -0,0 +1,79 @@
+package models_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestAnonymisingSoleMemberDeletesTeam(t *testing.T) {
+	testutil.SetupDB(t)
+	leader := testutil.CreateUser(t, "leader")
+	team := testutil.CreateTeam(t, models.DB, leader)
+
+	require.NoError(t, models.DB.First(&leader, leader.ID).Error)
+	require.NoError(t, models.DB.Transaction(func(tx *gorm.DB) error {
+		return models.Anonymise(tx, &leader)
+	}))
+	require.ErrorIs(t, models.DB.First(&team, team.ID).Error, gorm.ErrRecordNotFound)
+}
+
+func TestAnonymiseKeepsSolves(t *testing.T) {
+	testutil.SetupDB(t)
+	loner := testutil.CreateUser(t, "loner")
+	require.NoError(t, models.DB.Delete(&loner).Error, "users without a team can be deleted")
+
+	leader := testutil.CreateUser(t, "leader")
+	member := testutil.CreateUser(t, "member")
+	team := testutil.CreateTeam(t, models.DB, leader, member)
+	require.NoError(t, models.DB.First(&leader, leader.ID).Error)
+	require.NoError(t, models.DB.AutoMigrate(&models.Solve{}), "solves come from the challenge service")
+	require.NoError(t, models.DB.Create(&models.Solve{TeamID: team.ID, ChallengeID: 1, UserID: leader.ID, BloodCount: 1}).Error)
+	require.NoError(t, models.DB.Create(&models.UserOauthMeta{UserID: leader.ID, Provider: "github", ProviderID: "gh-1"}).Error)
+
+	require.NoError(t, models.ScheduleDeletion(models.DB, &leader, time.Now().Add(time.Hour)))
+	require.ErrorIs(t, models.ScheduleDeletion(models.DB, &leader, time.Now()), models.ErrDeletionScheduled)
+	due, err := models.DueDeletions(models.DB, time.Now(), 10)
+	require.NoError(t, err)
+	require.Empty(t, due, "the grace period has not ended")
+	require.NoError(t, models.CancelDeletion(models.DB, &leader))
+	require.ErrorIs(t, models.CancelDeletion(models.DB, &leader), models.ErrDeletionNotScheduled)
+
+	require.NoError(t, models.ScheduleDeletion(models.DB, &leader, time.Now().Add(-time.Minute)))
+	due, err = models.DueDeletions(models.DB, time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+
+	export, err := models.ExportUser(models.DB, &leader)
+	require.NoError(t, err)
+	require.True(t, export.Team.Leader)
+	require.Len(t, export.Solves, 1)
+	require.Len(t, export.OAuth, 1)
+
+	require.NoError(t, models.DB.Transaction(func(tx *gorm.DB) error {
+		return models.Anonymise(tx, &due[0])
+	}))
+	var ghost models.User
+	require.NoError(t, models.DB.Unscoped().First(&ghost, leader.ID).Error)
+	require.True(t, ghost.DeletedAt.Valid)
+	require.Equal(t, "deleted-"+strconv.Itoa(int(leader.ID)), ghost.Username)
+	require.NotContains(t, ghost.Email, "leader")
+	require.Empty(t, ghost.AvatarURL)
+	require.Nil(t, ghost.TeamID)
+	require.Nil(t, ghost.DeleteAfter)
+	require.Greater(t, ghost.TokenVersion, leader.TokenVersion)
+
+	var solves int64
+	require.NoError(t, models.DB.Model(&models.Solve{}).Where("user_id = ?", leader.ID).Count(&solves).Error)
+	require.EqualValues(t, 1, solves, "scoreboard history stays")
+	var links int64
+	require.NoError(t, models.DB.Unscoped().Model(&models.UserOauthMeta{}).Where("user_id = ?", leader.ID).Count(&links).Error)
+	require.Zero(t, links)
+	require.NoError(t, models.DB.First(&team, team.ID).Error)
+	require.Equal(t, member.ID, team.LeaderID)
+}
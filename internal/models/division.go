@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"gorm.io/gorm"
+)
+
+// Division is a bracket teams compete in, e.g. student, open or corporate.
+// Each one can tighten the global rules on who may be in its teams.
+type Division struct {
+	gorm.Model
+	Name           string   `json:"name" gorm:"not null"` // unique among divisions that are not deleted
+	Description    string   `json:"description"`
+	TeamSize       int      `json:"team_size" gorm:"not null;default:0"`                           // 0 uses app.team-size
+	EmailRegex     string   `json:"email_regex"`                                                   // members' email must match, if set
+	OAuthProviders []string `json:"oauth_providers" gorm:"column:oauth_providers;serializer:json"` // members must have linked one of these, if set
+}
+
+func (Division) TableName() string {
+	return "divisions"
+}
+
+var (
+	ErrDivisionNotFound   = errors.New("division not found")
+	ErrDivisionIneligible = errors.New("user does not meet the division rules")
+	ErrDivisionEmail      = fmt.Errorf("%w: email address is not allowed", ErrDivisionIneligible)
+	ErrDivisionProvider   = fmt.Errorf("%w: no linked account from an allowed provider", ErrDivisionIneligible)
+)
+
+// ValidateDivision checks the rules of d before it is saved.
+func ValidateDivision(d *Division) error {
+	if d.TeamSize < 0 {
+		return errors.New("team size cannot be negative")
+	}
+	if d.EmailRegex != "" {
+		if _, err := regexp.Compile(d.EmailRegex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MaxTeamSize is the largest a team in the division may grow.
+func (d *Division) MaxTeamSize() int {
+	if d.TeamSize > 0 {
+		return d.TeamSize
+	}
+	return values.GetConfig().App.TeamSize
+}
+
+// Admits reports whether the user meets the division's eligibility rules.
+func (d *Division) Admits(tx *gorm.DB, user *User) error {
+	if d.EmailRegex != "" {
+		re, err := regexp.Compile(d.EmailRegex)
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(user.Email) {
+			return ErrDivisionEmail
+		}
+	}
+	if len(d.OAuthProviders) > 0 {
+		var providers []string
+		if err := tx.Model(&UserOauthMeta{}).Where("user_id = ?", user.ID).
+			Pluck("provider", &providers).Error; err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(providers, func(p string) bool { return slices.Contains(d.OAuthProviders, p) }) {
+			return ErrDivisionProvider
+		}
+	}
+	return nil
+}
+
+// TeamDivision returns the division of the team, or an empty one carrying
+// only the global rules when the team has none.
+func TeamDivision(tx *gorm.DB, team *Team) (Division, error) {
+	var division Division
+	if team.DivisionID == nil {
+		return division, nil
+	}
+	if err := tx.First(&division, *team.DivisionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrDivisionNotFound
+		}
+		return division, err
+	}
+	return division, nil
+}
@@ -0,0 +1,54 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDivisionRules(t *testing.T) {
+	testutil.SetupDB(t)
+	division := models.Division{Name: "student", TeamSize: 3, EmailRegex: `@uni\.edu$`}
+	require.NoError(t, models.ValidateDivision(&division))
+	require.NoError(t, models.DB.Create(&division).Error)
+	leader := testutil.CreateUser(t, "leader")
+	team := testutil.CreateTeam(t, models.DB, leader)
+	require.NoError(t, models.DB.Model(&team).Update("division_id", division.ID).Error)
+
+	outsider := testutil.CreateUser(t, "outsider")
+	_, err := models.AddTeamMember(models.DB, team.ID, &outsider)
+	require.ErrorIs(t, err, models.ErrDivisionEmail)
+	require.ErrorIs(t, err, models.ErrDivisionIneligible)
+
+	// the division allows three members where app.team-size allows two
+	for _, name := range []string{"alice", "bob", "carol"} {
+		student := testutil.CreateUser(t, name)
+		require.NoError(t, models.DB.Model(&student).Update("email", name+"@uni.edu").Error)
+		student.Email = name + "@uni.edu"
+		_, err = models.AddTeamMember(models.DB, team.ID, &student)
+		if name == "carol" {
+			require.ErrorIs(t, err, models.ErrTeamFull)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+
+	require.NoError(t, models.DB.Model(&division).Updates(map[string]any{"team_size": 4, "email_regex": ""}).Error)
+	require.NoError(t, models.DB.Model(&division).Update("oauth_providers", `["github"]`).Error)
+	_, err = models.AddTeamMember(models.DB, team.ID, &outsider)
+	require.ErrorIs(t, err, models.ErrDivisionProvider)
+	require.NoError(t, models.DB.Create(&models.UserOauthMeta{UserID: outsider.ID, Provider: "github", ProviderID: "gh-1"}).Error)
+	_, err = models.AddTeamMember(models.DB, team.ID, &outsider)
+	require.NoError(t, err)
+}
+
+func TestDivisionNameFreedOnDelete(t *testing.T) {
+	testutil.SetupDB(t)
+	division := models.Division{Name: "open"}
+	require.NoError(t, models.DB.Create(&division).Error)
+	require.Error(t, models.DB.Create(&models.Division{Name: "open"}).Error)
+	require.NoError(t, models.DB.Delete(&division).Error)
+	require.NoError(t, models.DB.Create(&models.Division{Name: "open"}).Error)
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type FeatureFlag struct {
+	Name      string    `json:"name" gorm:"primaryKey"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (FeatureFlag) TableName() string {
+	return "feature_flags"
+}
@@ -5,28 +5,66 @@ import (
 	"os"
 	"time"
 
+	"github.com/glebarez/sqlite"
 	"github.com/intraware/rodan-authify/internal/config"
 	"github.com/sirupsen/logrus"
+	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
 var DB *gorm.DB
 
-func Init(cfg *config.Config) {
-	dbUrl := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
-		cfg.Database.Host,
-		cfg.Database.Username,
-		cfg.Database.Password,
-		cfg.Database.DatabaseName,
-		cfg.Database.Port,
-		cfg.Database.SSLMode,
-	)
+// DSN builds the connection string for the configured driver from the
+// individual [database] settings.
+func DSN(cfg *config.DatabaseConfig) string {
+	switch cfg.Driver {
+	case "sqlite":
+		// foreign keys are off by default in SQLite; WAL and a busy timeout
+		// let concurrent requests wait for the writer instead of failing
+		return cfg.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	case "mysql":
+		// multiStatements is needed to run migration files
+		return fmt.Sprintf(
+			"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=UTC&multiStatements=true",
+			cfg.Username,
+			cfg.Password,
+			cfg.Host,
+			cfg.Port,
+			cfg.DatabaseName,
+		)
+	default:
+		return fmt.Sprintf(
+			"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+			cfg.Host,
+			cfg.Username,
+			cfg.Password,
+			cfg.DatabaseName,
+			cfg.Port,
+			cfg.SSLMode,
+		)
+	}
+}
+
+func dialector(driver, dsn string) gorm.Dialector {
+	switch driver {
+	case "sqlite":
+		return sqlite.Open(dsn)
+	case "mysql":
+		return mysql.Open(dsn)
+	default:
+		return postgres.Open(dsn)
+	}
+}
+
+// Connect opens the database connection without touching the schema.
+func Connect(cfg *config.Config) {
+	dsn := DSN(&cfg.Database)
 	if envDBURL := os.Getenv("DATABASE_URL"); envDBURL != "" {
 		logrus.Warn("DATABASE_URL is set; overriding config values from TOML")
-		dbUrl = envDBURL
+		dsn = envDBURL
 	} else {
 		logrus.Info("Using database config from TOML file")
 	}
@@ -37,7 +75,7 @@ func Init(cfg *config.Config) {
 	var err error
 	maxRetries := cfg.Database.MaxTries
 	for i := range maxRetries {
-		DB, err = gorm.Open(postgres.Open(dbUrl), &gorm.Config{
+		DB, err = gorm.Open(dialector(cfg.Database.Driver, dsn), &gorm.Config{
 			TranslateError: true,
 			Logger:         logger.Default.LogMode(logLevel),
 		})
@@ -53,32 +91,49 @@ func Init(cfg *config.Config) {
 	if err != nil {
 		logrus.Fatalf("Failed to connect to database after %d attempts: %v", maxRetries, err)
 	}
-	if err := DB.AutoMigrate(&User{}, &Team{}, &BanHistory{}); err != nil {
-		logrus.Fatalf("Failed to migrate database: %v", err)
-	}
-	if !DB.Migrator().HasConstraint(&Team{}, "fk_teams_leader") {
-		err := DB.Exec(`
-        ALTER TABLE teams
-        ADD CONSTRAINT fk_teams_leader
-        FOREIGN KEY (leader_id) REFERENCES users(id)
-        ON UPDATE CASCADE ON DELETE SET NULL
-    `).Error
-		if err != nil {
-			logrus.Fatalf("Failed to add constraint: %v", err)
-		} else {
-			logrus.Infof("Constraint fk_teams_leader created successfully")
+	if cfg.Server.Tracing.Enabled {
+		if err := DB.Use(tracing.NewPlugin(tracing.WithoutMetrics())); err != nil {
+			logrus.Fatalf("Failed to enable database tracing: %v", err)
 		}
 	}
-	appCfg := cfg.App
-	if appCfg.OAuth.Enabled {
-		if err := DB.AutoMigrate(&UserOauthMeta{}); err != nil {
-			logrus.Fatalf("Failed to migrate database: %v", err)
-		}
+	logrus.Println("Database initialized successfully")
+}
+
+// Init connects to the database and makes sure its schema is current. In
+// production a schema with pending migrations is refused, since it should
+// have been migrated with `rodan-authify migrate up` before the rollout;
+// elsewhere pending migrations are applied on the spot.
+func Init(cfg *config.Config) {
+	Connect(cfg)
+	migrations, err := Migrations(DB)
+	if err != nil {
+		logrus.Fatalf("Failed to load migrations: %v", err)
+	}
+	applied, err := appliedMigrations(DB)
+	if err != nil {
+		logrus.Fatalf("Failed to read schema version: %v", err)
 	}
-	if appCfg.TOTP.Enabled {
-		if err := DB.AutoMigrate(&UserTOTPMeta{}); err != nil {
-			logrus.Fatalf("Failed to migrate database: %v", err)
+	known := make(map[int]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+	}
+	for version := range applied {
+		if !known[version] {
+			logrus.Fatalf("Database has migration %d applied, which this build does not know; it is newer than this binary", version)
 		}
 	}
-	logrus.Println("Database initialized successfully")
+	pending := len(migrations) - len(applied)
+	if pending == 0 {
+		return
+	}
+	if cfg.Server.Production {
+		logrus.Fatalf("Database schema has %d pending migrations; run `rodan-authify migrate up` first", pending)
+	}
+	done, err := MigrateUp(DB)
+	for _, m := range done {
+		logrus.Infof("Applied migration %d_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		logrus.Fatalf("Failed to migrate database: %v", err)
+	}
 }
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInviteNotFound      = errors.New("invite code is invalid")
+	ErrInviteRevoked       = errors.New("invite code has been revoked")
+	ErrInviteExpired       = errors.New("invite code has expired")
+	ErrInviteExhausted     = errors.New("invite code has no uses left")
+	ErrInviteEmailMismatch = errors.New("invite code is bound to a different email")
+	ErrInviteTeamClosed    = errors.New("team of the invite code cannot take new members")
+	ErrInviteIneligible    = errors.New("user does not meet the division rules of the invite code's team")
+)
+
+type Invite struct {
+	gorm.Model
+	Code        string     `json:"code" gorm:"unique;not null"`
+	MaxUses     int        `json:"max_uses" gorm:"not null;default:1"`
+	Uses        int        `json:"uses" gorm:"not null;default:0"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	Email       *string    `json:"email"`
+	TeamID      *uint      `json:"team_id" gorm:"index"`
+	CreatedByID *uint      `json:"created_by_id"` // nil when created with an admin API key
+	Revoked     bool       `json:"revoked" gorm:"default:false"`
+
+	Redemptions []InviteRedemption `json:"-" gorm:"foreignKey:InviteID"`
+}
+
+type InviteRedemption struct {
+	gorm.Model
+	InviteID uint   `json:"invite_id" gorm:"index;not null"`
+	UserID   uint   `json:"user_id" gorm:"index;not null"`
+	IP       string `json:"ip"`
+}
+
+func (Invite) TableName() string {
+	return "invites"
+}
+
+func (InviteRedemption) TableName() string {
+	return "invite_redemptions"
+}
+
+func (i *Invite) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.Code != "" {
+		return nil
+	}
+	random := make([]byte, 8)
+	if _, err = rand.Read(random); err != nil {
+		return err
+	}
+	i.Code = hex.EncodeToString(random)
+	return nil
+}
+
+// Usable reports why the invite cannot be redeemed for the email, if at all.
+func (i *Invite) Usable(email string) error {
+	if i.Revoked {
+		return ErrInviteRevoked
+	}
+	if i.ExpiresAt != nil && time.Now().After(*i.ExpiresAt) {
+		return ErrInviteExpired
+	}
+	if i.Uses >= i.MaxUses {
+		return ErrInviteExhausted
+	}
+	if i.Email != nil && *i.Email != "" && !strings.EqualFold(*i.Email, email) {
+		return ErrInviteEmailMismatch
+	}
+	return nil
+}
+
+// FindUsableInvite loads an invite by code and checks that it can be redeemed
+// for the email.
+func FindUsableInvite(tx *gorm.DB, code, email string) (invite Invite, err error) {
+	if err = tx.Where("code = ?", code).First(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrInviteNotFound
+		}
+		return
+	}
+	err = invite.Usable(email)
+	return
+}
+
+// RedeemInvite consumes one use of the invite for the user, records the
+// redemption and, for team-bound invites, puts the user in that team. It is
+// meant to run in the same transaction that creates or activates the user.
+func RedeemInvite(tx *gorm.DB, code string, user *User, ip string) (invite Invite, err error) {
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", code).First(&invite).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrInviteNotFound
+		}
+		return
+	}
+	if err = invite.Usable(user.Email); err != nil {
+		return
+	}
+	if err = tx.Model(&invite).Update("uses", gorm.Expr("uses + 1")).Error; err != nil {
+		return
+	}
+	invite.Uses++
+	if err = tx.Create(&InviteRedemption{InviteID: invite.ID, UserID: user.ID, IP: ip}).Error; err != nil {
+		return
+	}
+	if invite.TeamID == nil || user.TeamID != nil {
+		return
+	}
+	if _, err = AddTeamMember(tx, *invite.TeamID, user); errors.Is(err, ErrTeamBanned) || errors.Is(err, ErrTeamLocked) || errors.Is(err, ErrTeamFull) {
+		err = ErrInviteTeamClosed
+	} else if errors.Is(err, ErrDivisionIneligible) {
+		err = ErrInviteIneligible
+	}
+	return
+}
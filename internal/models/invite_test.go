@@ -0,0 +1,28 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedeemInviteJoinsTeam(t *testing.T) {
+	testutil.SetupDB(t)
+	leader := testutil.CreateUser(t, "leader")
+	team := testutil.CreateTeam(t, models.DB, leader)
+	invite := models.Invite{MaxUses: 2, TeamID: &team.ID}
+	require.NoError(t, models.DB.Create(&invite).Error)
+
+	newcomer := testutil.CreateUser(t, "newcomer")
+	redeemed, err := models.RedeemInvite(models.DB, invite.Code, &newcomer, "127.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, 1, redeemed.Uses)
+	require.Equal(t, team.ID, *newcomer.TeamID)
+
+	// the team is now at the configured size of two
+	late := testutil.CreateUser(t, "late")
+	_, err = models.RedeemInvite(models.DB, invite.Code, &late, "127.0.0.1")
+	require.ErrorIs(t, err, models.ErrInviteTeamClosed)
+}
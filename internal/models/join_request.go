@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	JoinRequestPending   = "pending"
+	JoinRequestApproved  = "approved"
+	JoinRequestRejected  = "rejected"
+	JoinRequestCancelled = "cancelled"
+)
+
+var (
+	ErrJoinRequestNotFound = errors.New("join request not found")
+	ErrJoinRequestClosed   = errors.New("join request is no longer pending")
+)
+
+// TeamJoinRequest is a user asking to join a team whose policy is
+// JoinPolicyApproval. The leader approves or rejects it.
+type TeamJoinRequest struct {
+	gorm.Model
+	TeamID      uint   `json:"team_id" gorm:"index;not null"`
+	UserID      uint   `json:"user_id" gorm:"index;not null"`
+	Message     string `json:"message"`
+	Status      string `json:"status" gorm:"not null;default:pending"`
+	DecidedByID *uint  `json:"decided_by_id"`
+
+	User User `json:"-" gorm:"foreignKey:UserID"`
+	Team Team `json:"-" gorm:"foreignKey:TeamID"`
+}
+
+func (TeamJoinRequest) TableName() string {
+	return "team_join_requests"
+}
+
+// DecideJoinRequest approves or rejects a pending request to join the team.
+// An approval puts the requester in the team, subject to the same checks as
+// any other join.
+func DecideJoinRequest(tx *gorm.DB, id, teamID uint, approve bool, deciderID uint) (request TeamJoinRequest, err error) {
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND team_id = ?", id, teamID).First(&request).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrJoinRequestNotFound
+		}
+		return
+	}
+	if request.Status != JoinRequestPending {
+		err = ErrJoinRequestClosed
+		return
+	}
+	status := JoinRequestRejected
+	if approve {
+		if err = tx.First(&request.User, request.UserID).Error; err != nil {
+			return
+		}
+		if request.Team, err = AddTeamMember(tx, teamID, &request.User); err != nil {
+			return
+		}
+		status = JoinRequestApproved
+	}
+	err = tx.Model(&request).Updates(map[string]any{"status": status, "decided_by_id": deciderID}).Error
+	return
+}
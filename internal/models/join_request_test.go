@@ -0,0 +1,40 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecideJoinRequest(t *testing.T) {
+	testutil.SetupDB(t)
+	leader := testutil.CreateUser(t, "leader")
+	team := testutil.CreateTeam(t, models.DB, leader)
+	require.Equal(t, models.JoinPolicyCode, team.JoinPolicy)
+
+	ask := func(user models.User) models.TeamJoinRequest {
+		request := models.TeamJoinRequest{TeamID: team.ID, UserID: user.ID, Status: models.JoinRequestPending}
+		require.NoError(t, models.DB.Create(&request).Error)
+		return request
+	}
+	rejected, approved := ask(testutil.CreateUser(t, "rejected")), ask(testutil.CreateUser(t, "approved"))
+
+	request, err := models.DecideJoinRequest(models.DB, rejected.ID, team.ID, false, leader.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.JoinRequestRejected, request.Status)
+	_, err = models.DecideJoinRequest(models.DB, rejected.ID, team.ID, true, leader.ID)
+	require.ErrorIs(t, err, models.ErrJoinRequestClosed)
+
+	_, err = models.DecideJoinRequest(models.DB, approved.ID, team.ID+1, true, leader.ID)
+	require.ErrorIs(t, err, models.ErrJoinRequestNotFound)
+	request, err = models.DecideJoinRequest(models.DB, approved.ID, team.ID, true, leader.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.JoinRequestApproved, request.Status)
+	require.Equal(t, leader.ID, *request.DecidedByID)
+
+	var member models.User
+	require.NoError(t, models.DB.First(&member, approved.UserID).Error)
+	require.Equal(t, team.ID, *member.TeamID)
+}
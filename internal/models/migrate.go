@@ -0,0 +1,205 @@
+package models
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// SchemaMigration records an applied migration.
+type SchemaMigration struct {
+	Version   int       `json:"version" gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `json:"name" gorm:"not null"`
+	AppliedAt time.Time `json:"applied_at" gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migration is one versioned schema change, read from
+// migrations/<dialect>/<version>_<name>.{up,down}.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState is a known migration and whether it has been applied.
+type MigrationState struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// Migrations returns the migrations for the dialect of db, oldest first.
+func Migrations(db *gorm.DB) ([]Migration, error) {
+	dir := path.Join("migrations", db.Dialector.Name())
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %s", db.Dialector.Name())
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		file := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("unexpected migration file %s", file)
+		}
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration file %s has no version prefix", file)
+		}
+		body, err := fs.ReadFile(migrationFiles, path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func appliedMigrations(db *gorm.DB) (map[int]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// MigrationStatus lists every known migration with when it was applied.
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	migrations, err := Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]MigrationState, len(migrations))
+	for i, m := range migrations {
+		states[i] = MigrationState{Version: m.Version, Name: m.Name}
+		if row, ok := applied[m.Version]; ok {
+			states[i].AppliedAt = &row.AppliedAt
+		}
+	}
+	return states, nil
+}
+
+// PendingMigrations returns the migrations that have not been applied yet.
+func PendingMigrations(db *gorm.DB) ([]Migration, error) {
+	migrations, err := Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// MigrateUp applies every pending migration in order, each in its own
+// transaction, and returns the ones it applied.
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 && pending[0].Version == 1 && db.Dialector.Name() != "postgres" &&
+		db.Migrator().HasTable("users") {
+		// only the Postgres baseline is written to take over such a schema
+		return nil, fmt.Errorf("the database has tables but no migration history; adopting an existing schema is only supported on postgres")
+	}
+	for i, m := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// MigrateDown reverts the last steps applied migrations, newest first, and
+// returns the ones it reverted.
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	migrations, err := Migrations(db)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var reverted []Migration
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == "" {
+			return reverted, fmt.Errorf("migration %d_%s cannot be reverted", m.Version, m.Name)
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("reverting migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+		reverted = append(reverted, m)
+	}
+	return reverted, nil
+}
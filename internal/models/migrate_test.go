@@ -0,0 +1,33 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	testutil.SetupDB(t)
+
+	pending, err := models.PendingMigrations(models.DB)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	states, err := models.MigrationStatus(models.DB)
+	require.NoError(t, err)
+	require.NotEmpty(t, states)
+	for _, s := range states {
+		require.NotNil(t, s.AppliedAt, "migration %d not applied", s.Version)
+	}
+
+	reverted, err := models.MigrateDown(models.DB, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	require.Equal(t, states[len(states)-1].Version, reverted[0].Version)
+
+	applied, err := models.MigrateUp(models.DB)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+}
@@ -0,0 +1,218 @@
+-- MySQL commits DDL implicitly, so a failure part way leaves the tables
+-- created so far in place; drop them before retrying.
+
+CREATE TABLE `teams` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` longtext,
+    `code` varchar(191),
+    `ban` boolean DEFAULT false,
+    `blacklist` boolean DEFAULT false,
+    `leader_id` bigint unsigned NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_teams_deleted_at` (`deleted_at`),
+    CONSTRAINT `uni_teams_code` UNIQUE (`code`)
+);
+
+CREATE TABLE `users` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `username` varchar(191),
+    `password` longtext,
+    `email` varchar(191),
+    `avatar_url` varchar(191),
+    `active` boolean DEFAULT false,
+    `ban` boolean DEFAULT false,
+    `blacklist` boolean DEFAULT false,
+    `team_id` bigint unsigned,
+    `role` varchar(191) NOT NULL DEFAULT 'player',
+    `token_version` bigint unsigned NOT NULL DEFAULT 0,
+    PRIMARY KEY (`id`),
+    INDEX `idx_users_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_teams_members` FOREIGN KEY (`team_id`) REFERENCES `teams`(`id`),
+    CONSTRAINT `uni_users_username` UNIQUE (`username`),
+    CONSTRAINT `uni_users_email` UNIQUE (`email`),
+    CONSTRAINT `uni_users_avatar_url` UNIQUE (`avatar_url`)
+);
+
+-- leader_id is NOT NULL, so deleting a leader is refused until leadership is
+-- handed over.
+ALTER TABLE `teams`
+    ADD CONSTRAINT `fk_teams_leader`
+    FOREIGN KEY (`leader_id`) REFERENCES `users`(`id`)
+    ON UPDATE CASCADE ON DELETE RESTRICT;
+
+CREATE TABLE `ban_histories` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned,
+    `team_id` bigint unsigned,
+    `expires_at` bigint,
+    `context` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_ban_histories_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE `invites` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `code` varchar(191) NOT NULL,
+    `max_uses` bigint NOT NULL DEFAULT 1,
+    `uses` bigint NOT NULL DEFAULT 0,
+    `expires_at` datetime(3) NULL,
+    `email` longtext,
+    `team_id` bigint unsigned,
+    `created_by_id` bigint unsigned,
+    `revoked` boolean DEFAULT false,
+    PRIMARY KEY (`id`),
+    INDEX `idx_invites_deleted_at` (`deleted_at`),
+    INDEX `idx_invites_team_id` (`team_id`),
+    CONSTRAINT `uni_invites_code` UNIQUE (`code`)
+);
+
+CREATE TABLE `invite_redemptions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `invite_id` bigint unsigned NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `ip` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_invite_redemptions_deleted_at` (`deleted_at`),
+    INDEX `idx_invite_redemptions_invite_id` (`invite_id`),
+    INDEX `idx_invite_redemptions_user_id` (`user_id`),
+    CONSTRAINT `fk_invites_redemptions` FOREIGN KEY (`invite_id`) REFERENCES `invites`(`id`)
+);
+
+CREATE TABLE `feature_flags` (
+    `name` varchar(191),
+    `enabled` boolean,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`name`)
+);
+
+CREATE TABLE `admin_api_keys` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` varchar(191) NOT NULL,
+    `prefix` varchar(191) NOT NULL,
+    `hash` longtext NOT NULL,
+    `scopes` longtext,
+    `created_by_id` bigint unsigned,
+    `expires_at` datetime(3) NULL,
+    `last_used_at` datetime(3) NULL,
+    `revoked` boolean DEFAULT false,
+    PRIMARY KEY (`id`),
+    INDEX `idx_admin_api_keys_deleted_at` (`deleted_at`),
+    CONSTRAINT `uni_admin_api_keys_name` UNIQUE (`name`),
+    CONSTRAINT `uni_admin_api_keys_prefix` UNIQUE (`prefix`)
+);
+
+CREATE TABLE `audit_events` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NOT NULL,
+    `level` longtext,
+    `event` varchar(191),
+    `status` varchar(191),
+    `reason` longtext,
+    `actor` longtext,
+    `actor_id` bigint unsigned,
+    `target_user_id` bigint unsigned,
+    `team_id` bigint unsigned,
+    `ip` longtext,
+    `request_id` varchar(191),
+    `message` longtext,
+    `fields` text,
+    `prev_hash` longtext,
+    `hash` longtext NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_audit_events_created_at` (`created_at`),
+    INDEX `idx_audit_events_event` (`event`),
+    INDEX `idx_audit_events_status` (`status`),
+    INDEX `idx_audit_events_actor_id` (`actor_id`),
+    INDEX `idx_audit_events_target_user_id` (`target_user_id`),
+    INDEX `idx_audit_events_team_id` (`team_id`),
+    INDEX `idx_audit_events_request_id` (`request_id`)
+);
+
+CREATE TABLE `webhooks` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` longtext NOT NULL,
+    `url` longtext NOT NULL,
+    `secret` longtext NOT NULL,
+    `events` longtext,
+    `active` boolean DEFAULT true,
+    PRIMARY KEY (`id`),
+    INDEX `idx_webhooks_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE `webhook_deliveries` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `webhook_id` bigint unsigned NOT NULL,
+    `event_id` varchar(191) NOT NULL,
+    `event_type` longtext NOT NULL,
+    `payload` text,
+    `status` varchar(191) NOT NULL DEFAULT 'pending',
+    `attempts` bigint NOT NULL DEFAULT 0,
+    `next_attempt_at` datetime(3) NULL,
+    `last_status_code` bigint,
+    `last_error` longtext,
+    `delivered_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_webhook_deliveries_deleted_at` (`deleted_at`),
+    INDEX `idx_webhook_deliveries_webhook_id` (`webhook_id`),
+    INDEX `idx_webhook_deliveries_event_id` (`event_id`),
+    INDEX `idx_webhook_deliveries_status` (`status`),
+    INDEX `idx_webhook_deliveries_next_attempt_at` (`next_attempt_at`)
+);
+
+CREATE TABLE `user_oauth_meta` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `provider` longtext NOT NULL,
+    `provider_id` varchar(191) NOT NULL,
+    `access_token` text,
+    `refresh_token` text,
+    `expiry` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_oauth_meta_deleted_at` (`deleted_at`),
+    INDEX `idx_user_oauth_meta_user_id` (`user_id`),
+    CONSTRAINT `fk_user_oauth_meta_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `uni_user_oauth_meta_provider_id` UNIQUE (`provider_id`)
+);
+
+CREATE TABLE `user_totp_meta` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `backup_code` varchar(191),
+    `totp_secret` varchar(191),
+    `user_id` bigint unsigned NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_totp_meta_deleted_at` (`deleted_at`),
+    INDEX `idx_user_totp_meta_user_id` (`user_id`),
+    CONSTRAINT `fk_user_totp_meta_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `uni_user_totp_meta_backup_code` UNIQUE (`backup_code`),
+    CONSTRAINT `uni_user_totp_meta_totp_secret` UNIQUE (`totp_secret`)
+);
@@ -0,0 +1,15 @@
+CREATE TABLE `team_invitations` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `team_id` bigint unsigned NOT NULL,
+    `invitee_id` bigint unsigned NOT NULL,
+    `inviter_id` bigint unsigned NOT NULL,
+    `status` varchar(191) NOT NULL DEFAULT 'pending',
+    `expires_at` datetime(3) NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_team_invitations_deleted_at` (`deleted_at`),
+    INDEX `idx_team_invitations_team_id` (`team_id`),
+    INDEX `idx_team_invitations_invitee_id` (`invitee_id`)
+);
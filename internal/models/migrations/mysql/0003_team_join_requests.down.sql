@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `team_join_requests`;
+ALTER TABLE `teams` DROP COLUMN `join_policy`;
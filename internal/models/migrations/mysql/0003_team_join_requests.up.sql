@@ -0,0 +1,17 @@
+ALTER TABLE `teams` ADD COLUMN `join_policy` varchar(191) NOT NULL DEFAULT 'code';
+
+CREATE TABLE `team_join_requests` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `team_id` bigint unsigned NOT NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `message` longtext,
+    `status` varchar(191) NOT NULL DEFAULT 'pending',
+    `decided_by_id` bigint unsigned,
+    PRIMARY KEY (`id`),
+    INDEX `idx_team_join_requests_deleted_at` (`deleted_at`),
+    INDEX `idx_team_join_requests_team_id` (`team_id`),
+    INDEX `idx_team_join_requests_user_id` (`user_id`)
+);
@@ -0,0 +1 @@
+ALTER TABLE `teams` ADD COLUMN `locked` boolean DEFAULT false;
@@ -0,0 +1 @@
+ALTER TABLE `users` ADD COLUMN `team_role` varchar(191) NOT NULL DEFAULT 'member';
@@ -0,0 +1,3 @@
+DROP INDEX `idx_teams_division_id` ON `teams`;
+ALTER TABLE `teams` DROP COLUMN `division_id`;
+DROP TABLE IF EXISTS `divisions`;
@@ -0,0 +1,17 @@
+CREATE TABLE `divisions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `name` varchar(191) NOT NULL,
+    `description` longtext,
+    `team_size` bigint NOT NULL DEFAULT 0,
+    `email_regex` longtext,
+    `oauth_providers` longtext,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `uni_divisions_name` UNIQUE (`name`),
+    INDEX `idx_divisions_deleted_at` (`deleted_at`)
+);
+
+ALTER TABLE `teams` ADD COLUMN `division_id` bigint unsigned;
+CREATE INDEX `idx_teams_division_id` ON `teams`(`division_id`);
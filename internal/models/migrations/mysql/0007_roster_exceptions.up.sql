@@ -0,0 +1,15 @@
+CREATE TABLE `roster_exceptions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned NOT NULL,
+    `team_id` bigint unsigned,
+    `reason` longtext NOT NULL,
+    `granted_by` longtext,
+    `expires_at` datetime(3) NOT NULL,
+    `revoked` boolean DEFAULT false,
+    PRIMARY KEY (`id`),
+    INDEX `idx_roster_exceptions_deleted_at` (`deleted_at`),
+    INDEX `idx_roster_exceptions_user_id` (`user_id`)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE `users` ADD COLUMN `hide_email` boolean NOT NULL DEFAULT true;
+ALTER TABLE `users` ADD COLUMN `hide_team` boolean NOT NULL DEFAULT false;
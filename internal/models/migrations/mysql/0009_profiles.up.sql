@@ -0,0 +1,9 @@
+ALTER TABLE `users` ADD COLUMN `country` varchar(2);
+ALTER TABLE `users` ADD COLUMN `affiliation` varchar(128);
+ALTER TABLE `users` ADD COLUMN `website` varchar(255);
+ALTER TABLE `users` ADD COLUMN `bio` varchar(500);
+
+ALTER TABLE `teams` ADD COLUMN `country` varchar(2);
+ALTER TABLE `teams` ADD COLUMN `affiliation` varchar(128);
+ALTER TABLE `teams` ADD COLUMN `website` varchar(255);
+ALTER TABLE `teams` ADD COLUMN `bio` varchar(500);
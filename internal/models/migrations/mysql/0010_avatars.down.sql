@@ -0,0 +1,6 @@
+ALTER TABLE `teams` DROP COLUMN `avatar_key`;
+ALTER TABLE `users` DROP COLUMN `avatar_key`;
+
+-- fails if users now share an avatar
+UPDATE `users` SET `avatar_url` = NULL WHERE `avatar_url` = '';
+ALTER TABLE `users` ADD CONSTRAINT `uni_users_avatar_url` UNIQUE (`avatar_url`);
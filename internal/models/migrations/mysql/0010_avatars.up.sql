@@ -0,0 +1,5 @@
+ALTER TABLE `users` DROP INDEX `uni_users_avatar_url`;
+UPDATE `users` SET `avatar_url` = NULL WHERE `avatar_url` = '';
+
+ALTER TABLE `users` ADD COLUMN `avatar_key` varchar(191);
+ALTER TABLE `teams` ADD COLUMN `avatar_key` varchar(191);
@@ -0,0 +1,2 @@
+DROP INDEX `idx_users_delete_after` ON `users`;
+ALTER TABLE `users` DROP COLUMN `delete_after`;
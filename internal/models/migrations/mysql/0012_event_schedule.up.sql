@@ -0,0 +1,11 @@
+CREATE TABLE `event_schedules` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `signup_open` datetime(3) NULL,
+    `signup_close` datetime(3) NULL,
+    `login_open` datetime(3) NULL,
+    `login_close` datetime(3) NULL,
+    `roster_freeze` datetime(3) NULL,
+    `event_end` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE `audit_chain_locks` (
+    `id` bigint unsigned,
+    `locked_at` datetime(3) NULL,
+    PRIMARY KEY (`id`)
+);
+INSERT INTO `audit_chain_locks` (`id`) VALUES (1);
@@ -0,0 +1,9 @@
+-- Deleted divisions may share a name now; keep only the latest row per name
+-- so that the name can be unique again.
+DELETE d FROM `divisions` d JOIN `divisions` o
+    ON o.`name` = d.`name` AND o.`id` <> d.`id` AND (o.`deleted_at` IS NULL OR o.`id` > d.`id`)
+    WHERE d.`deleted_at` IS NOT NULL;
+ALTER TABLE `divisions`
+    DROP INDEX `uni_divisions_name`,
+    DROP COLUMN `live_name`,
+    ADD CONSTRAINT `uni_divisions_name` UNIQUE (`name`);
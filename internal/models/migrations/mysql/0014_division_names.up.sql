@@ -0,0 +1,6 @@
+-- MySQL has no partial indexes: a generated column holds the name of live
+-- divisions only, and NULLs do not clash in a unique index.
+ALTER TABLE `divisions`
+    DROP INDEX `uni_divisions_name`,
+    ADD COLUMN `live_name` varchar(191) GENERATED ALWAYS AS (IF(`deleted_at` IS NULL, `name`, NULL)) STORED,
+    ADD CONSTRAINT `uni_divisions_name` UNIQUE (`live_name`);
@@ -0,0 +1 @@
+ALTER TABLE `audit_events` ADD COLUMN `redacted` boolean NOT NULL DEFAULT false;
@@ -0,0 +1,227 @@
+-- Baseline schema. Every statement is idempotent so that databases created
+-- by the old AutoMigrate-on-boot can adopt versioned migrations in place.
+
+CREATE TABLE IF NOT EXISTS "teams" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "code" text,
+    "ban" boolean DEFAULT false,
+    "blacklist" boolean DEFAULT false,
+    "leader_id" bigint NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_teams_code" UNIQUE ("code")
+);
+CREATE INDEX IF NOT EXISTS "idx_teams_deleted_at" ON "teams" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "username" text,
+    "password" text,
+    "email" text,
+    "avatar_url" text,
+    "active" boolean DEFAULT false,
+    "ban" boolean DEFAULT false,
+    "blacklist" boolean DEFAULT false,
+    "team_id" bigint,
+    "role" text NOT NULL DEFAULT 'player',
+    "token_version" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_teams_members" FOREIGN KEY ("team_id") REFERENCES "teams"("id"),
+    CONSTRAINT "uni_users_username" UNIQUE ("username"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email"),
+    CONSTRAINT "uni_users_avatar_url" UNIQUE ("avatar_url")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+-- Columns added to users while the schema was still auto-migrated. A
+-- database last migrated before them gets them here, the same as a fresh one.
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "role" text NOT NULL DEFAULT 'player';
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "token_version" bigint NOT NULL DEFAULT 0;
+
+-- leader_id is NOT NULL, so a leader cannot be deleted out from under a team;
+-- leadership is handed over first. Recreated so that an adopted database gets
+-- the same rule.
+ALTER TABLE "teams" DROP CONSTRAINT IF EXISTS "fk_teams_leader";
+ALTER TABLE "teams"
+    ADD CONSTRAINT "fk_teams_leader"
+    FOREIGN KEY ("leader_id") REFERENCES "users"("id")
+    ON UPDATE CASCADE ON DELETE RESTRICT;
+
+CREATE TABLE IF NOT EXISTS "ban_histories" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint,
+    "team_id" bigint,
+    "expires_at" bigint,
+    "context" text,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_ban_histories_deleted_at" ON "ban_histories" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "invites" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "code" text NOT NULL,
+    "max_uses" bigint NOT NULL DEFAULT 1,
+    "uses" bigint NOT NULL DEFAULT 0,
+    "expires_at" timestamptz,
+    "email" text,
+    "team_id" bigint,
+    "created_by_id" bigint,
+    "revoked" boolean DEFAULT false,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_invites_code" UNIQUE ("code")
+);
+CREATE INDEX IF NOT EXISTS "idx_invites_team_id" ON "invites" ("team_id");
+CREATE INDEX IF NOT EXISTS "idx_invites_deleted_at" ON "invites" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "invite_redemptions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "invite_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "ip" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_invites_redemptions" FOREIGN KEY ("invite_id") REFERENCES "invites"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_invite_redemptions_user_id" ON "invite_redemptions" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_invite_redemptions_invite_id" ON "invite_redemptions" ("invite_id");
+CREATE INDEX IF NOT EXISTS "idx_invite_redemptions_deleted_at" ON "invite_redemptions" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "feature_flags" (
+    "name" text,
+    "enabled" boolean,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "admin_api_keys" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text NOT NULL,
+    "prefix" text NOT NULL,
+    "hash" text NOT NULL,
+    "scopes" text,
+    "created_by_id" bigint,
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    "revoked" boolean DEFAULT false,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_admin_api_keys_name" UNIQUE ("name"),
+    CONSTRAINT "uni_admin_api_keys_prefix" UNIQUE ("prefix")
+);
+CREATE INDEX IF NOT EXISTS "idx_admin_api_keys_deleted_at" ON "admin_api_keys" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "audit_events" (
+    "id" bigserial,
+    "created_at" timestamptz NOT NULL,
+    "level" text,
+    "event" text,
+    "status" text,
+    "reason" text,
+    "actor" text,
+    "actor_id" bigint,
+    "target_user_id" bigint,
+    "team_id" bigint,
+    "ip" text,
+    "request_id" text,
+    "message" text,
+    "fields" text,
+    "prev_hash" text,
+    "hash" text NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_events_request_id" ON "audit_events" ("request_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_team_id" ON "audit_events" ("team_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_target_user_id" ON "audit_events" ("target_user_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_actor_id" ON "audit_events" ("actor_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_status" ON "audit_events" ("status");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_event" ON "audit_events" ("event");
+CREATE INDEX IF NOT EXISTS "idx_audit_events_created_at" ON "audit_events" ("created_at");
+
+CREATE TABLE IF NOT EXISTS "webhooks" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text NOT NULL,
+    "url" text NOT NULL,
+    "secret" text NOT NULL,
+    "events" text,
+    "active" boolean DEFAULT true,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhooks_deleted_at" ON "webhooks" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "webhook_id" bigint NOT NULL,
+    "event_id" text NOT NULL,
+    "event_type" text NOT NULL,
+    "payload" text,
+    "status" text NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz,
+    "last_status_code" bigint,
+    "last_error" text,
+    "delivered_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_next_attempt_at" ON "webhook_deliveries" ("next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_status" ON "webhook_deliveries" ("status");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_event_id" ON "webhook_deliveries" ("event_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_deleted_at" ON "webhook_deliveries" ("deleted_at");
+
+-- OAuth and TOTP tables are created unconditionally so that enabling either
+-- feature later needs no schema change.
+CREATE TABLE IF NOT EXISTS "user_oauth_meta" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "provider" text NOT NULL,
+    "provider_id" text NOT NULL,
+    "access_token" text,
+    "refresh_token" text,
+    "expiry" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_oauth_meta_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "uni_user_oauth_meta_provider_id" UNIQUE ("provider_id")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_oauth_meta_user_id" ON "user_oauth_meta" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_user_oauth_meta_deleted_at" ON "user_oauth_meta" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "user_totp_meta" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "backup_code" text,
+    "totp_secret" text,
+    "user_id" bigint NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_totp_meta_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "uni_user_totp_meta_backup_code" UNIQUE ("backup_code"),
+    CONSTRAINT "uni_user_totp_meta_totp_secret" UNIQUE ("totp_secret")
+);
+CREATE INDEX IF NOT EXISTS "idx_user_totp_meta_user_id" ON "user_totp_meta" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_user_totp_meta_deleted_at" ON "user_totp_meta" ("deleted_at");
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "team_invitations" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "team_id" bigint NOT NULL,
+    "invitee_id" bigint NOT NULL,
+    "inviter_id" bigint NOT NULL,
+    "status" text NOT NULL DEFAULT 'pending',
+    "expires_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_team_invitations_team_id" ON "team_invitations" ("team_id");
+CREATE INDEX IF NOT EXISTS "idx_team_invitations_invitee_id" ON "team_invitations" ("invitee_id");
+CREATE INDEX IF NOT EXISTS "idx_team_invitations_deleted_at" ON "team_invitations" ("deleted_at");
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "team_join_requests";
+ALTER TABLE "teams" DROP COLUMN IF EXISTS "join_policy";
@@ -0,0 +1,17 @@
+ALTER TABLE "teams" ADD COLUMN IF NOT EXISTS "join_policy" text NOT NULL DEFAULT 'code';
+
+CREATE TABLE IF NOT EXISTS "team_join_requests" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "team_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "message" text,
+    "status" text NOT NULL DEFAULT 'pending',
+    "decided_by_id" bigint,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_team_join_requests_team_id" ON "team_join_requests" ("team_id");
+CREATE INDEX IF NOT EXISTS "idx_team_join_requests_user_id" ON "team_join_requests" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_team_join_requests_deleted_at" ON "team_join_requests" ("deleted_at");
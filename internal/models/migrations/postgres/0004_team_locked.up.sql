@@ -0,0 +1 @@
+ALTER TABLE "teams" ADD COLUMN IF NOT EXISTS "locked" boolean DEFAULT false;
@@ -0,0 +1 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "team_role" text NOT NULL DEFAULT 'member';
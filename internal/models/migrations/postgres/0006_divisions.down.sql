@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "idx_teams_division_id";
+ALTER TABLE "teams" DROP COLUMN IF EXISTS "division_id";
+DROP TABLE IF EXISTS "divisions";
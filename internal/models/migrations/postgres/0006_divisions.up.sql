@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "divisions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text NOT NULL,
+    "description" text,
+    "team_size" bigint NOT NULL DEFAULT 0,
+    "email_regex" text,
+    "oauth_providers" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_divisions_name" UNIQUE ("name")
+);
+CREATE INDEX IF NOT EXISTS "idx_divisions_deleted_at" ON "divisions" ("deleted_at");
+
+ALTER TABLE "teams" ADD COLUMN IF NOT EXISTS "division_id" bigint;
+CREATE INDEX IF NOT EXISTS "idx_teams_division_id" ON "teams" ("division_id");
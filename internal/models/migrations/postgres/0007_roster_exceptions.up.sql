@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "roster_exceptions" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "user_id" bigint NOT NULL,
+    "team_id" bigint,
+    "reason" text NOT NULL,
+    "granted_by" text,
+    "expires_at" timestamptz NOT NULL,
+    "revoked" boolean DEFAULT false,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_roster_exceptions_user_id" ON "roster_exceptions" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_roster_exceptions_deleted_at" ON "roster_exceptions" ("deleted_at");
@@ -0,0 +1,2 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "hide_team";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "hide_email";
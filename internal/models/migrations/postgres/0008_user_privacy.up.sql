@@ -0,0 +1,2 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "hide_email" boolean NOT NULL DEFAULT true;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "hide_team" boolean NOT NULL DEFAULT false;
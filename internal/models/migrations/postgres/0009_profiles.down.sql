@@ -0,0 +1,9 @@
+ALTER TABLE "teams" DROP COLUMN IF EXISTS "bio";
+ALTER TABLE "teams" DROP COLUMN IF EXISTS "website";
+ALTER TABLE "teams" DROP COLUMN IF EXISTS "affiliation";
+ALTER TABLE "teams" DROP COLUMN IF EXISTS "country";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "bio";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "website";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "affiliation";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "country";
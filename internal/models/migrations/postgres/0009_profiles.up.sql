@@ -0,0 +1,9 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "country" varchar(2);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "affiliation" varchar(128);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "website" varchar(255);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "bio" varchar(500);
+
+ALTER TABLE "teams" ADD COLUMN IF NOT EXISTS "country" varchar(2);
+ALTER TABLE "teams" ADD COLUMN IF NOT EXISTS "affiliation" varchar(128);
+ALTER TABLE "teams" ADD COLUMN IF NOT EXISTS "website" varchar(255);
+ALTER TABLE "teams" ADD COLUMN IF NOT EXISTS "bio" varchar(500);
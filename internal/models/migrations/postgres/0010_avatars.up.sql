@@ -0,0 +1,5 @@
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "uni_users_avatar_url";
+UPDATE "users" SET "avatar_url" = NULL WHERE "avatar_url" = '';
+
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "avatar_key" text;
+ALTER TABLE "teams" ADD COLUMN IF NOT EXISTS "avatar_key" text;
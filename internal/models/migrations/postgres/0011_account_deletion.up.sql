@@ -0,0 +1,2 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "delete_after" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_users_delete_after" ON "users" ("delete_after");
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "event_schedules" (
+    "id" bigserial,
+    "signup_open" timestamptz,
+    "signup_close" timestamptz,
+    "login_open" timestamptz,
+    "login_close" timestamptz,
+    "roster_freeze" timestamptz,
+    "event_end" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS "audit_chain_locks" (
+    "id" bigint,
+    "locked_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+INSERT INTO "audit_chain_locks" ("id") VALUES (1) ON CONFLICT DO NOTHING;
@@ -0,0 +1,7 @@
+-- Deleted divisions may share a name now; keep only the latest row per name
+-- so that the name can be unique again.
+DELETE FROM "divisions" d USING "divisions" o
+    WHERE d."deleted_at" IS NOT NULL AND o."name" = d."name" AND o."id" <> d."id"
+    AND (o."deleted_at" IS NULL OR o."id" > d."id");
+DROP INDEX IF EXISTS "uni_divisions_name";
+ALTER TABLE "divisions" ADD CONSTRAINT "uni_divisions_name" UNIQUE ("name");
@@ -0,0 +1,2 @@
+ALTER TABLE "divisions" DROP CONSTRAINT IF EXISTS "uni_divisions_name";
+CREATE UNIQUE INDEX IF NOT EXISTS "uni_divisions_name" ON "divisions" ("name") WHERE "deleted_at" IS NULL;
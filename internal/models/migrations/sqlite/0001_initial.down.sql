@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS `user_totp_meta`;
+DROP TABLE IF EXISTS `user_oauth_meta`;
+DROP TABLE IF EXISTS `webhook_deliveries`;
+DROP TABLE IF EXISTS `webhooks`;
+DROP TABLE IF EXISTS `audit_events`;
+DROP TABLE IF EXISTS `admin_api_keys`;
+DROP TABLE IF EXISTS `feature_flags`;
+DROP TABLE IF EXISTS `invite_redemptions`;
+DROP TABLE IF EXISTS `invites`;
+DROP TABLE IF EXISTS `ban_histories`;
+-- users and teams reference each other; dropping a table deletes its rows
+-- first, so break the membership links before either goes
+UPDATE `users` SET `team_id` = NULL;
+DROP TABLE IF EXISTS `teams`;
+DROP TABLE IF EXISTS `users`;
@@ -0,0 +1,202 @@
+-- SQLite cannot add constraints to an existing table, so the leader foreign
+-- key is declared inline; it is only enforced once users exists. leader_id is
+-- NOT NULL, so deleting a leader is refused until leadership is handed over.
+CREATE TABLE `teams` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text,
+    `code` text,
+    `ban` numeric DEFAULT false,
+    `blacklist` numeric DEFAULT false,
+    `leader_id` integer NOT NULL,
+    CONSTRAINT `uni_teams_code` UNIQUE (`code`),
+    CONSTRAINT `fk_teams_leader` FOREIGN KEY (`leader_id`) REFERENCES `users`(`id`)
+        ON UPDATE CASCADE ON DELETE RESTRICT
+);
+CREATE INDEX `idx_teams_deleted_at` ON `teams`(`deleted_at`);
+
+CREATE TABLE `users` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `username` text,
+    `password` text,
+    `email` text,
+    `avatar_url` text,
+    `active` numeric DEFAULT false,
+    `ban` numeric DEFAULT false,
+    `blacklist` numeric DEFAULT false,
+    `team_id` integer,
+    `role` text NOT NULL DEFAULT 'player',
+    `token_version` integer NOT NULL DEFAULT 0,
+    CONSTRAINT `fk_teams_members` FOREIGN KEY (`team_id`) REFERENCES `teams`(`id`),
+    CONSTRAINT `uni_users_username` UNIQUE (`username`),
+    CONSTRAINT `uni_users_email` UNIQUE (`email`),
+    CONSTRAINT `uni_users_avatar_url` UNIQUE (`avatar_url`)
+);
+CREATE INDEX `idx_users_deleted_at` ON `users`(`deleted_at`);
+
+CREATE TABLE `ban_histories` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `user_id` integer,
+    `team_id` integer,
+    `expires_at` integer,
+    `context` text
+);
+CREATE INDEX `idx_ban_histories_deleted_at` ON `ban_histories`(`deleted_at`);
+
+CREATE TABLE `invites` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `code` text NOT NULL,
+    `max_uses` integer NOT NULL DEFAULT 1,
+    `uses` integer NOT NULL DEFAULT 0,
+    `expires_at` datetime,
+    `email` text,
+    `team_id` integer,
+    `created_by_id` integer,
+    `revoked` numeric DEFAULT false,
+    CONSTRAINT `uni_invites_code` UNIQUE (`code`)
+);
+CREATE INDEX `idx_invites_team_id` ON `invites`(`team_id`);
+CREATE INDEX `idx_invites_deleted_at` ON `invites`(`deleted_at`);
+
+CREATE TABLE `invite_redemptions` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `invite_id` integer NOT NULL,
+    `user_id` integer NOT NULL,
+    `ip` text,
+    CONSTRAINT `fk_invites_redemptions` FOREIGN KEY (`invite_id`) REFERENCES `invites`(`id`)
+);
+CREATE INDEX `idx_invite_redemptions_user_id` ON `invite_redemptions`(`user_id`);
+CREATE INDEX `idx_invite_redemptions_invite_id` ON `invite_redemptions`(`invite_id`);
+CREATE INDEX `idx_invite_redemptions_deleted_at` ON `invite_redemptions`(`deleted_at`);
+
+CREATE TABLE `feature_flags` (
+    `name` text,
+    `enabled` numeric,
+    `updated_at` datetime,
+    PRIMARY KEY (`name`)
+);
+
+CREATE TABLE `admin_api_keys` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text NOT NULL,
+    `prefix` text NOT NULL,
+    `hash` text NOT NULL,
+    `scopes` text,
+    `created_by_id` integer,
+    `expires_at` datetime,
+    `last_used_at` datetime,
+    `revoked` numeric DEFAULT false,
+    CONSTRAINT `uni_admin_api_keys_name` UNIQUE (`name`),
+    CONSTRAINT `uni_admin_api_keys_prefix` UNIQUE (`prefix`)
+);
+CREATE INDEX `idx_admin_api_keys_deleted_at` ON `admin_api_keys`(`deleted_at`);
+
+CREATE TABLE `audit_events` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime NOT NULL,
+    `level` text,
+    `event` text,
+    `status` text,
+    `reason` text,
+    `actor` text,
+    `actor_id` integer,
+    `target_user_id` integer,
+    `team_id` integer,
+    `ip` text,
+    `request_id` text,
+    `message` text,
+    `fields` text,
+    `prev_hash` text,
+    `hash` text NOT NULL
+);
+CREATE INDEX `idx_audit_events_request_id` ON `audit_events`(`request_id`);
+CREATE INDEX `idx_audit_events_team_id` ON `audit_events`(`team_id`);
+CREATE INDEX `idx_audit_events_target_user_id` ON `audit_events`(`target_user_id`);
+CREATE INDEX `idx_audit_events_actor_id` ON `audit_events`(`actor_id`);
+CREATE INDEX `idx_audit_events_status` ON `audit_events`(`status`);
+CREATE INDEX `idx_audit_events_event` ON `audit_events`(`event`);
+CREATE INDEX `idx_audit_events_created_at` ON `audit_events`(`created_at`);
+
+CREATE TABLE `webhooks` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text NOT NULL,
+    `url` text NOT NULL,
+    `secret` text NOT NULL,
+    `events` text,
+    `active` numeric DEFAULT true
+);
+CREATE INDEX `idx_webhooks_deleted_at` ON `webhooks`(`deleted_at`);
+
+CREATE TABLE `webhook_deliveries` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `webhook_id` integer NOT NULL,
+    `event_id` text NOT NULL,
+    `event_type` text NOT NULL,
+    `payload` text,
+    `status` text NOT NULL DEFAULT 'pending',
+    `attempts` integer NOT NULL DEFAULT 0,
+    `next_attempt_at` datetime,
+    `last_status_code` integer,
+    `last_error` text,
+    `delivered_at` datetime
+);
+CREATE INDEX `idx_webhook_deliveries_next_attempt_at` ON `webhook_deliveries`(`next_attempt_at`);
+CREATE INDEX `idx_webhook_deliveries_status` ON `webhook_deliveries`(`status`);
+CREATE INDEX `idx_webhook_deliveries_event_id` ON `webhook_deliveries`(`event_id`);
+CREATE INDEX `idx_webhook_deliveries_webhook_id` ON `webhook_deliveries`(`webhook_id`);
+CREATE INDEX `idx_webhook_deliveries_deleted_at` ON `webhook_deliveries`(`deleted_at`);
+
+CREATE TABLE `user_oauth_meta` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `user_id` integer NOT NULL,
+    `provider` text NOT NULL,
+    `provider_id` text NOT NULL,
+    `access_token` text,
+    `refresh_token` text,
+    `expiry` datetime,
+    CONSTRAINT `fk_user_oauth_meta_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `uni_user_oauth_meta_provider_id` UNIQUE (`provider_id`)
+);
+CREATE INDEX `idx_user_oauth_meta_user_id` ON `user_oauth_meta`(`user_id`);
+CREATE INDEX `idx_user_oauth_meta_deleted_at` ON `user_oauth_meta`(`deleted_at`);
+
+CREATE TABLE `user_totp_meta` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `backup_code` text,
+    `totp_secret` text,
+    `user_id` integer NOT NULL,
+    CONSTRAINT `fk_user_totp_meta_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+    CONSTRAINT `uni_user_totp_meta_backup_code` UNIQUE (`backup_code`),
+    CONSTRAINT `uni_user_totp_meta_totp_secret` UNIQUE (`totp_secret`)
+);
+CREATE INDEX `idx_user_totp_meta_user_id` ON `user_totp_meta`(`user_id`);
+CREATE INDEX `idx_user_totp_meta_deleted_at` ON `user_totp_meta`(`deleted_at`);
@@ -0,0 +1,14 @@
+CREATE TABLE `team_invitations` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `team_id` integer NOT NULL,
+    `invitee_id` integer NOT NULL,
+    `inviter_id` integer NOT NULL,
+    `status` text NOT NULL DEFAULT 'pending',
+    `expires_at` datetime NOT NULL
+);
+CREATE INDEX `idx_team_invitations_team_id` ON `team_invitations`(`team_id`);
+CREATE INDEX `idx_team_invitations_invitee_id` ON `team_invitations`(`invitee_id`);
+CREATE INDEX `idx_team_invitations_deleted_at` ON `team_invitations`(`deleted_at`);
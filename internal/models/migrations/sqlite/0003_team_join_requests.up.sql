@@ -0,0 +1,16 @@
+ALTER TABLE `teams` ADD COLUMN `join_policy` text NOT NULL DEFAULT 'code';
+
+CREATE TABLE `team_join_requests` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `team_id` integer NOT NULL,
+    `user_id` integer NOT NULL,
+    `message` text,
+    `status` text NOT NULL DEFAULT 'pending',
+    `decided_by_id` integer
+);
+CREATE INDEX `idx_team_join_requests_team_id` ON `team_join_requests`(`team_id`);
+CREATE INDEX `idx_team_join_requests_user_id` ON `team_join_requests`(`user_id`);
+CREATE INDEX `idx_team_join_requests_deleted_at` ON `team_join_requests`(`deleted_at`);
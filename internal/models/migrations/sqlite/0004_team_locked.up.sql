@@ -0,0 +1 @@
+ALTER TABLE `teams` ADD COLUMN `locked` numeric DEFAULT false;
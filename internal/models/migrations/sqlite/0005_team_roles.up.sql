@@ -0,0 +1 @@
+ALTER TABLE `users` ADD COLUMN `team_role` text NOT NULL DEFAULT 'member';
@@ -0,0 +1,16 @@
+CREATE TABLE `divisions` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text NOT NULL,
+    `description` text,
+    `team_size` integer NOT NULL DEFAULT 0,
+    `email_regex` text,
+    `oauth_providers` text,
+    CONSTRAINT `uni_divisions_name` UNIQUE (`name`)
+);
+CREATE INDEX `idx_divisions_deleted_at` ON `divisions`(`deleted_at`);
+
+ALTER TABLE `teams` ADD COLUMN `division_id` integer;
+CREATE INDEX `idx_teams_division_id` ON `teams`(`division_id`);
@@ -0,0 +1,14 @@
+CREATE TABLE `roster_exceptions` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `user_id` integer NOT NULL,
+    `team_id` integer,
+    `reason` text NOT NULL,
+    `granted_by` text,
+    `expires_at` datetime NOT NULL,
+    `revoked` numeric DEFAULT false
+);
+CREATE INDEX `idx_roster_exceptions_user_id` ON `roster_exceptions`(`user_id`);
+CREATE INDEX `idx_roster_exceptions_deleted_at` ON `roster_exceptions`(`deleted_at`);
@@ -0,0 +1,2 @@
+ALTER TABLE `users` DROP COLUMN `hide_team`;
+ALTER TABLE `users` DROP COLUMN `hide_email`;
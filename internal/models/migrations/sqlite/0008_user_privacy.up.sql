@@ -0,0 +1,2 @@
+ALTER TABLE `users` ADD COLUMN `hide_email` numeric NOT NULL DEFAULT true;
+ALTER TABLE `users` ADD COLUMN `hide_team` numeric NOT NULL DEFAULT false;
@@ -0,0 +1,9 @@
+ALTER TABLE `teams` DROP COLUMN `bio`;
+ALTER TABLE `teams` DROP COLUMN `website`;
+ALTER TABLE `teams` DROP COLUMN `affiliation`;
+ALTER TABLE `teams` DROP COLUMN `country`;
+
+ALTER TABLE `users` DROP COLUMN `bio`;
+ALTER TABLE `users` DROP COLUMN `website`;
+ALTER TABLE `users` DROP COLUMN `affiliation`;
+ALTER TABLE `users` DROP COLUMN `country`;
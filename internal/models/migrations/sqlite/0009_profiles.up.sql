@@ -0,0 +1,9 @@
+ALTER TABLE `users` ADD COLUMN `country` text;
+ALTER TABLE `users` ADD COLUMN `affiliation` text;
+ALTER TABLE `users` ADD COLUMN `website` text;
+ALTER TABLE `users` ADD COLUMN `bio` text;
+
+ALTER TABLE `teams` ADD COLUMN `country` text;
+ALTER TABLE `teams` ADD COLUMN `affiliation` text;
+ALTER TABLE `teams` ADD COLUMN `website` text;
+ALTER TABLE `teams` ADD COLUMN `bio` text;
@@ -0,0 +1,7 @@
+ALTER TABLE `teams` DROP COLUMN `avatar_key`;
+ALTER TABLE `users` DROP COLUMN `avatar_key`;
+
+-- fails if users now share an avatar
+UPDATE `users` SET `legacy_avatar_url` = NULLIF(`avatar_url`, '');
+ALTER TABLE `users` DROP COLUMN `avatar_url`;
+ALTER TABLE `users` RENAME COLUMN `legacy_avatar_url` TO `avatar_url`;
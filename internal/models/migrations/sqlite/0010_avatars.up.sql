@@ -0,0 +1,10 @@
+-- SQLite cannot drop the unique constraint on avatar_url without rebuilding
+-- users, which the foreign keys pointing at it make unsafe inside a
+-- transaction. The constrained column is renamed and left empty instead, and
+-- a fresh avatar_url takes over its data.
+ALTER TABLE `users` RENAME COLUMN `avatar_url` TO `legacy_avatar_url`;
+ALTER TABLE `users` ADD COLUMN `avatar_url` text;
+UPDATE `users` SET `avatar_url` = NULLIF(`legacy_avatar_url`, ''), `legacy_avatar_url` = NULL;
+
+ALTER TABLE `users` ADD COLUMN `avatar_key` text;
+ALTER TABLE `teams` ADD COLUMN `avatar_key` text;
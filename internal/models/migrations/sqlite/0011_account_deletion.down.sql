@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_users_delete_after`;
+ALTER TABLE `users` DROP COLUMN `delete_after`;
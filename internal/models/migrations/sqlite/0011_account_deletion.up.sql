@@ -0,0 +1,2 @@
+ALTER TABLE `users` ADD COLUMN `delete_after` datetime;
+CREATE INDEX `idx_users_delete_after` ON `users`(`delete_after`);
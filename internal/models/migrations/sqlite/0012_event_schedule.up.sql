@@ -0,0 +1,10 @@
+CREATE TABLE `event_schedules` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `signup_open` datetime,
+    `signup_close` datetime,
+    `login_open` datetime,
+    `login_close` datetime,
+    `roster_freeze` datetime,
+    `event_end` datetime,
+    `updated_at` datetime
+);
@@ -0,0 +1,5 @@
+CREATE TABLE `audit_chain_locks` (
+    `id` integer PRIMARY KEY,
+    `locked_at` datetime
+);
+INSERT INTO `audit_chain_locks` (`id`) VALUES (1);
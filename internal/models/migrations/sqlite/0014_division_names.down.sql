@@ -0,0 +1,23 @@
+-- Deleted divisions may share a name now; keep only the latest row per name
+-- so that the name can be unique again.
+DELETE FROM `divisions` WHERE `deleted_at` IS NOT NULL AND EXISTS (
+    SELECT 1 FROM `divisions` o WHERE o.`name` = `divisions`.`name` AND o.`id` <> `divisions`.`id`
+    AND (o.`deleted_at` IS NULL OR o.`id` > `divisions`.`id`)
+);
+CREATE TABLE `divisions_old` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text NOT NULL,
+    `description` text,
+    `team_size` integer NOT NULL DEFAULT 0,
+    `email_regex` text,
+    `oauth_providers` text,
+    CONSTRAINT `uni_divisions_name` UNIQUE (`name`)
+);
+INSERT INTO `divisions_old` (`id`, `created_at`, `updated_at`, `deleted_at`, `name`, `description`, `team_size`, `email_regex`, `oauth_providers`)
+    SELECT `id`, `created_at`, `updated_at`, `deleted_at`, `name`, `description`, `team_size`, `email_regex`, `oauth_providers` FROM `divisions`;
+DROP TABLE `divisions`;
+ALTER TABLE `divisions_old` RENAME TO `divisions`;
+CREATE INDEX `idx_divisions_deleted_at` ON `divisions`(`deleted_at`);
@@ -0,0 +1,17 @@
+CREATE TABLE `divisions_new` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `deleted_at` datetime,
+    `name` text NOT NULL,
+    `description` text,
+    `team_size` integer NOT NULL DEFAULT 0,
+    `email_regex` text,
+    `oauth_providers` text
+);
+INSERT INTO `divisions_new` (`id`, `created_at`, `updated_at`, `deleted_at`, `name`, `description`, `team_size`, `email_regex`, `oauth_providers`)
+    SELECT `id`, `created_at`, `updated_at`, `deleted_at`, `name`, `description`, `team_size`, `email_regex`, `oauth_providers` FROM `divisions`;
+DROP TABLE `divisions`;
+ALTER TABLE `divisions_new` RENAME TO `divisions`;
+CREATE INDEX `idx_divisions_deleted_at` ON `divisions`(`deleted_at`);
+CREATE UNIQUE INDEX `uni_divisions_name` ON `divisions`(`name`) WHERE `deleted_at` IS NULL;
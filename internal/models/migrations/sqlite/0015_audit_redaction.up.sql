@@ -0,0 +1 @@
+ALTER TABLE `audit_events` ADD COLUMN `redacted` numeric NOT NULL DEFAULT false;
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ProfileError names the profile field that failed validation.
+type ProfileError struct {
+	Field string
+}
+
+func (e *ProfileError) Error() string {
+	return "invalid profile field " + e.Field
+}
+
+// Profile is the public self-description of a user or team, the details
+// CTFtime and prize handling ask for. All of it is optional.
+type Profile struct {
+	Country     string `json:"country" gorm:"size:2" validate:"omitempty,iso3166_1_alpha2"` // ISO 3166-1 alpha-2
+	Affiliation string `json:"affiliation" gorm:"size:128" validate:"max=128"`              // university or company
+	Website     string `json:"website" gorm:"size:255" validate:"omitempty,http_url,max=255"`
+	Bio         string `json:"bio" gorm:"size:500" validate:"max=500"`
+}
+
+var profileValidator = validator.New()
+
+// Normalize trims the fields and upper-cases the country code.
+func (p *Profile) Normalize() {
+	p.Country = strings.ToUpper(strings.TrimSpace(p.Country))
+	p.Affiliation = strings.TrimSpace(p.Affiliation)
+	p.Website = strings.TrimSpace(p.Website)
+	p.Bio = strings.TrimSpace(p.Bio)
+}
+
+// Validate checks the country against the ISO list, the website is an
+// http(s) URL and the length limits.
+func (p *Profile) Validate() error {
+	err := profileValidator.Struct(p)
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		return &ProfileError{Field: strings.ToLower(fieldErrs[0].Field())}
+	}
+	return err
+}
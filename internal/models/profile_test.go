@@ -0,0 +1,35 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileValidation(t *testing.T) {
+	testutil.SetupDB(t)
+	user := testutil.CreateUser(t, "alice")
+	user.Profile = models.Profile{Country: " in ", Affiliation: "IIT Madras ", Website: "https://alice.dev"}
+	user.Normalize()
+	require.NoError(t, user.Validate())
+	require.Equal(t, "IN", user.Country)
+	require.NoError(t, models.DB.Save(&user).Error)
+	var stored models.User
+	require.NoError(t, models.DB.First(&stored, user.ID).Error)
+	require.Equal(t, user.Profile, stored.Profile)
+
+	var profileErr *models.ProfileError
+	bad := models.Profile{Country: "XX"}
+	require.ErrorAs(t, bad.Validate(), &profileErr)
+	require.Equal(t, "country", profileErr.Field)
+	bad = models.Profile{Website: "javascript:alert(1)"}
+	require.ErrorAs(t, bad.Validate(), &profileErr)
+	require.Equal(t, "website", profileErr.Field)
+	bad = models.Profile{Bio: strings.Repeat("a", 501)}
+	require.ErrorAs(t, bad.Validate(), &profileErr)
+	require.Equal(t, "bio", profileErr.Field)
+	require.NoError(t, (&models.Profile{}).Validate(), "every field is optional")
+}
@@ -0,0 +1,74 @@
+package models
+
+const (
+	RoleAdmin     = "admin"
+	RoleOrganiser = "organiser"
+	RoleSupport   = "support"
+	RolePlayer    = "player"
+)
+
+// Permissions checked on admin routes. API keys carry a subset of these as
+// their scopes.
+const (
+	PermEventManage    = "event:manage"
+	PermInvitesManage  = "invites:manage"
+	PermUsersRead      = "users:read"
+	PermRolesManage    = "roles:manage"
+	PermKeysManage     = "keys:manage"
+	PermAuditRead      = "audit:read"
+	PermUsersBan       = "users:ban"
+	PermWebhooksManage = "webhooks:manage"
+)
+
+var rolePermissions = map[string][]string{
+	RoleAdmin:     AllPermissions(),
+	RoleOrganiser: {PermEventManage, PermInvitesManage, PermUsersRead, PermAuditRead, PermUsersBan, PermWebhooksManage},
+	RoleSupport:   {PermUsersRead, PermAuditRead, PermUsersBan},
+	RolePlayer:    {},
+}
+
+// AllPermissions lists every permission known to the service.
+func AllPermissions() []string {
+	return []string{PermEventManage, PermInvitesManage, PermUsersRead, PermRolesManage, PermKeysManage, PermAuditRead, PermUsersBan, PermWebhooksManage}
+}
+
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func ValidPermission(perm string) bool {
+	for _, p := range AllPermissions() {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleHasPermission reports whether members of the role may perform the
+// action. Unknown roles have no permissions.
+func RoleHasPermission(role, perm string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleDemotes reports whether moving from one role to the other takes away
+// any permission.
+func RoleDemotes(from, to string) bool {
+	for _, p := range rolePermissions[from] {
+		if !RoleHasPermission(to, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStaff reports whether the user may use the admin API at all.
+func (u *User) IsStaff() bool {
+	return u.Role != "" && u.Role != RolePlayer && ValidRole(u.Role)
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RosterException lets one user change team rosters while they are frozen,
+// until it expires or is revoked. With a TeamID it covers only changes that
+// involve that team.
+type RosterException struct {
+	gorm.Model
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	TeamID    *uint     `json:"team_id"`
+	Reason    string    `json:"reason" gorm:"not null"`
+	GrantedBy string    `json:"granted_by"` // admin actor, e.g. user:alice or key:ci
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	Revoked   bool      `json:"revoked" gorm:"default:false"`
+}
+
+func (RosterException) TableName() string {
+	return "roster_exceptions"
+}
+
+// Active reports whether the exception can still be used.
+func (e *RosterException) Active() bool {
+	return !e.Revoked && time.Now().Before(e.ExpiresAt)
+}
+
+// FindRosterException returns an active exception of the user that covers a
+// change to teamID, or gorm.ErrRecordNotFound. A nil teamID, as when creating
+// a team, is only covered by exceptions that are not tied to a team.
+func FindRosterException(tx *gorm.DB, userID uint, teamID *uint) (exception RosterException, err error) {
+	query := tx.Where("user_id = ? AND revoked = ? AND expires_at > ?", userID, false, time.Now())
+	if teamID == nil {
+		query = query.Where("team_id IS NULL")
+	} else {
+		query = query.Where("team_id IS NULL OR team_id = ?", *teamID)
+	}
+	err = query.Order("expires_at DESC").Take(&exception).Error
+	return
+}
@@ -0,0 +1,39 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestFindRosterException(t *testing.T) {
+	testutil.SetupDB(t)
+	user := testutil.CreateUser(t, "swapper")
+	teamA, teamB := uint(1), uint(2)
+
+	_, err := models.FindRosterException(models.DB, user.ID, &teamA)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	scoped := models.RosterException{UserID: user.ID, TeamID: &teamA, Reason: "replacement", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, models.DB.Create(&scoped).Error)
+	found, err := models.FindRosterException(models.DB, user.ID, &teamA)
+	require.NoError(t, err)
+	require.Equal(t, scoped.ID, found.ID)
+	_, err = models.FindRosterException(models.DB, user.ID, &teamB)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = models.FindRosterException(models.DB, user.ID, nil)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	expired := models.RosterException{UserID: user.ID, Reason: "late", ExpiresAt: time.Now().Add(-time.Minute)}
+	require.NoError(t, models.DB.Create(&expired).Error)
+	_, err = models.FindRosterException(models.DB, user.ID, nil)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	require.NoError(t, models.DB.Model(&scoped).Update("revoked", true).Error)
+	_, err = models.FindRosterException(models.DB, user.ID, &teamA)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// EventSchedule is the schedule set through the admin API. It has a single
+// row with ID 1; while there is none the schedule in the config applies.
+type EventSchedule struct {
+	ID           uint `gorm:"primaryKey"`
+	SignupOpen   *time.Time
+	SignupClose  *time.Time
+	LoginOpen    *time.Time
+	LoginClose   *time.Time
+	RosterFreeze *time.Time
+	End          *time.Time `gorm:"column:event_end"`
+	UpdatedAt    time.Time
+}
+
+func (EventSchedule) TableName() string {
+	return "event_schedules"
+}
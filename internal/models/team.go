@@ -3,19 +3,43 @@ package models
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Join policies decide how users other than invitees get into a team.
+const (
+	JoinPolicyCode     = "code"     // join with the team code
+	JoinPolicyOpen     = "open"     // join without the code
+	JoinPolicyApproval = "approval" // join with the code, or ask the leader
+	JoinPolicyClosed   = "closed"   // invitations only
+)
+
+func ValidJoinPolicy(policy string) bool {
+	switch policy {
+	case JoinPolicyCode, JoinPolicyOpen, JoinPolicyApproval, JoinPolicyClosed:
+		return true
+	}
+	return false
+}
+
 type Team struct {
 	gorm.Model
-	Name      string `json:"name"`
-	Code      string `json:"code" gorm:"unique"`
-	Ban       bool   `json:"ban" gorm:"default:false"`
-	Blacklist bool   `json:"blacklist" gorm:"default:false"`
-	LeaderID  uint   `json:"leader" gorm:"not null"`
-	Leader    User   `gorm:"-"`
-	Members   []User `gorm:"foreignKey:TeamID"`
+	Name       string    `json:"name"`
+	Code       string    `json:"code" gorm:"unique"`
+	Ban        bool      `json:"ban" gorm:"default:false"`
+	Blacklist  bool      `json:"blacklist" gorm:"default:false"`
+	LeaderID   uint      `json:"leader" gorm:"not null"`
+	JoinPolicy string    `json:"join_policy" gorm:"not null;default:code"`
+	Locked     bool      `json:"locked" gorm:"default:false"` // no one joins while the leader has the roster locked
+	DivisionID *uint     `json:"division_id" gorm:"index"`
+	Division   *Division `json:"division,omitempty"`
+	Profile    `gorm:"embedded"`
+	AvatarKey  string `json:"-"` // storage key of the uploaded avatar, see avatar.Store
+	Leader     User   `gorm:"-"`
+	Members    []User `gorm:"foreignKey:TeamID"`
 }
 
 func (Team) TableName() string {
@@ -23,16 +47,71 @@ func (Team) TableName() string {
 }
 
 func (t *Team) BeforeCreate(tx *gorm.DB) (err error) {
+	t.Code, err = NewTeamCode()
+	return
+}
+
+// NewTeamCode returns a fresh random join code.
+func NewTeamCode() (string, error) {
 	random := make([]byte, 6) // this much size to avoid collisions
-	_, err = rand.Read(random)
-	if err != nil {
-		return err
+	if _, err := rand.Read(random); err != nil {
+		return "", err
 	}
-	t.Code = hex.EncodeToString(random)
-	return nil
+	return hex.EncodeToString(random), nil
 }
 
 func (t *Team) BeforeDelete(tx *gorm.DB) (err error) {
-	err = tx.Model(&User{}).Where("team_id = ?", t.ID).Update("team_id", nil).Error
+	err = tx.Model(&User{}).Where("team_id = ?", t.ID).
+		Updates(map[string]any{"team_id": nil, "team_role": TeamRoleMember}).Error
+	return
+}
+
+var (
+	ErrAlreadyInTeam = errors.New("user is already in a team")
+	ErrTeamBanned    = errors.New("team is banned")
+	ErrTeamFull      = errors.New("team max size reached")
+	ErrTeamLocked    = errors.New("team roster is locked")
+)
+
+// AddTeamMember puts the user in the team, as long as they are not in a team
+// yet, the team is neither banned nor locked, and the user meets the rules of
+// the team's division, which also caps its size. The team row is locked so
+// that concurrent joins cannot overfill it; call it inside a transaction.
+func AddTeamMember(tx *gorm.DB, teamID uint, user *User) (team Team, err error) {
+	if user.TeamID != nil {
+		err = ErrAlreadyInTeam
+		return
+	}
+	if err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&team, teamID).Error; err != nil {
+		return
+	}
+	if team.Ban {
+		err = ErrTeamBanned
+		return
+	}
+	if team.Locked {
+		err = ErrTeamLocked
+		return
+	}
+	var division Division
+	if division, err = TeamDivision(tx, &team); err != nil {
+		return
+	}
+	if err = division.Admits(tx, user); err != nil {
+		return
+	}
+	var members int64
+	if err = tx.Model(&User{}).Where("team_id = ?", team.ID).Count(&members).Error; err != nil {
+		return
+	}
+	if int(members) >= division.MaxTeamSize() {
+		err = ErrTeamFull
+		return
+	}
+	if err = tx.Model(user).Updates(map[string]any{"team_id": team.ID, "team_role": TeamRoleMember}).Error; err != nil {
+		return
+	}
+	user.TeamID = &team.ID
+	user.TeamRole = TeamRoleMember
 	return
 }
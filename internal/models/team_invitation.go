@@ -0,0 +1,101 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+	InvitationRevoked  = "revoked"
+)
+
+var (
+	ErrInvitationNotFound = errors.New("team invitation not found")
+	ErrInvitationClosed   = errors.New("team invitation is no longer pending")
+	ErrInvitationExpired  = errors.New("team invitation has expired")
+)
+
+// TeamInvitation is a leader's invite for one specific user to join their
+// team. Unlike an Invite code it cannot be passed on to someone else.
+type TeamInvitation struct {
+	gorm.Model
+	TeamID    uint      `json:"team_id" gorm:"index;not null"`
+	InviteeID uint      `json:"invitee_id" gorm:"index;not null"`
+	InviterID uint      `json:"inviter_id" gorm:"not null"`
+	Status    string    `json:"status" gorm:"not null;default:pending"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+
+	Team    Team `json:"-" gorm:"foreignKey:TeamID"`
+	Invitee User `json:"-" gorm:"foreignKey:InviteeID"`
+	Inviter User `json:"-" gorm:"foreignKey:InviterID"`
+}
+
+func (TeamInvitation) TableName() string {
+	return "team_invitations"
+}
+
+// Open reports whether the invitation can still be answered.
+func (i *TeamInvitation) Open() error {
+	if i.Status != InvitationPending {
+		return ErrInvitationClosed
+	}
+	if time.Now().After(i.ExpiresAt) {
+		return ErrInvitationExpired
+	}
+	return nil
+}
+
+// State is the status shown to users, with lapsed pending invitations
+// reported as expired.
+func (i *TeamInvitation) State() string {
+	if i.Status == InvitationPending && time.Now().After(i.ExpiresAt) {
+		return "expired"
+	}
+	return i.Status
+}
+
+// AcceptTeamInvitation puts the invitee in the team of a pending invitation
+// addressed to them. The team size is checked again here, since the team may
+// have filled up after the invitation was sent.
+func AcceptTeamInvitation(tx *gorm.DB, id uint, user *User) (invitation TeamInvitation, err error) {
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND invitee_id = ?", id, user.ID).First(&invitation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrInvitationNotFound
+		}
+		return
+	}
+	if err = invitation.Open(); err != nil {
+		return
+	}
+	if invitation.Team, err = AddTeamMember(tx, invitation.TeamID, user); err != nil {
+		return
+	}
+	err = tx.Model(&invitation).Update("status", InvitationAccepted).Error
+	return
+}
+
+// DeclineTeamInvitation closes a pending invitation addressed to the user.
+func DeclineTeamInvitation(tx *gorm.DB, id uint, user *User) (invitation TeamInvitation, err error) {
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND invitee_id = ?", id, user.ID).First(&invitation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrInvitationNotFound
+		}
+		return
+	}
+	if invitation.Status != InvitationPending {
+		err = ErrInvitationClosed
+		return
+	}
+	err = tx.Model(&invitation).Update("status", InvitationDeclined).Error
+	return
+}
@@ -0,0 +1,46 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptTeamInvitation(t *testing.T) {
+	testutil.SetupDB(t)
+	leader := testutil.CreateUser(t, "leader")
+	team := testutil.CreateTeam(t, models.DB, leader)
+
+	invite := func(user models.User) models.TeamInvitation {
+		invitation := models.TeamInvitation{
+			TeamID:    team.ID,
+			InviteeID: user.ID,
+			InviterID: leader.ID,
+			Status:    models.InvitationPending,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		require.NoError(t, models.DB.Create(&invitation).Error)
+		return invitation
+	}
+	first, second := testutil.CreateUser(t, "first"), testutil.CreateUser(t, "second")
+	firstInvite, secondInvite := invite(first), invite(second)
+
+	_, err := models.AcceptTeamInvitation(models.DB, firstInvite.ID, &second)
+	require.ErrorIs(t, err, models.ErrInvitationNotFound)
+
+	accepted, err := models.AcceptTeamInvitation(models.DB, firstInvite.ID, &first)
+	require.NoError(t, err)
+	require.Equal(t, team.ID, *first.TeamID)
+	require.Equal(t, models.InvitationAccepted, accepted.Status)
+
+	_, err = models.AcceptTeamInvitation(models.DB, firstInvite.ID, &first)
+	require.ErrorIs(t, err, models.ErrInvitationClosed)
+
+	// the team reached its size of two after the second invitation went out
+	_, err = models.AcceptTeamInvitation(models.DB, secondInvite.ID, &second)
+	require.ErrorIs(t, err, models.ErrTeamFull)
+	require.Nil(t, second.TeamID)
+}
@@ -0,0 +1,69 @@
+package models
+
+// Roles inside a team. The captain is whoever Team.LeaderID points at; the
+// others are stored on the member's row.
+const (
+	TeamRoleCaptain   = "captain"
+	TeamRoleCoCaptain = "co_captain"
+	TeamRoleMember    = "member"
+)
+
+// Actions a team member may be allowed to take on their team.
+const (
+	TeamPermInvite     = "invite"      // invitations, invite codes and join requests
+	TeamPermKick       = "kick"        // remove members ranked below them
+	TeamPermRename     = "rename"      // name and join policy
+	TeamPermRotateCode = "rotate_code" // new join code
+	TeamPermLock       = "lock"        // lock and unlock the roster
+	TeamPermDelete     = "delete"      // delete the team
+	TeamPermRoles      = "roles"       // hand out roles and the captaincy
+)
+
+var teamRolePermissions = map[string][]string{
+	TeamRoleCaptain:   {TeamPermInvite, TeamPermKick, TeamPermRename, TeamPermRotateCode, TeamPermLock, TeamPermDelete, TeamPermRoles},
+	TeamRoleCoCaptain: {TeamPermInvite, TeamPermKick, TeamPermRename, TeamPermRotateCode, TeamPermLock},
+	TeamRoleMember:    {},
+}
+
+var teamRoleRank = map[string]int{
+	TeamRoleCaptain:   2,
+	TeamRoleCoCaptain: 1,
+	TeamRoleMember:    0,
+}
+
+// ValidTeamRole reports whether the role can be assigned to a member. The
+// captaincy is handed over by changing the team's leader instead.
+func ValidTeamRole(role string) bool {
+	return role == TeamRoleCoCaptain || role == TeamRoleMember
+}
+
+// TeamRoleOf is the user's role in the team, or "" if they are not in it.
+func TeamRoleOf(team *Team, user *User) string {
+	switch {
+	case user.TeamID == nil || *user.TeamID != team.ID:
+		return ""
+	case team.LeaderID == user.ID:
+		return TeamRoleCaptain
+	case user.TeamRole == TeamRoleCoCaptain:
+		return TeamRoleCoCaptain
+	default:
+		return TeamRoleMember
+	}
+}
+
+// TeamRoleHasPermission reports whether holders of the team role may take
+// the action.
+func TeamRoleHasPermission(role, perm string) bool {
+	for _, p := range teamRolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// TeamRoleOutranks reports whether a holder of role may act on a member
+// holding other, e.g. kick them.
+func TeamRoleOutranks(role, other string) bool {
+	return teamRoleRank[role] > teamRoleRank[other]
+}
@@ -0,0 +1,26 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockedTeamTakesNoMembers(t *testing.T) {
+	testutil.SetupDB(t)
+	leader := testutil.CreateUser(t, "leader")
+	team := models.Team{Name: "pwners", LeaderID: leader.ID, Locked: true}
+	require.NoError(t, models.DB.Create(&team).Error)
+
+	user := testutil.CreateUser(t, "newcomer")
+	_, err := models.AddTeamMember(models.DB, team.ID, &user)
+	require.ErrorIs(t, err, models.ErrTeamLocked)
+
+	require.NoError(t, models.DB.Model(&team).Update("locked", false).Error)
+	_, err = models.AddTeamMember(models.DB, team.ID, &user)
+	require.NoError(t, err)
+	_, err = models.AddTeamMember(models.DB, team.ID, &user)
+	require.ErrorIs(t, err, models.ErrAlreadyInTeam)
+}
@@ -1,9 +1,12 @@
 package models
 
 import (
-	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
@@ -14,6 +17,7 @@ import (
 	"github.com/pquerna/otp/totp"
 	"golang.org/x/crypto/argon2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -29,12 +33,37 @@ type User struct {
 	Username  string `json:"username" gorm:"unique"`
 	Password  string `json:"-"`
 	Email     string `json:"email" gorm:"unique"`
-	AvatarURL string `json:"avatar_url" gorm:"column:avatar_url;unique"`
+	AvatarURL string `json:"avatar_url" gorm:"column:avatar_url"` // set by admins or OAuth; an upload takes precedence
+	AvatarKey string `json:"-"`                                   // storage key of the uploaded avatar, see avatar.Store
 	Active    bool   `json:"active" gorm:"default:false"`
 	Ban       bool   `json:"ban" gorm:"default:false"`
 	Blacklist bool   `json:"blacklist" gorm:"default:false"`
 	TeamID    *uint  `json:"team_id" gorm:"column:team_id"`
 	Team      *Team  `json:"team" gorm:"foreignKey:TeamID"`
+	Role      string `json:"role" gorm:"not null;default:player"`
+	TeamRole  string `json:"team_role" gorm:"not null;default:member"` // see TeamRoleOf
+
+	// Privacy settings for what others than teammates and staff see.
+	HideEmail bool `json:"hide_email" gorm:"not null;default:true"`
+	HideTeam  bool `json:"hide_team" gorm:"not null;default:false"`
+
+	Profile `gorm:"embedded"`
+
+	// TokenVersion is embedded in every issued JWT; bumping it revokes all
+	// sessions of the user.
+	TokenVersion uint `json:"-" gorm:"not null;default:0"`
+
+	// DeleteAfter is when a requested account deletion anonymises the user,
+	// see ScheduleDeletion.
+	DeleteAfter *time.Time `json:"delete_after,omitempty" gorm:"index"`
+}
+
+// PasswordReset is what an issued reset token resolves to. It is cached under
+// the hash of the token, and the fingerprint ties it to the password that was
+// set when it was issued.
+type PasswordReset struct {
+	UserID      uint   `json:"user_id"`
+	Fingerprint string `json:"fingerprint"`
 }
 
 type UserOauthMeta struct {
@@ -124,6 +153,13 @@ func (u *User) SetPassword(password string) (err error) {
 	return
 }
 
+// PasswordFingerprint changes whenever the password does, without exposing
+// the stored hash itself.
+func (u *User) PasswordFingerprint() string {
+	sum := sha256.Sum256([]byte(u.Password))
+	return hex.EncodeToString(sum[:])
+}
+
 func (ut *UserTOTPMeta) TOTPUrl() (string, error) {
 	issuer := values.GetConfig().App.TOTP.Issuer
 	if key, err := otp.NewKeyFromURL(
@@ -140,9 +176,16 @@ func (ut *UserTOTPMeta) VerifyTOTP(otp string) bool {
 	return totp.Validate(otp, ut.TOTPSecret)
 }
 
+// HasPassword tells whether the user can log in with a password. Accounts
+// created through OAuth store the hash of an empty one, which login refuses.
+func (u *User) HasPassword() bool {
+	empty, err := u.ComparePassword("")
+	return err == nil && !empty
+}
+
 func (u *User) ComparePassword(password string) (bool, error) {
 	parts := strings.Split(u.Password, "$")
-	if len(parts) != 7 {
+	if len(parts) != 8 {
 		return false, fmt.Errorf("invalid hash format")
 	}
 	var t, m uint32
@@ -168,29 +211,16 @@ func (u *User) ComparePassword(password string) (bool, error) {
 		return false, fmt.Errorf("error decoding hash: %w", err)
 	}
 	actualHash := argon2.IDKey([]byte(password), salt, t, m, p, uint32(len(expectedHash)))
-	if bytes.Equal(actualHash, expectedHash) {
+	if subtle.ConstantTimeCompare(actualHash, expectedHash) == 1 {
 		return true, nil
 	}
 	return false, nil
 }
 
 func (u *User) BeforeDelete(tx *gorm.DB) (err error) {
-	var team Team
-	err = tx.First(&team, u.TeamID).Error
-	if err != nil {
+	if err = u.handOverTeam(tx); err != nil {
 		return
 	}
-	if team.LeaderID == u.ID {
-		err = tx.Exec(`
-    UPDATE teams 
-    SET leader_id = (
-        SELECT id FROM users 
-        WHERE team_id = ? AND id != ? AND deleted_at IS NULL
-        ORDER BY created_at LIMIT 1
-    ) 
-    WHERE id = ?`,
-			team.ID, u.ID, team.ID).Error
-	}
 	appCfg := values.GetConfig().App
 	if appCfg.TOTP.Enabled {
 		err = tx.Where("user_id = ?", u.ID).Delete(&UserTOTPMeta{}).Error
@@ -206,3 +236,37 @@ func (u *User) BeforeDelete(tx *gorm.DB) (err error) {
 	}
 	return
 }
+
+// handOverTeam passes the leadership of the user's team on before the user
+// goes away. A team with nobody else in it goes with its leader, as no one
+// would be left to manage it.
+func (u *User) handOverTeam(tx *gorm.DB) error {
+	if u.TeamID == nil {
+		return nil
+	}
+	var team Team
+	if err := tx.First(&team, *u.TeamID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if team.LeaderID != u.ID {
+		return nil
+	}
+	// a co-captain takes over if there is one, else the longest-standing
+	// remaining member
+	var next User
+	err := tx.Where("team_id = ? AND id <> ?", team.ID, u.ID).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "CASE WHEN team_role = ? THEN 0 ELSE 1 END, created_at",
+			Vars: []any{TeamRoleCoCaptain},
+		}}).Take(&next).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Delete(&team).Error
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Model(&team).Update("leader_id", next.ID).Error
+}
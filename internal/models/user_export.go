@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserExport is everything stored about a user, as handed out for data
+// access requests. Secrets such as the password hash, OAuth tokens and the
+// TOTP seed are left out.
+type UserExport struct {
+	ExportedAt        time.Time           `json:"exported_at"`
+	Profile           User                `json:"profile"`
+	Team              *ExportedTeam       `json:"team"`
+	OAuth             []ExportedOAuthLink `json:"oauth"`
+	TOTPEnabled       bool                `json:"totp_enabled"`
+	Solves            []Solve             `json:"solves"`
+	TeamInvitations   []TeamInvitation    `json:"team_invitations"`
+	JoinRequests      []TeamJoinRequest   `json:"join_requests"`
+	InviteRedemptions []InviteRedemption  `json:"invite_redemptions"`
+	Bans              []BanHistory        `json:"bans"`
+	RosterExceptions  []RosterException   `json:"roster_exceptions"`
+	AuditEvents       []AuditEvent        `json:"audit_events"`
+}
+
+// ExportedTeam is the user's team as far as it concerns them; other
+// members' data is not theirs to take.
+type ExportedTeam struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	TeamRole string `json:"team_role"`
+	Leader   bool   `json:"leader"`
+}
+
+type ExportedOAuthLink struct {
+	Provider   string    `json:"provider"`
+	ProviderID string    `json:"provider_id"`
+	LinkedAt   time.Time `json:"linked_at"`
+}
+
+// ExportUser collects the export for u.
+func ExportUser(tx *gorm.DB, u *User) (UserExport, error) {
+	export := UserExport{ExportedAt: time.Now().UTC(), Profile: *u}
+	export.Profile.Team = nil
+	if u.TeamID != nil {
+		var team Team
+		if err := tx.First(&team, *u.TeamID).Error; err != nil {
+			return export, err
+		}
+		export.Team = &ExportedTeam{ID: team.ID, Name: team.Name, TeamRole: TeamRoleOf(&team, u), Leader: team.LeaderID == u.ID}
+	}
+	var links []UserOauthMeta
+	if err := tx.Where("user_id = ?", u.ID).Find(&links).Error; err != nil {
+		return export, err
+	}
+	for _, link := range links {
+		export.OAuth = append(export.OAuth, ExportedOAuthLink{Provider: link.Provider, ProviderID: link.ProviderID, LinkedAt: link.CreatedAt})
+	}
+	var totp int64
+	if err := tx.Model(&UserTOTPMeta{}).Where("user_id = ?", u.ID).Count(&totp).Error; err != nil {
+		return export, err
+	}
+	export.TOTPEnabled = totp > 0
+	// solves belong to the challenge service and only exist in a shared database
+	if tx.Migrator().HasTable(&Solve{}) {
+		if err := tx.Where("user_id = ?", u.ID).Order("id").Find(&export.Solves).Error; err != nil {
+			return export, err
+		}
+	}
+	for _, q := range []struct {
+		dest  any
+		where string
+		args  []any
+	}{
+		{&export.TeamInvitations, "invitee_id = ? OR inviter_id = ?", []any{u.ID, u.ID}},
+		{&export.JoinRequests, "user_id = ?", []any{u.ID}},
+		{&export.InviteRedemptions, "user_id = ?", []any{u.ID}},
+		{&export.Bans, "user_id = ?", []any{u.ID}},
+		{&export.RosterExceptions, "user_id = ?", []any{u.ID}},
+		{&export.AuditEvents, "actor_id = ? OR target_user_id = ?", []any{u.ID, u.ID}},
+	} {
+		if err := tx.Where(q.where, q.args...).Order("id").Find(q.dest).Error; err != nil {
+			return export, err
+		}
+	}
+	return export, nil
+}
@@ -0,0 +1,88 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestPassword(t *testing.T) {
+	testutil.SetupDB(t)
+	user := testutil.CreateUser(t, "alice")
+	require.NotEqual(t, "hunter2-alice", user.Password)
+
+	ok, err := user.ComparePassword("hunter2-alice")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = user.ComparePassword("wrong")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.True(t, user.HasPassword())
+
+	oauthOnly := models.User{Username: "bob", Email: "bob@example.com", Active: true}
+	require.NoError(t, models.DB.Create(&oauthOnly).Error)
+	require.False(t, oauthOnly.HasPassword())
+}
+
+func TestUniqueUsername(t *testing.T) {
+	testutil.SetupDB(t)
+	testutil.CreateUser(t, "alice")
+	dup := models.User{Username: "alice", Email: "other@example.com", AvatarURL: "x", Password: "p"}
+	require.ErrorIs(t, models.DB.Create(&dup).Error, gorm.ErrDuplicatedKey)
+}
+
+func TestReservedUsername(t *testing.T) {
+	require.True(t, models.ReservedUsername("deleted-12"))
+	require.True(t, models.ReservedUsername("Deleted-x"))
+	require.False(t, models.ReservedUsername("undeleted-12"))
+	require.False(t, models.ReservedUsername("deleted"))
+}
+
+func TestDeletingLeaderHandsTeamOver(t *testing.T) {
+	testutil.SetupDB(t)
+	leader := testutil.CreateUser(t, "leader")
+	member := testutil.CreateUser(t, "member")
+	team := testutil.CreateTeam(t, models.DB, leader, member)
+
+	require.NoError(t, models.DB.First(&leader, leader.ID).Error)
+	require.NoError(t, models.DB.Delete(&leader).Error)
+
+	require.NoError(t, models.DB.First(&team, team.ID).Error)
+	require.Equal(t, member.ID, team.LeaderID)
+}
+
+func TestDeletingLeaderPrefersCoCaptain(t *testing.T) {
+	testutil.SetupDB(t)
+	leader := testutil.CreateUser(t, "leader")
+	veteran := testutil.CreateUser(t, "veteran")
+	cocaptain := testutil.CreateUser(t, "cocaptain")
+	team := testutil.CreateTeam(t, models.DB, leader, veteran, cocaptain)
+	require.NoError(t, models.DB.Model(&cocaptain).Update("team_role", models.TeamRoleCoCaptain).Error)
+
+	require.NoError(t, models.DB.First(&leader, leader.ID).Error)
+	require.NoError(t, models.DB.First(&cocaptain, cocaptain.ID).Error)
+	require.Equal(t, models.TeamRoleCaptain, models.TeamRoleOf(&team, &leader))
+	require.Equal(t, models.TeamRoleCoCaptain, models.TeamRoleOf(&team, &cocaptain))
+	require.True(t, models.TeamRoleOutranks(models.TeamRoleCoCaptain, models.TeamRoleMember))
+	require.False(t, models.TeamRoleHasPermission(models.TeamRoleCoCaptain, models.TeamPermDelete))
+
+	require.NoError(t, models.DB.Delete(&leader).Error)
+	require.NoError(t, models.DB.First(&team, team.ID).Error)
+	require.Equal(t, cocaptain.ID, team.LeaderID)
+}
+
+func TestUsersShareDefaultAvatar(t *testing.T) {
+	testutil.SetupDB(t)
+	for _, name := range []string{"alice", "bob"} {
+		user := models.User{Username: name, Email: name + "@example.com", Password: "hunter2-" + name}
+		require.NoError(t, models.DB.Create(&user).Error, "users without an avatar must not collide")
+	}
+	var users []models.User
+	require.NoError(t, models.DB.Find(&users).Error)
+	require.Len(t, users, 2)
+	require.Empty(t, users[0].AvatarKey)
+}
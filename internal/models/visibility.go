@@ -0,0 +1,61 @@
+package models
+
+// Views decide which fields of a team or profile a viewer sees.
+const (
+	ViewPublic   = "public"   // anyone, signed in or not
+	ViewTeammate = "teammate" // members of the same team, and the user themself
+	ViewLeader   = "leader"   // team members whose role lets them invite
+	ViewAdmin    = "admin"    // staff allowed to read users
+)
+
+// AdminViewer reports whether viewer gets the admin view of everything.
+func AdminViewer(viewer *User) bool {
+	return viewer != nil && viewer.IsStaff() && RoleHasPermission(viewer.Role, PermUsersRead)
+}
+
+// TeamViewFor is the view viewer gets of the team; viewer is nil for
+// anonymous requests.
+func TeamViewFor(viewer *User, team *Team) string {
+	if AdminViewer(viewer) {
+		return ViewAdmin
+	}
+	if viewer == nil {
+		return ViewPublic
+	}
+	role := TeamRoleOf(team, viewer)
+	switch {
+	case role == "":
+		return ViewPublic
+	case TeamRoleHasPermission(role, TeamPermInvite):
+		return ViewLeader
+	default:
+		return ViewTeammate
+	}
+}
+
+// UserViewFor is the view viewer gets of target's profile. A profile shows
+// the same to every member of the user's team, so it has no leader view.
+func UserViewFor(viewer, target *User) string {
+	switch {
+	case AdminViewer(viewer):
+		return ViewAdmin
+	case viewer == nil:
+		return ViewPublic
+	case viewer.ID == target.ID:
+		return ViewTeammate
+	case viewer.TeamID != nil && target.TeamID != nil && *viewer.TeamID == *target.TeamID:
+		return ViewTeammate
+	default:
+		return ViewPublic
+	}
+}
+
+// EmailVisible reports whether the user's email shows in the given view.
+func (u *User) EmailVisible(view string) bool {
+	return view != ViewPublic || !u.HideEmail
+}
+
+// TeamVisible reports whether the user's team shows in the given view.
+func (u *User) TeamVisible(view string) bool {
+	return view != ViewPublic || !u.HideTeam
+}
@@ -0,0 +1,42 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVisibilityViews(t *testing.T) {
+	testutil.SetupDB(t)
+	captain := testutil.CreateUser(t, "captain")
+	member := testutil.CreateUser(t, "member")
+	outsider := testutil.CreateUser(t, "outsider")
+	admin := testutil.CreateUser(t, "admin")
+	require.NoError(t, models.DB.Model(&admin).Update("role", models.RoleAdmin).Error)
+	team := testutil.CreateTeam(t, models.DB, captain, member)
+	for _, u := range []*models.User{&captain, &member, &admin} {
+		require.NoError(t, models.DB.First(u, u.ID).Error)
+	}
+
+	require.True(t, member.HideEmail, "emails are private unless the user shares them")
+	require.False(t, member.HideTeam)
+
+	require.Equal(t, models.ViewPublic, models.TeamViewFor(nil, &team))
+	require.Equal(t, models.ViewPublic, models.TeamViewFor(&outsider, &team))
+	require.Equal(t, models.ViewTeammate, models.TeamViewFor(&member, &team))
+	require.Equal(t, models.ViewLeader, models.TeamViewFor(&captain, &team))
+	require.Equal(t, models.ViewAdmin, models.TeamViewFor(&admin, &team))
+
+	require.Equal(t, models.ViewPublic, models.UserViewFor(&outsider, &member))
+	require.Equal(t, models.ViewTeammate, models.UserViewFor(&captain, &member))
+	require.Equal(t, models.ViewTeammate, models.UserViewFor(&outsider, &outsider))
+	require.Equal(t, models.ViewAdmin, models.UserViewFor(&admin, &member))
+
+	require.False(t, member.EmailVisible(models.ViewPublic))
+	require.True(t, member.EmailVisible(models.ViewTeammate))
+	member.HideTeam = true
+	require.False(t, member.TeamVisible(models.ViewPublic))
+	require.True(t, member.TeamVisible(models.ViewAdmin))
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+type Webhook struct {
+	gorm.Model
+	Name   string   `json:"name" gorm:"not null"`
+	URL    string   `json:"url" gorm:"not null"`
+	Secret string   `json:"-" gorm:"not null"`
+	Events []string `json:"events" gorm:"serializer:json"`
+	Active bool     `json:"active" gorm:"default:true"`
+}
+
+type WebhookDelivery struct {
+	gorm.Model
+	WebhookID      uint       `json:"webhook_id" gorm:"index;not null"`
+	EventID        string     `json:"event_id" gorm:"index;not null"`
+	EventType      string     `json:"event_type" gorm:"not null"`
+	Payload        string     `json:"payload" gorm:"type:text"`
+	Status         string     `json:"status" gorm:"index;not null;default:pending"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+func (w *Webhook) Wants(eventType string) bool {
+	for _, e := range w.Events {
+		if e == eventType || e == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+// Package testutil holds the fixtures shared by the model and handler tests.
+package testutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/config"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// SetupDB points models.DB at a freshly migrated database. Tests run against
+// a SQLite file by default; set TEST_DATABASE_DRIVER and DATABASE_URL to run
+// them against Postgres or MySQL instead.
+func SetupDB(t *testing.T) *config.Config {
+	t.Helper()
+	cfg := &config.Config{
+		Server: config.ServerConfig{Production: true},
+		Database: config.DatabaseConfig{
+			Driver:   "sqlite",
+			Path:     filepath.Join(t.TempDir(), "test.db"),
+			MaxTries: 1,
+		},
+		App: config.AppConfig{TeamSize: 2},
+	}
+	if driver := os.Getenv("TEST_DATABASE_DRIVER"); driver != "" {
+		cfg.Database.Driver = driver
+	}
+	values.SetConfig(cfg)
+	models.Connect(cfg)
+	_, err := models.MigrateUp(models.DB)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := models.MigrateDown(models.DB, 1<<30)
+		require.NoError(t, err)
+		sqlDB, err := models.DB.DB()
+		require.NoError(t, err)
+		sqlDB.Close()
+	})
+	return cfg
+}
+
+// SetupAPI migrates a database like SetupDB and initialises what the
+// handlers share. The returned config can be adjusted before the routes
+// are loaded.
+func SetupAPI(t *testing.T) *config.Config {
+	t.Helper()
+	cfg := SetupDB(t)
+	cfg.Server.Security.JWTSecret = "test-secret"
+	cfg.App.TokenExpiry = time.Hour
+	cfg.App.Avatar = config.AvatarConfig{Storage: "local", Path: t.TempDir()}
+	utils.NewLogger(true)
+	shared.Init(&cfg.App)
+	gin.SetMode(gin.TestMode)
+	return cfg
+}
+
+// Router returns an engine set up like the server's, with each load
+// registering its routes under /api.
+func Router(loads ...func(*gin.RouterGroup)) *gin.Engine {
+	r := gin.New()
+	r.ContextWithFallback = true
+	api := r.Group("/api")
+	for _, load := range loads {
+		load(api)
+	}
+	return r
+}
+
+// CreateUser stores an active user whose password is "hunter2-" followed by
+// the username.
+func CreateUser(t *testing.T, username string) models.User {
+	t.Helper()
+	user := models.User{
+		Username:  username,
+		Email:     username + "@example.com",
+		Password:  "hunter2-" + username,
+		AvatarURL: "https://example.com/" + username + ".png",
+		Active:    true,
+	}
+	require.NoError(t, models.DB.Create(&user).Error)
+	return user
+}
+
+// CreateTeam creates a team led by leader with the members in it, leader
+// included.
+func CreateTeam(t *testing.T, db *gorm.DB, leader models.User, members ...models.User) models.Team {
+	t.Helper()
+	team := models.Team{Name: "pwners", LeaderID: leader.ID}
+	require.NoError(t, db.Create(&team).Error)
+	ids := []uint{leader.ID}
+	for _, member := range members {
+		ids = append(ids, member.ID)
+	}
+	require.NoError(t, db.Model(&models.User{}).Where("id IN ?", ids).Update("team_id", team.ID).Error)
+	return team
+}
+
+// Token issues a bearer token for the user as they are stored now.
+func Token(t *testing.T, user models.User) string {
+	t.Helper()
+	require.NoError(t, models.DB.First(&user, user.ID).Error)
+	var teamID uint
+	if user.TeamID != nil {
+		teamID = *user.TeamID
+	}
+	token, err := utils.GenerateJWT(teamID, user.ID, user.Username, user.TokenVersion, false, values.GetConfig().Server.Security.JWTSecret)
+	require.NoError(t, err)
+	return token
+}
+
+// Do sends a request to r with an optional bearer token and JSON body.
+func Do(r http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// AuditLog returns an audit entry carrying the request ID and, when the
+// request is authenticated, who made it. Traced requests also carry their
+// trace and span IDs so entries can be matched to spans.
+func AuditLog(ctx *gin.Context) *logrus.Entry {
+	fields := logrus.Fields{
+		"type":       "audit",
+		"request_id": ctx.GetString("request_id"),
+	}
+	if actor := ctx.GetString("admin_actor"); actor != "" {
+		fields["actor"] = actor
+	} else if username := ctx.GetString("username"); username != "" {
+		fields["actor"] = "user:" + username
+	}
+	if userID := ctx.GetUint("user_id"); userID != 0 {
+		fields["actor_id"] = userID
+	}
+	if traceID, spanID := tracing.IDs(ctx.Request.Context()); traceID != "" {
+		fields["trace_id"] = traceID
+		fields["span_id"] = spanID
+	}
+	return Logger.WithContext(ctx.Request.Context()).WithFields(fields)
+}
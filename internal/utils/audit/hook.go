@@ -0,0 +1,171 @@
+// Package audit persists audit log entries to the audit_events table.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// columns are the fields stored in their own columns rather than in the
+// JSON blob.
+var columns = map[string]bool{
+	"type": true, "event": true, "status": true, "reason": true, "ip": true,
+	"actor": true, "actor_id": true, "request_id": true, "team_id": true,
+	"user_id": true, "target_user_id": true,
+}
+
+// Dropped counts the audit events discarded because the queue was full.
+var Dropped = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "rodan",
+	Name:      "audit_events_dropped_total",
+	Help:      "Audit events dropped because the persistence queue was full.",
+})
+
+// Hook is a logrus hook that queues every entry with type=audit for
+// persistence. Run drains the queue. When the queue is full the entry is
+// dropped rather than stalling the request that logged it.
+type Hook struct {
+	events chan models.AuditEvent
+}
+
+func NewHook(buffer int) *Hook {
+	return &Hook{events: make(chan models.AuditEvent, buffer)}
+}
+
+func (h *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	if entry.Data["type"] != "audit" {
+		return nil
+	}
+	select {
+	case h.events <- buildEvent(entry):
+	default:
+		Dropped.Inc()
+		// logging through logrus here would feed the hook again
+		fmt.Fprintf(os.Stderr, "[AUDIT] queue full, dropped %v event\n", entry.Data["event"])
+	}
+	return nil
+}
+
+// Run writes queued events until the context is cancelled, then flushes
+// whatever is still queued.
+func (h *Hook) Run(ctx context.Context) {
+	for {
+		select {
+		case e := <-h.events:
+			store(e)
+		case <-ctx.Done():
+			for {
+				select {
+				case e := <-h.events:
+					store(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func store(e models.AuditEvent) {
+	if err := models.AppendAuditEvent(&e); err != nil {
+		// logging through logrus here would feed the hook again
+		fmt.Fprintf(os.Stderr, "[AUDIT] failed to persist %s event: %v\n", e.Event, err)
+	}
+}
+
+func buildEvent(entry *logrus.Entry) models.AuditEvent {
+	data := entry.Data
+	e := models.AuditEvent{
+		CreatedAt: entry.Time,
+		Level:     entry.Level.String(),
+		Event:     str(data["event"]),
+		Status:    str(data["status"]),
+		Reason:    str(data["reason"]),
+		Actor:     str(data["actor"]),
+		ActorID:   id(data["actor_id"]),
+		TeamID:    id(data["team_id"]),
+		IP:        str(data["ip"]),
+		RequestID: str(data["request_id"]),
+		Message:   entry.Message,
+	}
+	if target, ok := data["target_user_id"]; ok {
+		e.TargetUserID = id(target)
+	} else {
+		e.TargetUserID = id(data["user_id"])
+	}
+	extra := map[string]any{}
+	for key, value := range data {
+		if columns[key] {
+			continue
+		}
+		switch v := value.(type) {
+		case error:
+			extra[key] = v.Error()
+		case func() string:
+			extra[key] = v()
+		default:
+			if _, err := json.Marshal(v); err != nil {
+				extra[key] = fmt.Sprint(v)
+			} else {
+				extra[key] = v
+			}
+		}
+	}
+	if len(extra) > 0 {
+		if raw, err := json.Marshal(extra); err == nil {
+			e.Fields = string(raw)
+		}
+	}
+	return e
+}
+
+func str(v any) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func id(v any) *uint {
+	var n uint64
+	switch t := v.(type) {
+	case uint:
+		n = uint64(t)
+	case *uint:
+		if t == nil {
+			return nil
+		}
+		n = uint64(*t)
+	case int:
+		n = uint64(t)
+	case uint64:
+		n = t
+	case string:
+		parsed, err := strconv.ParseUint(t, 10, 64)
+		if err != nil {
+			return nil
+		}
+		n = parsed
+	default:
+		return nil
+	}
+	if n == 0 {
+		return nil
+	}
+	out := uint(n)
+	return &out
+}
@@ -0,0 +1,153 @@
+package avatar
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // registered for Process
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+// Sizes are the square edge lengths, in pixels, every avatar is stored in.
+var Sizes = []int{64, 256}
+
+// DefaultSize is the size avatar URLs in API responses point at.
+const DefaultSize = 256
+
+// maxDimension bounds the decoded image so a small file cannot expand into
+// gigabytes of pixels.
+const maxDimension = 4096
+
+var (
+	ErrTooLarge    = errors.New("avatar file is too large")
+	ErrUnsupported = errors.New("avatar must be a PNG, JPEG or GIF image")
+	ErrInvalid     = errors.New("avatar is not a readable image")
+)
+
+// Process reads an upload of at most maxSize bytes, crops it to a centred
+// square and returns it re-encoded as PNG in each of Sizes. Re-encoding drops
+// any metadata the original carried.
+func Process(r io.Reader, maxSize int64) (map[int][]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrTooLarge
+	}
+	switch http.DetectContentType(data) {
+	case "image/png", "image/jpeg", "image/gif":
+	default:
+		return nil, ErrUnsupported
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width < 1 || cfg.Height < 1 || cfg.Width > maxDimension || cfg.Height > maxDimension {
+		return nil, ErrInvalid
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	square := cropSquare(img)
+	files := make(map[int][]byte, len(Sizes))
+	for _, size := range Sizes {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, resize(square, size)); err != nil {
+			return nil, err
+		}
+		files[size] = buf.Bytes()
+	}
+	return files, nil
+}
+
+// cropSquare copies the centred square of img into an RGBA image.
+func cropSquare(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	edge := min(b.Dx(), b.Dy())
+	origin := image.Pt(b.Min.X+(b.Dx()-edge)/2, b.Min.Y+(b.Dy()-edge)/2)
+	square := image.NewRGBA(image.Rect(0, 0, edge, edge))
+	draw.Draw(square, square.Bounds(), img, origin, draw.Src)
+	return square
+}
+
+// resize scales a square image to size×size, averaging the source pixels
+// under each target pixel when shrinking and repeating them when growing.
+func resize(src *image.RGBA, size int) *image.RGBA {
+	edge := src.Bounds().Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := range size {
+		y0, y1 := span(y, edge, size)
+		for x := range size {
+			x0, x1 := span(x, edge, size)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := range sum {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			off := y*dst.Stride + x*4
+			for c := range sum {
+				dst.Pix[off+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// span is the range of source pixels covered by target pixel i.
+func span(i, edge, size int) (int, int) {
+	lo := i * edge / size
+	hi := (i + 1) * edge / size
+	return lo, max(hi, lo+1)
+}
+
+// FileKey is where the given size of the avatar stored under key lives.
+func FileKey(key string, size int) string {
+	return fmt.Sprintf("%s/%d.png", key, size)
+}
+
+// NearestSize is the stored size that best serves a request for n pixels:
+// the smallest one at least as large, or the largest there is.
+func NearestSize(n int) int {
+	for _, size := range Sizes {
+		if size >= n {
+			return size
+		}
+	}
+	return Sizes[len(Sizes)-1]
+}
+
+// Save puts every size of a processed avatar into store under a key below
+// prefix, such as "users/42", and returns the key. The key changes with the
+// content, so stored files can be cached forever.
+func Save(ctx context.Context, store Store, prefix string, files map[int][]byte) (string, error) {
+	sum := sha256.Sum256(files[DefaultSize])
+	key := prefix + "/" + hex.EncodeToString(sum[:8])
+	for size, data := range files {
+		if err := store.Put(ctx, FileKey(key, size), data); err != nil {
+			return "", err
+		}
+	}
+	return key, nil
+}
+
+// Remove deletes every size of the avatar stored under key.
+func Remove(ctx context.Context, store Store, key string) error {
+	var errs []error
+	for _, size := range Sizes {
+		errs = append(errs, store.Delete(ctx, FileKey(key, size)))
+	}
+	return errors.Join(errs...)
+}
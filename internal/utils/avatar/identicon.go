@@ -0,0 +1,39 @@
+package avatar
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+var identiconBackground = color.RGBA{R: 0xf0, G: 0xf0, B: 0xf0, A: 0xff}
+
+// Identicon draws the generated avatar for seed, a size×size PNG of a
+// mirrored 5×5 pattern. The same seed always gives the same image.
+func Identicon(seed string, size int) ([]byte, error) {
+	sum := sha256.Sum256([]byte(seed))
+	fg := color.RGBA{R: 48 + sum[29]%160, G: 48 + sum[30]%160, B: 48 + sum[31]%160, A: 0xff}
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: identiconBackground}, image.Point{}, draw.Src)
+	cell := size / 6
+	margin := (size - 5*cell) / 2
+	for i := range 15 {
+		if sum[i]&1 == 0 {
+			continue
+		}
+		// the three left columns are drawn and mirrored onto the right
+		col, row := i/5, i%5
+		for _, c := range []int{col, 4 - col} {
+			r := image.Rect(margin+c*cell, margin+row*cell, margin+(c+1)*cell, margin+(row+1)*cell)
+			draw.Draw(img, r, &image.Uniform{C: fg}, image.Point{}, draw.Src)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,84 @@
+package avatar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/intraware/rodan-authify/internal/config"
+)
+
+// LocalPrefix is where the server itself serves a local store when no
+// public-url is configured.
+const LocalPrefix = "/avatars"
+
+// Store keeps the encoded avatar files.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Delete(ctx context.Context, key string) error
+	// URL is where clients fetch the file stored under key.
+	URL(key string) string
+}
+
+// NewStore opens the storage backend the config names.
+func NewStore(cfg config.AvatarConfig) (Store, error) {
+	switch cfg.Storage {
+	case "local":
+		publicURL := cfg.PublicURL
+		if publicURL == "" {
+			publicURL = LocalPrefix
+		}
+		return &localStore{dir: cfg.Path, publicURL: strings.TrimRight(publicURL, "/")}, nil
+	case "s3":
+		return newS3Store(cfg.S3, cfg.PublicURL), nil
+	default:
+		return nil, fmt.Errorf("unknown avatar storage: %s", cfg.Storage)
+	}
+}
+
+type localStore struct {
+	dir       string
+	publicURL string
+}
+
+func (s *localStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+func (s *localStore) Put(_ context.Context, key string, data []byte) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// write then rename, so readers never see half a file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *localStore) Delete(_ context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *localStore) URL(key string) string {
+	return s.publicURL + "/" + key
+}
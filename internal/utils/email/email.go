@@ -1,13 +1,19 @@
 package email
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"html/template"
 	email_smtp "net/smtp"
+	"os"
 	"time"
 
+	"github.com/intraware/rodan-authify/internal/tracing"
 	"github.com/intraware/rodan-authify/internal/utils/email/microsoft"
 	"github.com/intraware/rodan-authify/internal/utils/email/smtp"
 	"github.com/intraware/rodan-authify/internal/utils/values"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type EmailDelivery interface {
@@ -27,7 +33,7 @@ func NewEmail() (*Email, error) {
 	var delivery EmailDelivery
 	var err error
 	switch emailCfg.Provider.Type {
-	case "microsoft":
+	case "microsoft", "microsoft-graph":
 		msCfg := emailCfg.Provider
 		delivery, err = microsoft.NewEmailDeliveryClient(
 			emailCfg.AgentEmail,
@@ -55,3 +61,58 @@ func NewEmail() (*Email, error) {
 		limiter:       limiter,
 	}, nil
 }
+
+// CheckConfig reports whether the configured provider has what it needs to
+// send mail. It does not contact the provider.
+func CheckConfig() error {
+	emailCfg := values.GetConfig().App.Email
+	if !emailCfg.Enabled {
+		return nil
+	}
+	provider := emailCfg.Provider
+	switch provider.Type {
+	case "microsoft", "microsoft-graph":
+		if provider.TenantID == "" || provider.ClientID == "" || provider.ClientSecret == "" {
+			return fmt.Errorf("microsoft provider requires tenant-id, client_id and client_secret")
+		}
+	case "smtp":
+		if provider.Host == "" || provider.Port == 0 {
+			return fmt.Errorf("smtp provider requires host and port")
+		}
+	default:
+		return fmt.Errorf("unknown email provider: %s", provider.Type)
+	}
+	if emailCfg.AgentEmail == "" {
+		return fmt.Errorf("agent-email is not set")
+	}
+	if _, err := os.Stat(emailCfg.EmailTemplate); err != nil {
+		return fmt.Errorf("email template is not readable: %w", err)
+	}
+	return nil
+}
+
+// Send renders the HTML template file with data and delivers it to the
+// address through the configured provider.
+func Send(ctx context.Context, to, subject, templateFile string, data any) error {
+	emailCfg := values.GetConfig().App.Email
+	emailObj, err := NewEmail()
+	if err != nil {
+		return fmt.Errorf("failed to init email service: %w", err)
+	}
+	if emailCfg.AllowedEmailCompilexRegex != nil && !emailCfg.AllowedEmailCompilexRegex.MatchString(to) {
+		return fmt.Errorf("email does not match allowed regex")
+	}
+	tmpl, err := template.ParseFiles(templateFile)
+	if err != nil {
+		return fmt.Errorf("failed to parse email template: %w", err)
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return fmt.Errorf("failed to execute email template: %w", err)
+	}
+	_, span := tracing.Start(ctx, "email.send")
+	span.SetAttributes(attribute.String("email.provider", emailCfg.Provider.Type))
+	err = emailObj.DeliveryAgent.SendEmail(to, subject, body.String())
+	tracing.End(span, err)
+	return err
+}
@@ -12,14 +12,18 @@ type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	TeamID   uint   `json:"team_id"`
+	Version  uint   `json:"token_version"`
+	MFA      bool   `json:"mfa"` // set when the login included a valid TOTP code
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(teamID, userID uint, username string, secret string) (string, error) {
+func GenerateJWT(teamID, userID uint, username string, version uint, mfa bool, secret string) (string, error) {
 	claims := &Claims{
 		UserID:   userID,
 		TeamID:   teamID,
 		Username: username,
+		Version:  version,
+		MFA:      mfa,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(values.GetConfig().App.TokenExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/sirupsen/logrus"
+)
+
+// adminPrincipal is who is calling the admin API: a staff user or an API key.
+type adminPrincipal struct {
+	userID uint
+	role   string
+	key    *models.AdminAPIKey
+	master bool // the bootstrap key from the config
+}
+
+func (p adminPrincipal) can(perm string) bool {
+	switch {
+	case p.master:
+		return true
+	case p.key != nil:
+		return p.key.Allows(perm)
+	default:
+		return models.RoleHasPermission(p.role, perm)
+	}
+}
+
+// AdminAuth accepts either an admin API key in x-api-key or the JWT of a
+// staff user. Staff sessions must have been opened with a TOTP code unless
+// app.admin.allow-without-mfa is set.
+func AdminAuth(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	fail := func(status int, reason, msg string) {
+		auditLog.WithFields(logrus.Fields{
+			"event":  "admin_auth",
+			"status": "failure",
+			"reason": reason,
+			"path":   ctx.FullPath(),
+			"ip":     ctx.ClientIP(),
+		}).Warn("Admin authentication failed")
+		ctx.JSON(status, gin.H{"error": msg})
+		ctx.Abort()
+	}
+	adminCfg := values.GetConfig().App.Admin
+	if raw := ctx.GetHeader("x-api-key"); raw != "" {
+		if adminCfg.HashedAPIKey != "" {
+			sum := sha256.Sum256([]byte(raw))
+			if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(sum[:])), []byte(adminCfg.HashedAPIKey)) == 1 {
+				ctx.Set("admin", adminPrincipal{master: true})
+				ctx.Set("admin_actor", "bootstrap-key")
+				ctx.Next()
+				return
+			}
+		}
+		key, ok := lookupAdminKey(ctx, raw)
+		if !ok {
+			fail(http.StatusUnauthorized, "invalid_api_key", "Invalid API key")
+			return
+		}
+		now := time.Now()
+		models.DB.WithContext(ctx).Model(&key).UpdateColumn("last_used_at", now)
+		ctx.Set("admin", adminPrincipal{key: &key})
+		ctx.Set("admin_actor", "key:"+key.Name)
+		ctx.Set("admin_key_id", key.ID)
+		ctx.Next()
+		return
+	}
+	tokenString := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if tokenString == "" || tokenString == ctx.GetHeader("Authorization") {
+		fail(http.StatusUnauthorized, "missing_credentials", "API key or bearer token required")
+		return
+	}
+	claims, err := utils.ValidateJWT(tokenString, values.GetConfig().Server.Security.JWTSecret)
+	if err != nil {
+		fail(http.StatusUnauthorized, "invalid_token", "Invalid token")
+		return
+	}
+	user, err := getUserFromContext(ctx, claims.UserID)
+	if err != nil || user.TokenVersion != claims.Version {
+		fail(http.StatusUnauthorized, "session_revoked", "Session has been revoked")
+		return
+	}
+	if !user.IsStaff() || user.Ban {
+		fail(http.StatusForbidden, "not_staff", "Insufficient permissions")
+		return
+	}
+	if !claims.MFA && !adminCfg.AllowWithoutMFA {
+		fail(http.StatusForbidden, "mfa_required", "Log in with a TOTP code to use the admin API")
+		return
+	}
+	ctx.Set("user_id", user.ID)
+	ctx.Set("username", user.Username)
+	ctx.Set("admin", adminPrincipal{userID: user.ID, role: user.Role})
+	ctx.Set("admin_actor", "user:"+user.Username)
+	ctx.Next()
+}
+
+// RequirePermission must run after AdminAuth.
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, _ := ctx.Get("admin")
+		if p, ok := principal.(adminPrincipal); ok && p.can(perm) {
+			ctx.Next()
+			return
+		}
+		utils.AuditLog(ctx).WithFields(logrus.Fields{
+			"event":      "admin_auth",
+			"status":     "failure",
+			"reason":     "forbidden",
+			"permission": perm,
+			"path":       ctx.FullPath(),
+			"ip":         ctx.ClientIP(),
+		}).Warn("Admin permission denied")
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		ctx.Abort()
+	}
+}
+
+func lookupAdminKey(ctx context.Context, raw string) (models.AdminAPIKey, bool) {
+	prefix, ok := models.AdminKeyPrefix(raw)
+	if !ok {
+		return models.AdminAPIKey{}, false
+	}
+	key, hit := shared.AdminKeyCache.Get(ctx, prefix)
+	if !hit {
+		if err := models.DB.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+			return key, false
+		}
+		shared.AdminKeyCache.Set(ctx, prefix, key)
+	}
+	return key, key.Active() && key.Matches(raw)
+}
@@ -9,27 +9,48 @@ import (
 	"github.com/intraware/rodan-authify/internal/utils/values"
 )
 
-func AuthRequired(ctx *gin.Context) {
+// authenticate validates the bearer token and puts its claims on the context;
+// auth_time is when the token was issued. It returns the message to reject
+// the request with, or "" on success.
+func authenticate(ctx *gin.Context) string {
 	authHeader := ctx.GetHeader("Authorization")
 	if authHeader == "" {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-		ctx.Abort()
-		return
+		return "Authorization header required"
 	}
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	if tokenString == authHeader {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
-		ctx.Abort()
-		return
+		return "Bearer token required"
 	}
 	claims, err := utils.ValidateJWT(tokenString, values.GetConfig().Server.Security.JWTSecret)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-		ctx.Abort()
-		return
+		return "Invalid token"
+	}
+	user, err := getUserFromContext(ctx, claims.UserID)
+	if err != nil || user.TokenVersion != claims.Version {
+		return "Session has been revoked"
 	}
 	ctx.Set("user_id", claims.UserID)
 	ctx.Set("username", claims.Username)
 	ctx.Set("team_id", claims.TeamID)
+	if claims.IssuedAt != nil {
+		ctx.Set("auth_time", claims.IssuedAt.Time)
+	}
+	return ""
+}
+
+func AuthRequired(ctx *gin.Context) {
+	if msg := authenticate(ctx); msg != "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+}
+
+// OptionalAuth identifies the caller when they send a valid token and lets
+// the request through anonymously otherwise, for routes whose response
+// depends on who is asking.
+func OptionalAuth(ctx *gin.Context) {
+	authenticate(ctx)
 	ctx.Next()
 }
@@ -1,30 +1,32 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/lifecycle"
 	"github.com/intraware/rodan-authify/internal/models"
 	"gorm.io/gorm"
 )
 
-func getUserFromContext(userID uint) (models.User, error) {
-	if u, ok := shared.UserCache.Get(userID); ok {
+func getUserFromContext(ctx context.Context, userID uint) (models.User, error) {
+	if u, ok := shared.UserCache.Get(ctx, userID); ok {
 		return u, nil
 	}
 	var user models.User
-	if err := models.DB.First(&user, userID).Error; err != nil {
+	if err := models.DB.WithContext(ctx).First(&user, userID).Error; err != nil {
 		return user, err
 	}
-	shared.UserCache.Set(user.ID, user)
+	shared.UserCache.Set(ctx, user.ID, user)
 	return user, nil
 }
 
-func checkAndUnblockBan(targetID uint, isUser bool, now int64, userRef *models.User) (bool, error) {
+func checkAndUnblockBan(ctx context.Context, targetID uint, isUser bool, now int64, userRef *models.User) (bool, error) {
 	var ban models.BanHistory
-	query := models.DB
+	query := models.DB.WithContext(ctx)
 	if isUser {
 		query = query.Where("user_id = ?", targetID)
 	} else {
@@ -38,42 +40,44 @@ func checkAndUnblockBan(targetID uint, isUser bool, now int64, userRef *models.U
 		if ban.ExpiresAt > now {
 			return true, nil
 		}
-		go func() {
+		// the request may be over before the unban is written
+		ctx := context.WithoutCancel(ctx)
+		lifecycle.Go(func() {
 			if isUser {
 				if userRef.Ban {
 					userRef.Ban = false
-					models.DB.Save(userRef)
-					shared.UserCache.Set(userRef.ID, *userRef)
+					models.DB.WithContext(ctx).Save(userRef)
+					shared.UserCache.Set(ctx, userRef.ID, *userRef)
 				}
 			} else {
 				var team models.Team
 				var ok bool
-				if team, ok = shared.TeamCache.Get(*ban.TeamID); !ok {
-					if err := models.DB.First(&team, ban.TeamID).Error; err != nil {
+				if team, ok = shared.TeamCache.Get(ctx, *ban.TeamID); !ok {
+					if err := models.DB.WithContext(ctx).First(&team, ban.TeamID).Error; err != nil {
 						return
 					}
 				}
 				if team.Ban {
 					team.Ban = false
-					models.DB.Save(&team)
-					shared.TeamCache.Set(team.ID, team)
+					models.DB.WithContext(ctx).Save(&team)
+					shared.TeamCache.Delete(ctx, team.ID)
 				}
 			}
-		}()
+		})
 	}
 	return false, nil
 }
 
 func BanMiddleware(ctx *gin.Context) {
 	userID := ctx.GetUint("user_id")
-	user, err := getUserFromContext(userID)
+	user, err := getUserFromContext(ctx, userID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
 		ctx.Abort()
 		return
 	}
 	now := time.Now().Unix()
-	if blocked, err := checkAndUnblockBan(user.ID, true, now, &user); err != nil {
+	if blocked, err := checkAndUnblockBan(ctx.Request.Context(), user.ID, true, now, &user); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check user ban"})
 		ctx.Abort()
 		return
@@ -83,7 +87,7 @@ func BanMiddleware(ctx *gin.Context) {
 		return
 	}
 	if user.TeamID != nil {
-		if blocked, err := checkAndUnblockBan(*user.TeamID, false, now, &user); err != nil {
+		if blocked, err := checkAndUnblockBan(ctx.Request.Context(), *user.TeamID, false, now, &user); err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check team ban"})
 			ctx.Abort()
 			return
@@ -15,3 +15,13 @@ func CacheMiddleware(ctx *gin.Context) {
 	ctx.Header("Expires", time.Now().Add(cache_time).Format(http.TimeFormat))
 	ctx.Next()
 }
+
+// ViewerCacheMiddleware is CacheMiddleware for responses that differ per
+// caller: only the caller's own cache may keep them.
+func ViewerCacheMiddleware(ctx *gin.Context) {
+	cache_time := values.GetConfig().App.CacheDuration
+	ctx.Header("Cache-Control", fmt.Sprintf("private,max-age=%.0f", cache_time.Seconds()))
+	ctx.Header("Vary", "Authorization")
+	ctx.Header("Expires", time.Now().Add(cache_time).Format(http.TimeFormat))
+	ctx.Next()
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// RequireFlag rejects the request while the named feature flag is off.
+func RequireFlag(name string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if shared.Flag(name) {
+			ctx.Next()
+			return
+		}
+		utils.AuditLog(ctx).WithFields(logrus.Fields{
+			"event":  "feature_disabled",
+			"status": "failure",
+			"reason": "flag_off",
+			"flag":   name,
+			"path":   ctx.FullPath(),
+			"ip":     ctx.ClientIP(),
+		}).Warn("Request blocked by feature flag")
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "This action is disabled right now"})
+		ctx.Abort()
+	}
+}
@@ -15,12 +15,13 @@ func Logger() gin.HandlerFunc {
 		ctx.Next()
 		status := ctx.Writer.Status()
 		fields := logrus.Fields{
-			"type":     "http",
-			"status":   status,
-			"method":   ctx.Request.Method,
-			"path":     ctx.Request.URL.Path,
-			"errors":   ctx.Errors.String(),
-			"time(µs)": time.Since(startTime).Microseconds(),
+			"type":       "http",
+			"request_id": ctx.GetString("request_id"),
+			"status":     status,
+			"method":     ctx.Request.Method,
+			"path":       ctx.Request.URL.Path,
+			"errors":     ctx.Errors.String(),
+			"time(µs)":   time.Since(startTime).Microseconds(),
 		}
 		if msg, ok := ctx.Value("message").(string); ok && msg != "" {
 			fields["message_context"] = msg
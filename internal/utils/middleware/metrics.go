@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/internal/metrics"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics records the count and latency of every request under its route
+// template, so that path parameters do not blow up the label set.
+func Metrics(ctx *gin.Context) {
+	start := time.Now()
+	ctx.Next()
+	route := ctx.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	status := strconv.Itoa(ctx.Writer.Status())
+	metrics.HTTPRequests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+	metrics.HTTPDuration.WithLabelValues(ctx.Request.Method, route, status).Observe(time.Since(start).Seconds())
+}
+
+// MetricsHandler serves the Prometheus registry, behind the configured token
+// when there is one.
+func MetricsHandler() gin.HandlerFunc {
+	handler := promhttp.Handler()
+	return func(ctx *gin.Context) {
+		if token := values.GetConfig().Server.Metrics.Token; token != "" {
+			if subtle.ConstantTimeCompare([]byte(ctx.GetHeader("Authorization")), []byte("Bearer "+token)) != 1 {
+				ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid metrics token"})
+				return
+			}
+		}
+		handler.ServeHTTP(ctx.Writer, ctx.Request)
+	}
+}
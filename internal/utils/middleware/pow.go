@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/pow"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/sirupsen/logrus"
+)
+
+// ProofOfWork requires a solved challenge from GET /auth/challenge in the
+// X-PoW-Challenge and X-PoW-Nonce headers. Each challenge is accepted once.
+func ProofOfWork(ctx *gin.Context) {
+	auditLog := utils.AuditLog(ctx)
+	fail := func(status int, reason, msg string) {
+		auditLog.WithFields(logrus.Fields{
+			"event":  "proof_of_work",
+			"status": "failure",
+			"reason": reason,
+			"path":   ctx.FullPath(),
+			"ip":     ctx.ClientIP(),
+		}).Warn("Proof of work rejected")
+		ctx.JSON(status, gin.H{"error": msg})
+		ctx.Abort()
+	}
+	token := ctx.GetHeader("X-PoW-Challenge")
+	nonce := ctx.GetHeader("X-PoW-Nonce")
+	if token == "" || nonce == "" {
+		fail(http.StatusPreconditionRequired, "missing_solution", "Proof of work required")
+		return
+	}
+	challenge, err := pow.Parse(token)
+	if err != nil {
+		fail(http.StatusBadRequest, "malformed_challenge", "Malformed challenge")
+		return
+	}
+	if err := challenge.Verify(pow.Key(values.GetConfig().Server.Security.JWTSecret)); err != nil {
+		fail(http.StatusForbidden, "invalid_challenge", "Invalid or expired challenge")
+		return
+	}
+	if !challenge.Solved(nonce) {
+		fail(http.StatusForbidden, "wrong_solution", "Challenge not solved")
+		return
+	}
+	if _, ok := shared.PoWChallengeCache.Take(ctx, challenge.ID); !ok {
+		fail(http.StatusForbidden, "challenge_reused", "Invalid or expired challenge")
+		return
+	}
+	ctx.Next()
+}
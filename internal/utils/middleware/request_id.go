@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{8,64}$`)
+
+// RequestID tags every request with an ID, reusing a well-formed
+// X-Request-ID from the proxy in front of us.
+func RequestID(ctx *gin.Context) {
+	id := ctx.GetHeader("X-Request-ID")
+	if !requestIDPattern.MatchString(id) {
+		random := make([]byte, 12)
+		rand.Read(random)
+		id = hex.EncodeToString(random)
+	}
+	ctx.Set("request_id", id)
+	ctx.Header("X-Request-ID", id)
+	ctx.Next()
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/intraware/rodan-authify/api/shared"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// StreamTicketAuth authenticates an event stream opened with a ?ticket= from
+// the stream ticket endpoint of the same scope, for clients such as a
+// browser EventSource that cannot set headers. The ticket is consumed.
+func StreamTicketAuth(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ticket, ok := shared.RedeemStreamTicket(ctx, ctx.Query("ticket"), scope)
+		if !ok {
+			utils.AuditLog(ctx).WithFields(logrus.Fields{
+				"event":  "stream_auth",
+				"status": "failure",
+				"reason": "invalid_ticket",
+				"scope":  scope,
+				"ip":     ctx.ClientIP(),
+			}).Warn("Event stream opened without a valid ticket")
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired stream ticket"})
+			ctx.Abort()
+			return
+		}
+		ctx.Set("user_id", ticket.UserID)
+		ctx.Set("username", ticket.Username)
+		if ticket.Actor != "" {
+			ctx.Set("admin_actor", ticket.Actor)
+		}
+		ctx.Next()
+	}
+}
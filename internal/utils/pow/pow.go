@@ -0,0 +1,116 @@
+// Package pow implements hashcash-style proof-of-work puzzles. A puzzle is a
+// signed, self-describing token; it is solved by finding a nonce for which
+// sha256(token + nonce) starts with the required number of zero bits.
+package pow
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed = errors.New("malformed challenge")
+	ErrSignature = errors.New("invalid challenge signature")
+	ErrExpired   = errors.New("challenge expired")
+)
+
+type Challenge struct {
+	ID         string
+	Difficulty int
+	ExpiresAt  int64
+	signature  string
+}
+
+// Key derives the signing key for challenges from the server secret.
+func Key(secret string) []byte {
+	sum := sha256.Sum256([]byte("pow:" + secret))
+	return sum[:]
+}
+
+// Issue creates a new challenge signed with the secret.
+func Issue(secret []byte, difficulty int, ttl time.Duration) (Challenge, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return Challenge{}, err
+	}
+	c := Challenge{
+		ID:         hex.EncodeToString(random),
+		Difficulty: difficulty,
+		ExpiresAt:  time.Now().Add(ttl).Unix(),
+	}
+	c.signature = c.sign(secret)
+	return c, nil
+}
+
+// Parse decodes a token produced by Challenge.Token. It does not verify it.
+func Parse(token string) (Challenge, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return Challenge{}, ErrMalformed
+	}
+	difficulty, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Challenge{}, ErrMalformed
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return Challenge{}, ErrMalformed
+	}
+	return Challenge{
+		ID:         parts[0],
+		Difficulty: difficulty,
+		ExpiresAt:  expiresAt,
+		signature:  parts[3],
+	}, nil
+}
+
+func (c Challenge) payload() string {
+	return fmt.Sprintf("%s.%d.%d", c.ID, c.Difficulty, c.ExpiresAt)
+}
+
+func (c Challenge) sign(secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(c.payload()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Token is the string handed to clients and hashed together with the nonce.
+func (c Challenge) Token() string {
+	return c.payload() + "." + c.signature
+}
+
+// Verify checks the signature and expiry of the challenge.
+func (c Challenge) Verify(secret []byte) error {
+	if !hmac.Equal([]byte(c.sign(secret)), []byte(c.signature)) {
+		return ErrSignature
+	}
+	if time.Now().Unix() > c.ExpiresAt {
+		return ErrExpired
+	}
+	return nil
+}
+
+// Solved reports whether the nonce solves the challenge.
+func (c Challenge) Solved(nonce string) bool {
+	sum := sha256.Sum256([]byte(c.Token() + nonce))
+	return leadingZeroBits(sum[:]) >= c.Difficulty
+}
+
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, v := range b {
+		if v != 0 {
+			return n + bits.LeadingZeros8(v)
+		}
+		n += 8
+	}
+	return n
+}
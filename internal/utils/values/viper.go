@@ -7,6 +7,7 @@ import (
 	"log"
 	"reflect"
 	"regexp"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/intraware/rodan-authify/internal/config"
@@ -14,6 +15,9 @@ import (
 )
 
 func reloadEqual(a, b any) bool {
+	if ta, ok := a.(time.Time); ok {
+		return ta.Equal(b.(time.Time))
+	}
 	va := reflect.ValueOf(a)
 	vb := reflect.ValueOf(b)
 	if va.Kind() == reflect.Ptr {
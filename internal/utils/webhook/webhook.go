@@ -0,0 +1,259 @@
+// Package webhook queues lifecycle events for registered webhooks and
+// delivers them with retries.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/intraware/rodan-authify/internal/events"
+	"github.com/intraware/rodan-authify/internal/models"
+	"github.com/intraware/rodan-authify/internal/tracing"
+	"github.com/intraware/rodan-authify/internal/utils"
+	"github.com/intraware/rodan-authify/internal/utils/values"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	batchSize  = 20
+	baseDelay  = 30 * time.Second
+	maxDelay   = time.Hour
+	claimLease = 5 * time.Minute // a claimed delivery is retried after this if the worker dies
+	queueSize  = 1024
+)
+
+// errRedirect is recorded when an endpoint answers with a redirect, which is
+// never followed so a hook cannot be bounced to an internal address.
+var errRedirect = errors.New("endpoint redirected, redirects are not followed")
+
+// queue holds published events until RunQueue records their deliveries, so
+// that publishing never waits on the database.
+var queue = make(chan events.Event, queueSize)
+
+// GenerateSecret returns a random signing secret for a new webhook.
+func GenerateSecret() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random), nil
+}
+
+// Sign computes the X-Rodan-Signature value for a payload sent at ts.
+func Sign(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", ts)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Enqueue queues the event for RunQueue. It is registered with
+// events.Subscribe and drops the event if the queue is full.
+func Enqueue(e events.Event) {
+	select {
+	case queue <- e:
+	default:
+		utils.Logger.WithFields(logrus.Fields{
+			"event_id": e.ID,
+			"event":    e.Type,
+		}).Error("Webhook queue full, event dropped")
+	}
+}
+
+// RunQueue records deliveries for queued events until the context is
+// cancelled, then records whatever is still queued.
+func RunQueue(ctx context.Context) {
+	for {
+		select {
+		case e := <-queue:
+			record(e)
+		case <-ctx.Done():
+			for {
+				select {
+				case e := <-queue:
+					record(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// record stores a pending delivery for every active webhook subscribed to
+// the event.
+func record(e events.Event) {
+	var hooks []models.Webhook
+	if err := models.DB.Where("active = ?", true).Find(&hooks).Error; err != nil {
+		utils.Logger.WithField("error", err.Error()).Error("Failed to load webhooks")
+		return
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	for _, hook := range hooks {
+		if !hook.Wants(e.Type) {
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			WebhookID:     hook.ID,
+			EventID:       e.ID,
+			EventType:     e.Type,
+			Payload:       string(payload),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: e.Time,
+		}
+		if err := models.DB.Create(&delivery).Error; err != nil {
+			utils.Logger.WithFields(logrus.Fields{
+				"webhook_id": hook.ID,
+				"event":      e.Type,
+				"error":      err.Error(),
+			}).Error("Failed to queue webhook delivery")
+		}
+	}
+}
+
+// RunWorker delivers due webhooks until the context is cancelled. Several
+// instances can run it at once; each delivery is claimed by one of them.
+func RunWorker(ctx context.Context, interval time.Duration) {
+	client := newClient(values.GetConfig().App.Webhooks.Timeout)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, delivery := range claimDue() {
+			deliver(ctx, client, delivery)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newClient returns the traced client deliveries are posted with. It gives
+// up after timeout and refuses to follow redirects.
+func newClient(timeout time.Duration) *http.Client {
+	client := tracing.HTTPClient()
+	client.Timeout = timeout
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return errRedirect
+	}
+	return client
+}
+
+func claimDue() []models.WebhookDelivery {
+	var due []models.WebhookDelivery
+	now := time.Now()
+	err := models.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+			Order("next_attempt_at").Limit(batchSize).Find(&due).Error; err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(due))
+		for i, d := range due {
+			ids[i] = d.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(claimLease)).Error
+	})
+	if err != nil {
+		utils.Logger.WithField("error", err.Error()).Error("Failed to claim webhook deliveries")
+		return nil
+	}
+	return due
+}
+
+// Backoff is the wait before the given retry: 30s, 1m, 2m, ... up to an hour.
+func Backoff(attempt int) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+func deliver(ctx context.Context, client *http.Client, delivery models.WebhookDelivery) {
+	cfg := values.GetConfig().App.Webhooks
+	var hook models.Webhook
+	if err := models.DB.First(&hook, delivery.WebhookID).Error; err != nil || !hook.Active {
+		models.DB.Model(&delivery).Updates(map[string]any{
+			"status":     models.DeliveryFailed,
+			"last_error": "webhook removed or disabled",
+		})
+		return
+	}
+	statusCode, err := post(ctx, client, hook, delivery)
+	delivery.Attempts++
+	updates := map[string]any{
+		"attempts":         delivery.Attempts,
+		"last_status_code": statusCode,
+		"last_error":       "",
+	}
+	log := utils.Logger.WithFields(logrus.Fields{
+		"webhook_id":  hook.ID,
+		"delivery_id": delivery.ID,
+		"event":       delivery.EventType,
+		"attempt":     delivery.Attempts,
+		"status_code": statusCode,
+	})
+	switch {
+	case err == nil:
+		now := time.Now()
+		updates["status"] = models.DeliverySucceeded
+		updates["delivered_at"] = &now
+		log.Debug("Webhook delivered")
+	case delivery.Attempts >= cfg.MaxAttempts:
+		updates["status"] = models.DeliveryFailed
+		updates["last_error"] = err.Error()
+		log.WithField("error", err.Error()).Warn("Webhook delivery failed permanently")
+	default:
+		updates["next_attempt_at"] = time.Now().Add(Backoff(delivery.Attempts))
+		updates["last_error"] = err.Error()
+		log.WithField("error", err.Error()).Info("Webhook delivery failed, will retry")
+	}
+	models.DB.Model(&delivery).Updates(updates)
+}
+
+func post(ctx context.Context, client *http.Client, hook models.Webhook, delivery models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "rodan-authify-webhook")
+	req.Header.Set("X-Rodan-Event", delivery.EventType)
+	req.Header.Set("X-Rodan-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Rodan-Timestamp", strconv.FormatInt(ts, 10))
+	req.Header.Set("X-Rodan-Signature", Sign(hook.Secret, ts, body))
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
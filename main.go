@@ -4,5 +4,5 @@ package main
 import "github.com/intraware/rodan-authify/cmd"
 
 func main() {
-	cmd.Run()
+	cmd.Execute()
 }
@@ -81,6 +81,14 @@ func (c *TinyLFU) Del(key string) {
 	c.lfu.Del(key)
 }
 
+// Evictions returns how many entries were evicted for lack of space.
+func (c *TinyLFU) Evictions() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lfu.Evictions()
+}
+
 func (c *TinyLFU) GetAllKeys() []string {
 	return c.lfu.GetKeys()
 }
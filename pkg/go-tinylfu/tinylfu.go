@@ -37,6 +37,8 @@ type T struct {
 
 	lru  *lruCache
 	slru *slruCache
+
+	evictions uint64
 }
 
 func New(size int, samples int) *T {
@@ -65,6 +67,11 @@ func New(size int, samples int) *T {
 	}
 }
 
+// Evictions returns how many items were pushed out to make room for others.
+func (t *T) Evictions() uint64 {
+	return t.evictions
+}
+
 func (t *T) onEvict(item *Item) {
 	if item.OnEvict != nil {
 		item.OnEvict()
@@ -134,6 +141,8 @@ func (t *T) Set(newItem *Item) {
 		t.slru.add(oldItem)
 		return
 	}
+	// from here on either oldItem or the victim leaves the cache
+	t.evictions++
 
 	if !t.bouncer.allow(oldItem.keyh) {
 		t.onEvict(oldItem)